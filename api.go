@@ -0,0 +1,34 @@
+package ticketswitch
+
+import "context"
+
+// API is the set of calls a Client makes to the ticketswitch API. Code that
+// depends on API rather than *Client can be tested with the implementation in
+// the mock subpackage.
+type API interface {
+	Test(ctx context.Context) (*User, error)
+	ListEvents(ctx context.Context, params *ListEventsParams) (*ListEventsResults, error)
+	GetEvents(ctx context.Context, eventIDs []string, params *UniversalParams) (map[string]*Event, error)
+	GetEvent(ctx context.Context, eventID string, params *UniversalParams) (*Event, error)
+	ListPerformances(ctx context.Context, params *ListPerformancesParams) (*ListPerformancesResults, error)
+	ListComponentPerformances(ctx context.Context, event *Event, params *ListPerformancesParams) (map[string]*ListPerformancesResults, error)
+	ListPerformanceTimes(ctx context.Context, params *ListPerformancesParams) (*ListPerformanceTimesResults, error)
+	GetAvailability(ctx context.Context, perf string, params *GetAvailabilityParams) (*AvailabilityResult, error)
+	GetProductAvailability(ctx context.Context, event *Event, params *GetAvailabilityParams) (*ProductAvailability, error)
+	GetDiscounts(ctx context.Context, perf, ticketTypeCode, priceBandCode string, params *UniversalParams) (*DiscountsResult, error)
+	GetSources(ctx context.Context, params *UniversalParams) (*SourcesResult, error)
+	GetSendMethods(ctx context.Context, perf string, params *UniversalParams) (*SendMethodsResults, error)
+	GetTrolley(ctx context.Context, params *TrolleyParams) (*TrolleyResult, error)
+	MakeReservation(ctx context.Context, params *MakeReservationParams) (*ReservationResult, error)
+	MakeMetaReservation(ctx context.Context, params *MetaReservationParams) (*MetaReservationResult, error)
+	ReleaseReservation(ctx context.Context, params *TransactionParams) (bool, error)
+	MakePurchase(ctx context.Context, params *MakePurchaseParams) (*MakePurchaseResult, error)
+	Callback(ctx context.Context, params *CallbackParams) (*MakePurchaseResult, error)
+	GetStatus(ctx context.Context, params *TransactionParams) (*StatusResult, error)
+	GetSelfPrintVoucher(ctx context.Context, order *Order) (*Voucher, error)
+	Cancel(ctx context.Context, params *CancellationParams) (*CancellationResult, error)
+	CancelOrders(ctx context.Context, params *CancelOrdersParams) (*CancelOrdersResult, error)
+	EmailCheck(ctx context.Context, params *EmailCheckParams) error
+}
+
+var _ API = (*Client)(nil)
@@ -2,6 +2,7 @@ package ticketswitch
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
@@ -10,25 +11,37 @@ import (
 // defined by price point. The price of a price band is defined by its default
 // discount code, this is normally the most expensive discount option available
 type PriceBand struct {
-	Code                     string                `json:"price_band_code"`
-	Desc                     string                `json:"price_band_desc"`
-	DiscountCode             string                `json:"discount_code"`
-	DiscountDesc             string                `json:"discount_desc"`
-	NumberAvailable          int                   `json:"number_available"`
-	Seatprice                decimal.Decimal       `json:"sale_seatprice"`
-	Surcharge                decimal.Decimal       `json:"sale_surcharge"`
-	AllowsLeavingSingleSeats string                `json:"allows_leaving_single_seats"`
-	IsOffer                  bool                  `json:"is_offer"`
-	NonOfferSeatprice        decimal.Decimal       `json:"non_offer_sale_seatprice"`
-	NonOfferSurcharge        decimal.Decimal       `json:"non_offer_sale_surcharge"`
-	PercentageSaving         decimal.Decimal       `json:"percentage_saving"`
-	AbsoluteSaving           decimal.Decimal       `json:"absolute_saving"`
-	FreeSeatBlocksRaw        map[string][][]string `json:"free_seat_blocks"`
-	RestrictedViewSeatsRaw   []string              `json:"restricted_view_seats_raw"`
-	SeatsByTextMessageRaw    []string              `json:"seats_by_text_message_raw"`
-	PredictedUserCommission  UserCommission        `json:"predicted_user_commission"`
-	PossibleDiscounts        DiscountsHolder       `json:"possible_discounts"`
-	AvailDetails             []AvailabilityDetail  `json:"avail_detail"`
+	Code                       string                `json:"price_band_code"`
+	Desc                       string                `json:"price_band_description"`
+	DiscountCode               string                `json:"discount_code"`
+	DiscountDesc               string                `json:"discount_desc"`
+	NumberAvailable            int                   `json:"number_available"`
+	Seatprice                  decimal.Decimal       `json:"sale_seatprice"`
+	Surcharge                  decimal.Decimal       `json:"sale_surcharge"`
+	SeatpriceInDesired         decimal.Decimal       `json:"sale_seatprice_in_desired"`
+	SurchargeInDesired         decimal.Decimal       `json:"sale_surcharge_in_desired"`
+	AllowsLeavingSingleSeats   string                `json:"allows_leaving_single_seats"`
+	IsOffer                    bool                  `json:"is_offer"`
+	NonOfferSeatprice          decimal.Decimal       `json:"non_offer_sale_seatprice"`
+	NonOfferSurcharge          decimal.Decimal       `json:"non_offer_sale_surcharge"`
+	NonOfferSeatpriceInDesired decimal.Decimal       `json:"non_offer_sale_seatprice_in_desired"`
+	NonOfferSurchargeInDesired decimal.Decimal       `json:"non_offer_sale_surcharge_in_desired"`
+	PercentageSaving           decimal.Decimal       `json:"percentage_saving"`
+	AbsoluteSaving             decimal.Decimal       `json:"absolute_saving"`
+	AbsoluteSavingInDesired    decimal.Decimal       `json:"absolute_saving_in_desired"`
+	FreeSeatBlocksRaw          map[string][][]string `json:"free_seat_blocks"`
+	RestrictedViewSeatsRaw     []string              `json:"restricted_view_seats_raw"`
+	SeatsByTextMessageRaw      []string              `json:"seats_by_text_message_raw"`
+	PredictedUserCommission    UserCommission        `json:"predicted_user_commission"`
+	PossibleDiscounts          DiscountsHolder       `json:"possible_discounts"`
+	AvailDetails               []AvailabilityDetail  `json:"avail_detail"`
+}
+
+// AssignDiscounts works out the cheapest eligible discount for each member of
+// the party from the price band's possible discounts. The availability must
+// have been requested with discounts.
+func (band *PriceBand) AssignDiscounts(party Party) (*DiscountAssignment, error) {
+	return AssignDiscounts(party, band.PossibleDiscounts.Discounts)
 }
 
 // TicketType describes a sub set of available tickets defined by some
@@ -54,6 +67,7 @@ type AvailabilityResult struct {
 	BackendThrottleFailed       bool                `json:"backend_throttle_failed"`
 	ContiguousSeatSelectionOnly bool                `json:"contiguous_seat_selection_only"`
 	CurrencyCode                string              `json:"currency_code"`
+	DesiredCurrencyCode         string              `json:"desired_currency_code"`
 	CurrencyDetails             map[string]Currency `json:"currency_details"`
 	ValidQuantities             []int               `json:"valid_quantities"`
 }
@@ -67,12 +81,23 @@ type GetAvailabilityParams struct {
 	ExampleSeats   bool
 	SeatBlocks     bool
 	UserCommission bool
+	// for events that need a departure date or duration.
+	DepartureDate time.Time
+	Duration      int
 }
 
 // Params returns the call parameters as a map
 func (params *GetAvailabilityParams) Params() map[string]string {
 	values := make(map[string]string)
 
+	if !params.DepartureDate.IsZero() {
+		values["departure_date"] = params.DepartureDate.Format("20060102")
+	}
+
+	if params.Duration > 0 {
+		values["duration"] = strconv.Itoa(params.Duration)
+	}
+
 	if params.NumberOfSeats > 0 {
 		values["number_of_seats"] = strconv.Itoa(params.NumberOfSeats)
 	}
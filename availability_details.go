@@ -1,40 +1,311 @@
 package ticketswitch
 
 import (
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/shopspring/decimal"
 )
 
-// AvailabilityDetails describes an availability summary for a performance.
-// This information is generated using cached data.
+// AvailabilityDetails summarises the availability of an event or performance
+// broken down by ticket type and price band.
+//
+// This information is generated using cached data collected when making
+// actual calls to the backend system, and should not be considered accurate.
 type AvailabilityDetails struct {
-	// ticket type array, if present
-	TicketType []TicketType `json:"ticket_type"`
+	TicketTypes []AvailabilityDetailsTicketType `json:"ticket_type"`
+}
+
+// AvailabilityDetailsTicketType holds the cached availability details for the
+// price bands of a ticket type.
+type AvailabilityDetailsTicketType struct {
 	// identifier of the ticket type.
-	TicketTypeCode string
+	Code string `json:"ticket_type_code"`
 	// human readable description of the ticket type.
-	TicketTypeDescription string
+	Desc string `json:"ticket_type_desc"`
+	// price bands within the ticket type.
+	PriceBands []AvailabilityDetailsPriceBand `json:"price_band"`
+}
+
+// AvailabilityDetailsPriceBand holds the cached availability details for a
+// price band.
+type AvailabilityDetailsPriceBand struct {
 	// identifier of the price band.
-	PriceBandCode string
+	Code string `json:"price_band_code"`
 	// human readable description of the price band.
-	PriceBandDescription string
+	Desc string `json:"price_band_desc"`
+	// details of the price band for different sets of dates and weekdays.
+	Details []AvailabilityDetail `json:"avail_detail"`
+}
+
+// AvailabilityDetail describes the cached price and availability of a price
+// band over a range of dates and weekdays.
+type AvailabilityDetail struct {
+	// the currency of the prices.
+	AvailabilityCurrencyCode string `json:"avail_currency_code"`
+	// the first and last dates this detail applies to.
+	AvailableDates AvailableDates `json:"available_dates"`
+	// bitmask of the weekdays this detail applies to, see Weekdays.
+	AvailableWeekdays    int             `json:"available_weekdays_bitmask"`
+	CombinedTaxComponent decimal.Decimal `json:"combined_tax_component"`
+	// the currency the customer is expecting, empty when none was requested.
+	DesiredCurrencyCode  string `json:"desired_currency_code"`
+	DiscountSemanticType string `json:"discount_semantic_type"`
 	// price of an individual seat.
-	SeatPrice decimal.Decimal
-	// additional charges per seat.
-	Surcharge decimal.Decimal
+	Seatprice decimal.Decimal `json:"seatprice"`
 	// the non-offer price of an individual seat.
-	FullSeatPrice decimal.Decimal
+	FullSeatprice decimal.Decimal `json:"full_seatprice"`
+	// price band code including any pool or allocation suffix.
+	SuffixedPriceBandCode string `json:"suffixed_price_band_code"`
+	// additional charges per seat.
+	Surcharge decimal.Decimal `json:"surcharge"`
 	// the non-offer additional charges per seat.
-	FullSurcharge decimal.Decimal
-	// the currency of the prices.
-	CurrencyCode string
-	// the first date and time this combination of ticket type and price
-	// band is available from.
-	FirstDate time.Time
-	// the latest date and time this combination of ticket type and price
-	// band is available from.
-	LastDate time.Time
+	FullSurcharge            decimal.Decimal `json:"full_surcharge"`
+	SurchargeTaxSubComponent decimal.Decimal `json:"surcharge_tax_sub_component"`
+	// the prices in the desired currency, when one was requested.
+	SeatpriceInDesired                decimal.Decimal `json:"seatprice_in_desired"`
+	SurchargeInDesired                decimal.Decimal `json:"surcharge_in_desired"`
+	CombinedTaxComponentInDesired     decimal.Decimal `json:"combined_tax_component_in_desired"`
+	SurchargeTaxSubComponentInDesired decimal.Decimal `json:"surcharge_tax_sub_component_in_desired"`
+	// the amount of money saved by an offer.
+	AbsoluteSaving decimal.Decimal `json:"absolute_saving"`
+	// the amount of money saved by an offer, as a percentage of the original
+	// price.
+	PercentageSaving decimal.Decimal `json:"percentage_saving"`
 	// list of valid number of tickets available for selection.
-	ValidQuantities []int
+	ValidQuantities []int `json:"valid_quantities"`
+	// the number of tickets last seen available.
+	CachedNumberAvailable int `json:"cached_number_available"`
+}
+
+// AvailableDates describes the range of dates an AvailabilityDetail applies
+// to.
+type AvailableDates struct {
+	First string `json:"first_yyyymmdd"`
+	Last  string `json:"last_yyyymmdd"`
+}
+
+// availableDatesLayout is the layout of the dates in AvailableDates.
+const availableDatesLayout = "20060102"
+
+// FirstDate returns the first date the availability applies to. A zero time
+// is returned when the API didn't supply a date.
+func (dates AvailableDates) FirstDate() (time.Time, error) {
+	return parseAvailableDate(dates.First)
+}
+
+// LastDate returns the last date the availability applies to. A zero time is
+// returned when the API didn't supply a date.
+func (dates AvailableDates) LastDate() (time.Time, error) {
+	return parseAvailableDate(dates.Last)
+}
+
+// Contains checks if the calendar date of the given time falls between the
+// first and last dates inclusively. A missing bound is treated as open ended,
+// while a malformed one never contains anything.
+func (dates AvailableDates) Contains(date time.Time) bool {
+	first, err := dates.FirstDate()
+	if err != nil {
+		return false
+	}
+	last, err := dates.LastDate()
+	if err != nil {
+		return false
+	}
+
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if !first.IsZero() && day.Before(first) {
+		return false
+	}
+	if !last.IsZero() && day.After(last) {
+		return false
+	}
+	return true
+}
+
+func parseAvailableDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(availableDatesLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ticketswitch: invalid available date %q: %w", value, err)
+	}
+	return date, nil
+}
+
+// Weekdays returns the days of the week decoded from the
+// available_weekdays_bitmask. The least significant bit of the mask is
+// Sunday, matching the numbering of time.Weekday.
+func (detail *AvailabilityDetail) Weekdays() []time.Weekday {
+	weekdays := make([]time.Weekday, 0, 7)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if detail.AvailableOnWeekday(day) {
+			weekdays = append(weekdays, day)
+		}
+	}
+	return weekdays
+}
+
+// AvailableOnWeekday checks if the detail applies to the given day of the
+// week.
+func (detail *AvailabilityDetail) AvailableOnWeekday(day time.Weekday) bool {
+	return detail.AvailableWeekdays&(1<<uint(day)) != 0
+}
+
+// AvailableOn checks if the detail applies to the given date, both by its
+// available dates and by its available weekdays. The date is compared in its
+// own location.
+func (detail *AvailabilityDetail) AvailableOn(date time.Time) bool {
+	return detail.AvailableOnWeekday(date.Weekday()) && detail.AvailableDates.Contains(date)
+}
+
+// CombinedPrice returns the price of a single seat including any surcharge.
+func (detail *AvailabilityDetail) CombinedPrice() decimal.Decimal {
+	return detail.Seatprice.Add(detail.Surcharge)
+}
+
+// CachedPriceBand is a price band from cached availability details along with
+// the ticket type it belongs to and the detail that matched a query.
+type CachedPriceBand struct {
+	TicketTypeCode string
+	TicketTypeDesc string
+	PriceBandCode  string
+	PriceBandDesc  string
+	Detail         AvailabilityDetail
+}
+
+// priceBands returns every ticket type and price band combination with a
+// detail accepted by match, ordered from cheapest to most expensive. When a
+// price band has more than one accepted detail only the cheapest is returned.
+func (details *AvailabilityDetails) priceBands(match func(*AvailabilityDetail) bool) []CachedPriceBand {
+	bands := make([]CachedPriceBand, 0)
+	for _, ticketType := range details.TicketTypes {
+		for _, priceBand := range ticketType.PriceBands {
+			var cheapest *AvailabilityDetail
+			for i := range priceBand.Details {
+				detail := &priceBand.Details[i]
+				if !match(detail) {
+					continue
+				}
+				if cheapest == nil || detail.CombinedPrice().LessThan(cheapest.CombinedPrice()) {
+					cheapest = detail
+				}
+			}
+			if cheapest == nil {
+				continue
+			}
+			bands = append(bands, CachedPriceBand{
+				TicketTypeCode: ticketType.Code,
+				TicketTypeDesc: ticketType.Desc,
+				PriceBandCode:  priceBand.Code,
+				PriceBandDesc:  priceBand.Desc,
+				Detail:         *cheapest,
+			})
+		}
+	}
+
+	sort.SliceStable(bands, func(i, j int) bool {
+		return bands[i].Detail.CombinedPrice().LessThan(bands[j].Detail.CombinedPrice())
+	})
+	return bands
+}
+
+// PriceBands returns every ticket type and price band combination ordered
+// from cheapest to most expensive, using the cheapest detail of each price
+// band.
+func (details *AvailabilityDetails) PriceBands() []CachedPriceBand {
+	return details.priceBands(func(*AvailabilityDetail) bool { return true })
+}
+
+// CheapestPriceBand returns the cheapest ticket type and price band
+// combination across all dates. The boolean is false when there are no
+// details.
+func (details *AvailabilityDetails) CheapestPriceBand() (CachedPriceBand, bool) {
+	bands := details.PriceBands()
+	if len(bands) == 0 {
+		return CachedPriceBand{}, false
+	}
+	return bands[0], true
+}
+
+// PriceBandsAvailableOn returns every ticket type and price band combination
+// that the cached details say is available on the given date, ordered from
+// cheapest to most expensive. When a price band has more than one matching
+// detail only the cheapest is returned.
+func (details *AvailabilityDetails) PriceBandsAvailableOn(date time.Time) []CachedPriceBand {
+	return details.priceBands(func(detail *AvailabilityDetail) bool {
+		return detail.AvailableOn(date)
+	})
+}
+
+// CheapestPriceBandOn returns the cheapest ticket type and price band
+// combination that the cached details say is available on the given date.
+// The boolean is false when nothing is available.
+func (details *AvailabilityDetails) CheapestPriceBandOn(date time.Time) (CachedPriceBand, bool) {
+	bands := details.PriceBandsAvailableOn(date)
+	if len(bands) == 0 {
+		return CachedPriceBand{}, false
+	}
+	return bands[0], true
+}
+
+// TicketType returns the ticket type with the given code.
+func (details *AvailabilityDetails) TicketType(code string) (*AvailabilityDetailsTicketType, bool) {
+	for i := range details.TicketTypes {
+		if details.TicketTypes[i].Code == code {
+			return &details.TicketTypes[i], true
+		}
+	}
+	return nil, false
+}
+
+// TotalCachedAvailable returns the sum of the cached number of tickets
+// available across all ticket types.
+func (details *AvailabilityDetails) TotalCachedAvailable() int {
+	total := 0
+	for i := range details.TicketTypes {
+		total += details.TicketTypes[i].TotalCachedAvailable()
+	}
+	return total
+}
+
+// PriceRange returns the lowest and highest combined seat price across all
+// the price bands of the ticket type. The boolean is false when the ticket
+// type has no details.
+func (ticketType *AvailabilityDetailsTicketType) PriceRange() (min, max decimal.Decimal, ok bool) {
+	for _, priceBand := range ticketType.PriceBands {
+		for i := range priceBand.Details {
+			price := priceBand.Details[i].CombinedPrice()
+			if !ok || price.LessThan(min) {
+				min = price
+			}
+			if !ok || price.GreaterThan(max) {
+				max = price
+			}
+			ok = true
+		}
+	}
+	return min, max, ok
+}
+
+// TotalCachedAvailable returns the sum of the cached number of tickets
+// available across all price bands of the ticket type.
+func (ticketType *AvailabilityDetailsTicketType) TotalCachedAvailable() int {
+	total := 0
+	for i := range ticketType.PriceBands {
+		total += ticketType.PriceBands[i].TotalCachedAvailable()
+	}
+	return total
+}
+
+// TotalCachedAvailable returns the sum of the cached number of tickets
+// available across all details of the price band.
+func (priceBand *AvailabilityDetailsPriceBand) TotalCachedAvailable() int {
+	total := 0
+	for _, detail := range priceBand.Details {
+		total += detail.CachedNumberAvailable
+	}
+	return total
 }
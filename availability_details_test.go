@@ -0,0 +1,207 @@
+package ticketswitch
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAvailableDates(t *testing.T) {
+	dates := AvailableDates{First: "20261101", Last: "20261130"}
+
+	first, err := dates.FirstDate()
+	if assert.Nil(t, err) {
+		assert.Equal(t, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), first)
+	}
+	last, err := dates.LastDate()
+	if assert.Nil(t, err) {
+		assert.Equal(t, time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC), last)
+	}
+
+	assert.True(t, dates.Contains(time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, dates.Contains(time.Date(2026, 11, 30, 23, 59, 0, 0, time.UTC)))
+	assert.False(t, dates.Contains(time.Date(2026, 10, 31, 23, 59, 0, 0, time.UTC)))
+	assert.False(t, dates.Contains(time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)))
+
+	// the calendar date is taken from the time's own location
+	tokyo := time.FixedZone("JST", 9*60*60)
+	assert.True(t, dates.Contains(time.Date(2026, 11, 30, 8, 0, 0, 0, tokyo)))
+
+	open := AvailableDates{First: "20261101"}
+	last, err = open.LastDate()
+	assert.Nil(t, err)
+	assert.True(t, last.IsZero())
+	assert.True(t, open.Contains(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	bad := AvailableDates{First: "2026-11-01"}
+	_, err = bad.FirstDate()
+	assert.NotNil(t, err)
+	assert.False(t, bad.Contains(time.Date(2026, 11, 5, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestAvailabilityDetail_Weekdays(t *testing.T) {
+	detail := AvailabilityDetail{AvailableWeekdays: 65}
+	assert.Equal(t, []time.Weekday{time.Sunday, time.Saturday}, detail.Weekdays())
+	assert.True(t, detail.AvailableOnWeekday(time.Saturday))
+	assert.False(t, detail.AvailableOnWeekday(time.Monday))
+
+	detail = AvailabilityDetail{AvailableWeekdays: 127}
+	assert.Len(t, detail.Weekdays(), 7)
+
+	detail = AvailabilityDetail{}
+	assert.Empty(t, detail.Weekdays())
+}
+
+func TestAvailabilityDetail_AvailableOn(t *testing.T) {
+	detail := AvailabilityDetail{
+		AvailableDates:    AvailableDates{First: "20261201", Last: "20270131"},
+		AvailableWeekdays: 62,
+	}
+	// Friday 11th December
+	assert.True(t, detail.AvailableOn(time.Date(2026, 12, 11, 19, 30, 0, 0, time.UTC)))
+	// Saturday 12th December
+	assert.False(t, detail.AvailableOn(time.Date(2026, 12, 12, 19, 30, 0, 0, time.UTC)))
+	// Monday 30th November
+	assert.False(t, detail.AvailableOn(time.Date(2026, 11, 30, 19, 30, 0, 0, time.UTC)))
+}
+
+func TestEvent_PriceBandsAvailableOn(t *testing.T) {
+	data, err := os.ReadFile("testdata/event_avail_details.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatal(err)
+	}
+
+	saturday := time.Date(2026, 12, 12, 0, 0, 0, 0, time.UTC)
+	bands := event.PriceBandsAvailableOn(saturday)
+	if assert.Len(t, bands, 2) {
+		assert.Equal(t, "STALLS", bands[0].TicketTypeCode)
+		assert.Equal(t, "A", bands[0].PriceBandCode)
+		assert.Equal(t, "20261201", bands[0].Detail.AvailableDates.First)
+		assert.True(t, decimal.NewFromInt(33).Equal(bands[0].Detail.CombinedPrice()))
+		assert.Equal(t, "CIRCLE", bands[1].TicketTypeCode)
+		assert.Equal(t, "A", bands[1].PriceBandCode)
+	}
+
+	cheapest, ok := event.CheapestPriceBandOn(saturday)
+	if assert.True(t, ok) {
+		assert.Equal(t, "STALLS", cheapest.TicketTypeCode)
+		assert.Equal(t, "Stalls", cheapest.TicketTypeDesc)
+	}
+
+	// a saturday in november picks up the restricted view band instead
+	cheapest, ok = event.CheapestPriceBandOn(time.Date(2026, 11, 14, 0, 0, 0, 0, time.UTC))
+	if assert.True(t, ok) {
+		assert.Equal(t, "CIRCLE", cheapest.TicketTypeCode)
+		assert.Equal(t, "B", cheapest.PriceBandCode)
+		assert.Equal(t, "Restricted view", cheapest.PriceBandDesc)
+	}
+
+	_, ok = event.CheapestPriceBandOn(time.Date(2027, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.False(t, ok)
+	assert.Empty(t, event.PriceBandsAvailableOn(time.Date(2027, 6, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestPerformance_AvailabilityDetails(t *testing.T) {
+	data, err := os.ReadFile("testdata/performances_avail_details.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc ListPerformancesTopLevel
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	perfs := doc.Results.Performances
+	if !assert.Len(t, perfs, 2) {
+		t.FailNow()
+	}
+
+	details := perfs[0].AvailabilityDetails
+	if assert.Len(t, details.TicketTypes, 2) {
+		circle := details.TicketTypes[0]
+		assert.Equal(t, "CIRCLE", circle.Code)
+		assert.Equal(t, "Upper circle", circle.Desc)
+		if assert.Len(t, circle.PriceBands, 2) {
+			band := circle.PriceBands[1]
+			assert.Equal(t, "B", band.Code)
+			assert.Equal(t, "Restricted view", band.Desc)
+			if assert.Len(t, band.Details, 1) {
+				detail := band.Details[0]
+				assert.Equal(t, "gbp", detail.AvailabilityCurrencyCode)
+				assert.Equal(t, "B/pool", detail.SuffixedPriceBandCode)
+				assert.Equal(t, 3, detail.CachedNumberAvailable)
+				assert.Equal(t, []int{1, 2}, detail.ValidQuantities)
+				assert.Equal(t, []time.Weekday{time.Saturday}, detail.Weekdays())
+				assert.True(t, decimal.NewFromInt(15).Equal(detail.Seatprice))
+				assert.True(t, decimal.NewFromInt(20).Equal(detail.FullSeatprice))
+				assert.True(t, decimal.NewFromFloat(2.5).Equal(detail.FullSurcharge))
+				assert.True(t, decimal.NewFromInt(5).Equal(detail.AbsoluteSaving))
+				assert.True(t, decimal.NewFromFloat(22.22).Equal(detail.PercentageSaving))
+			}
+		}
+	}
+
+	assert.Empty(t, perfs[1].AvailabilityDetails.TicketTypes)
+	assert.Equal(t, 0, perfs[1].AvailabilityDetails.TotalCachedAvailable())
+	_, ok := perfs[1].AvailabilityDetails.CheapestPriceBand()
+	assert.False(t, ok)
+}
+
+func TestAvailabilityDetails_summaries(t *testing.T) {
+	data, err := os.ReadFile("testdata/performances_avail_details.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc ListPerformancesTopLevel
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	details := doc.Results.Performances[0].AvailabilityDetails
+
+	assert.Equal(t, 35, details.TotalCachedAvailable())
+
+	cheapest, ok := details.CheapestPriceBand()
+	if assert.True(t, ok) {
+		assert.Equal(t, "CIRCLE", cheapest.TicketTypeCode)
+		assert.Equal(t, "B", cheapest.PriceBandCode)
+		assert.True(t, decimal.NewFromFloat(17.5).Equal(cheapest.Detail.CombinedPrice()))
+	}
+
+	bands := details.PriceBands()
+	if assert.Len(t, bands, 3) {
+		assert.Equal(t, "B", bands[0].PriceBandCode)
+		assert.Equal(t, "CIRCLE", bands[1].TicketTypeCode)
+		assert.Equal(t, "A", bands[1].PriceBandCode)
+		assert.Equal(t, "STALLS", bands[2].TicketTypeCode)
+	}
+
+	circle, ok := details.TicketType("CIRCLE")
+	if assert.True(t, ok) {
+		min, max, ok := circle.PriceRange()
+		assert.True(t, ok)
+		assert.True(t, decimal.NewFromFloat(17.5).Equal(min))
+		assert.True(t, decimal.NewFromInt(39).Equal(max))
+		assert.Equal(t, 15, circle.TotalCachedAvailable())
+	}
+
+	stalls, ok := details.TicketType("STALLS")
+	if assert.True(t, ok) {
+		min, max, ok := stalls.PriceRange()
+		assert.True(t, ok)
+		assert.True(t, decimal.NewFromInt(60).Equal(min))
+		assert.True(t, decimal.NewFromInt(60).Equal(max))
+	}
+
+	_, ok = details.TicketType("BOX")
+	assert.False(t, ok)
+
+	_, _, ok = (&AvailabilityDetailsTicketType{}).PriceRange()
+	assert.False(t, ok)
+}
@@ -0,0 +1,97 @@
+// Package barcode renders the barcodes of purchased tickets without calling
+// out to a rendering service.
+//
+// Once a purchase is complete each Seat of an Order may have a Barcode. Encode
+// it in a symbology the order supports and render it as PNG or SVG:
+//
+//	code, err := barcode.ForSeat(&order, &seat)
+//	if errors.Is(err, barcode.ErrNotForEntry) {
+//		// the barcode is a reference, not an entry ticket
+//	}
+//	err = code.PNG(w, nil)
+//
+// QR codes, Code 128 and PDF417 are supported. Wallet passes made with
+// NewApplePass and NewGooglePass leave rendering the barcode to the wallet.
+package barcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// Symbology is a kind of barcode.
+type Symbology string
+
+// The symbologies tickets use.
+const (
+	QR      Symbology = "qr"
+	Code128 Symbology = "code128"
+	PDF417  Symbology = "pdf417"
+)
+
+var (
+	// ErrUnsupported is returned for a symbology that can't be rendered, or
+	// when none of the symbologies an order supports can be.
+	ErrUnsupported = errors.New("ticketswitch: unsupported barcode symbology")
+	// ErrNoBarcode is returned for a seat without a barcode.
+	ErrNoBarcode = errors.New("ticketswitch: seat has no barcode")
+	// ErrNotForEntry is returned when the order's barcodes don't allow entry
+	// to the event.
+	ErrNotForEntry = errors.New("ticketswitch: barcode does not allow entry")
+)
+
+// ParseSymbology converts a barcode type from the API, such as "QR_CODE" or
+// "code-128", to a Symbology.
+func ParseSymbology(name string) (Symbology, bool) {
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r - 'A' + 'a'
+		}
+		return -1
+	}, name)
+	switch name {
+	case "qr", "qrcode":
+		return QR, true
+	case "code128":
+		return Code128, true
+	case "pdf417":
+		return PDF417, true
+	}
+	return "", false
+}
+
+// Renderable checks if codes of the symbology can be encoded and rendered.
+func (symbology Symbology) Renderable() bool {
+	return symbology == QR || symbology == Code128 || symbology == PDF417
+}
+
+// Code is an encoded barcode, a grid of dark and light modules. Linear codes
+// are one module high and each row of a PDF417 code is three modules high.
+type Code struct {
+	Symbology Symbology
+	Width     int
+	Height    int
+	modules   []bool
+}
+
+// Dark checks if the module at column x and row y is dark.
+func (code *Code) Dark(x, y int) bool {
+	return code.modules[y*code.Width+x]
+}
+
+// Encode encodes data in the symbology. QR codes use the Medium error
+// correction level.
+func Encode(symbology Symbology, data string) (*Code, error) {
+	switch symbology {
+	case QR:
+		return EncodeQR(data, Medium)
+	case Code128:
+		return EncodeCode128(data)
+	case PDF417:
+		return EncodePDF417(data)
+	}
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,386 @@
+package barcode
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSymbology(t *testing.T) {
+	for name, want := range map[string]Symbology{
+		"qr":       QR,
+		"QR_CODE":  QR,
+		"code128":  Code128,
+		"CODE-128": Code128,
+		"PDF_417":  PDF417,
+	} {
+		got, ok := ParseSymbology(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, want, got, name)
+	}
+	_, ok := ParseSymbology("aztec")
+	assert.False(t, ok)
+}
+
+func TestQRDataCodewords(t *testing.T) {
+	// the worked example of a 1-M code.
+	data := qrDataCodewords(newQRSegment("HELLO WORLD"), 1, Medium)
+	assert.Equal(t, []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}, data)
+	ecc := reedSolomonRemainder(data, reedSolomonDivisor(10))
+	assert.Equal(t, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, ecc)
+
+	assert.Equal(t, numericMode, newQRSegment("0123").mode)
+	assert.Equal(t, alphanumericMode, newQRSegment("5B0AAC93").mode)
+	assert.Equal(t, byteMode, newQRSegment("5b0aac93").mode)
+}
+
+func TestQRCapacity(t *testing.T) {
+	assert.Equal(t, 19, dataCodewords(1, Low))
+	assert.Equal(t, 9, dataCodewords(1, High))
+	assert.Equal(t, 2956, dataCodewords(40, Low))
+	assert.Equal(t, 1276, dataCodewords(40, High))
+}
+
+func TestQRFormatAndVersionBits(t *testing.T) {
+	assert.Equal(t, 0x77C4, qrFormatBits(Low, 0))
+	assert.Equal(t, 0x5412, qrFormatBits(Medium, 0))
+
+	qr := newQRSymbol(7)
+	assert.Equal(t, []int{6, 22, 38}, qr.alignmentPositions())
+	qr = newQRSymbol(32)
+	assert.Equal(t, []int{6, 34, 60, 86, 112, 138}, qr.alignmentPositions())
+}
+
+// readQR reads the data and error correction codewords back out of a code.
+func readQR(t *testing.T, code *Code) (Level, []byte) {
+	version := (code.Width - 17) / 4
+	format := 0
+	for i := 14; i >= 9; i-- {
+		format = format<<1 | b2i(code.Dark(14-i, 8))
+	}
+	format = format<<1 | b2i(code.Dark(7, 8))
+	format = format<<1 | b2i(code.Dark(8, 8))
+	format = format<<1 | b2i(code.Dark(8, 7))
+	for i := 5; i >= 0; i-- {
+		format = format<<1 | b2i(code.Dark(8, i))
+	}
+
+	level, mask := Level(-1), -1
+	for l := Low; l <= High; l++ {
+		for m := 0; m < 8; m++ {
+			if qrFormatBits(l, m) == format {
+				level, mask = l, m
+			}
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("unknown format bits %015b", format)
+	}
+
+	qr := newQRSymbol(version)
+	qr.drawFunctionPatterns()
+	copy(qr.modules, code.modules)
+	qr.applyMask(mask)
+
+	data := make([]byte, rawDataModules(version)/8)
+	i := 0
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < qr.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = qr.size - 1 - vert
+				}
+				if !qr.function[y*qr.size+x] && i < len(data)*8 {
+					if qr.modules[y*qr.size+x] {
+						data[i>>3] |= 1 << uint(7-(i&7))
+					}
+					i++
+				}
+			}
+		}
+	}
+	return level, data
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestEncodeQR(t *testing.T) {
+	for _, data := range []string{"5B0AAC93", "https://example.com/tickets/5b0aac93?seat=A1", strings.Repeat("1234567890", 30)} {
+		code, err := EncodeQR(data, Quartile)
+		if !assert.Nil(t, err) {
+			continue
+		}
+		assert.Equal(t, code.Width, code.Height)
+		// finder patterns in three corners.
+		for _, corner := range [][2]int{{0, 0}, {code.Width - 7, 0}, {0, code.Width - 7}} {
+			assert.True(t, code.Dark(corner[0], corner[1]))
+			assert.True(t, code.Dark(corner[0]+3, corner[1]+3))
+			assert.False(t, code.Dark(corner[0]+1, corner[1]+1))
+		}
+
+		level, codewords := readQR(t, code)
+		assert.Equal(t, Quartile, level)
+		version := (code.Width - 17) / 4
+		segment := newQRSegment(data)
+		assert.Equal(t, addECCAndInterleave(qrDataCodewords(segment, version, level), version, level), codewords, data)
+	}
+
+	_, err := EncodeQR(strings.Repeat("x", 3000), High)
+	assert.EqualError(t, err, "ticketswitch: data too long for a QR code")
+}
+
+func TestCode128Values(t *testing.T) {
+	values, err := code128Values("PJJ123C")
+	if assert.Nil(t, err) {
+		assert.Equal(t, []int{code128StartB, 48, 42, 42, 17, 18, 19, 35, 55}, values)
+	}
+
+	values, err = code128Values("1234")
+	if assert.Nil(t, err) {
+		assert.Equal(t, []int{code128StartC, 12, 34, 82}, values)
+	}
+
+	values, err = code128Values("AB12345")
+	if assert.Nil(t, err) {
+		assert.Equal(t, []int{code128StartB, 33, 34, 17, code128CodeC, 23, 45}, values[:7])
+	}
+
+	_, err = code128Values("café")
+	assert.EqualError(t, err, "ticketswitch: Code 128 can only encode ASCII")
+}
+
+func TestEncodeCode128(t *testing.T) {
+	for i, pattern := range code128Patterns {
+		width := 0
+		for _, w := range pattern {
+			width += int(w - '0')
+		}
+		if i == code128Stop {
+			assert.Equal(t, 13, width)
+		} else {
+			assert.Equal(t, 11, width, pattern)
+		}
+	}
+
+	code, err := EncodeCode128("1234")
+	if assert.Nil(t, err) {
+		assert.Equal(t, 4*11+13, code.Width)
+		assert.Equal(t, 1, code.Height)
+		assert.True(t, code.Dark(0, 0))
+		assert.True(t, code.Dark(code.Width-1, 0))
+	}
+}
+
+func TestPDF417DataCodewords(t *testing.T) {
+	assert.Equal(t, []int{845, 841, 840, 840, 2, 849, 119}, pdf417DataCodewords("5B0AAC93"))
+	assert.Equal(t, []int{810, 32, 813, 814, 815}, pdf417DataCodewords("abcDEF"))
+	assert.Equal(t, []int{810, 870, 59, 59, 62}, pdf417DataCodewords("a;b<>c"))
+	assert.Equal(t, []int{902, 171, 209, 269, 12, 434}, pdf417DataCodewords("12345678901234"))
+	assert.Equal(t, []int{901, 99, 97, 102, 195, 169}, pdf417DataCodewords("café"))
+	assert.Equal(t, []int{924, 166, 490, 218, 551, 567}, pdf417DataCodewords("cafés"))
+}
+
+func TestPDF417ECC(t *testing.T) {
+	// (x - 3)(x - 9) = x^2 - 12x + 27
+	assert.Equal(t, []int{27, 917}, pdf417Generator(2))
+	assert.Equal(t, []int{522, 568, 723, 809}, pdf417Generator(4))
+
+	// the example of the specification, "PDF417" at level 1.
+	data := []int{5, 453, 178, 121, 239}
+	assert.Equal(t, []int{452, 327, 657, 619}, pdf417ECC(data, 4))
+}
+
+// readPDF417 reads the codewords of each row of a code back out, checking the
+// start and stop patterns and the row indicators.
+func readPDF417(t *testing.T, code *Code) (level int, codewords []int) {
+	columns := (code.Width-1)/17 - 4
+	rows := code.Height / pdf417RowHeight
+	clusters := make([]map[uint32]int, 3)
+	for cluster := range clusters {
+		clusters[cluster] = make(map[uint32]int)
+		for codeword, pattern := range pdf417Patterns[cluster] {
+			clusters[cluster][pattern] = codeword
+		}
+	}
+
+	read := func(y, x, modules int) uint32 {
+		var pattern uint32
+		for i := 0; i < modules; i++ {
+			pattern = pattern<<1 | uint32(b2i(code.Dark(x+i, y)))
+		}
+		return pattern
+	}
+	level = -1
+	for row := 0; row < rows; row++ {
+		y := row*pdf417RowHeight + 1
+		cluster := clusters[row%3]
+		assert.Equal(t, uint32(pdf417Start), read(y, 0, 17))
+		assert.Equal(t, uint32(pdf417Stop), read(y, code.Width-18, 18))
+
+		var words []int
+		for x := 17; x < code.Width-18; x += 17 {
+			codeword, ok := cluster[read(y, x, 17)]
+			if !ok {
+				t.Fatalf("row %d column %d is not a cluster %d pattern", row, x/17, 3*(row%3))
+			}
+			words = append(words, codeword)
+		}
+		left, right := words[0]%30, words[len(words)-1]%30
+		switch row % 3 {
+		case 0:
+			assert.Equal(t, (rows-1)/3, left)
+			assert.Equal(t, columns-1, right)
+		case 1:
+			level = (left - (rows-1)%3) / 3
+			assert.Equal(t, (rows-1)/3, right)
+		case 2:
+			assert.Equal(t, columns-1, left)
+			assert.Equal(t, 3*level+(rows-1)%3, right)
+		}
+		codewords = append(codewords, words[1:len(words)-1]...)
+	}
+	return level, codewords
+}
+
+func TestEncodePDF417(t *testing.T) {
+	for _, data := range []string{"5B0AAC93", "https://example.com/tickets/5b0aac93?seat=A1", "café", strings.Repeat("1234567890", 30)} {
+		code, err := EncodePDF417(data)
+		if !assert.Nil(t, err) {
+			continue
+		}
+		assert.Equal(t, PDF417, code.Symbology)
+		assert.Equal(t, 0, code.Height%pdf417RowHeight)
+		rows := code.Height / pdf417RowHeight
+		assert.True(t, rows >= pdf417MinRows && rows <= pdf417MaxRows, data)
+
+		level, codewords := readPDF417(t, code)
+		dataCodewords := pdf417DataCodewords(data)
+		assert.Equal(t, pdf417SecurityLevel(len(dataCodewords)+1), level, data)
+		k := 2 << uint(level)
+		length := len(codewords) - k
+		assert.Equal(t, length, codewords[0], data)
+		assert.Equal(t, dataCodewords, codewords[1:1+len(dataCodewords)], data)
+		for _, pad := range codewords[1+len(dataCodewords) : length] {
+			assert.Equal(t, pdf417Pad, pad)
+		}
+		assert.Equal(t, pdf417ECC(codewords[:length], k), codewords[length:], data)
+	}
+
+	_, err := EncodePDF417(strings.Repeat("\x00\xff", 1000))
+	assert.EqualError(t, err, "ticketswitch: data too long for a PDF417 code")
+}
+
+func TestRender(t *testing.T) {
+	code, err := EncodeCode128("5B0AAC93")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, code.PNG(&buf, &Options{Scale: 1, BarHeight: 20}))
+	img, err := png.Decode(&buf)
+	if assert.Nil(t, err) {
+		assert.Equal(t, code.Width+20, img.Bounds().Dx())
+		assert.Equal(t, 40, img.Bounds().Dy())
+		r, _, _, _ := img.At(10, 15).RGBA()
+		assert.Equal(t, uint32(0), r)
+		r, _, _, _ = img.At(9, 15).RGBA()
+		assert.Equal(t, uint32(0xFFFF), r)
+	}
+
+	buf.Reset()
+	assert.Nil(t, code.SVG(&buf, &Options{Scale: 1, BarHeight: 20}))
+	assert.True(t, strings.HasPrefix(buf.String(), `<svg xmlns="http://www.w3.org/2000/svg"`))
+	assert.Contains(t, buf.String(), `M10 10h2v20h-2z`)
+}
+
+func testOrder() *ticketswitch.Order {
+	return &ticketswitch.Order{
+		Event:                 ticketswitch.Event{Description: "The Unremarkable Ballet", Venue: "Lyric Theatre"},
+		Performance:           ticketswitch.Performance{Datetime: time.Date(2030, 1, 2, 19, 30, 0, 0, time.UTC), DateDesc: "Wed, 2nd January 2030", TimeDesc: "7.30 PM"},
+		TicketTypeDesc:        "Stalls",
+		BarcodeAllowsEntry:    true,
+		SupportedBarcodeTypes: []string{"pdf417", "code128"},
+	}
+}
+
+func TestForSeat(t *testing.T) {
+	order := testOrder()
+	seat := &ticketswitch.Seat{FullID: "A1", RowID: "A", ColumnID: "1", Barcode: "5B0AAC93"}
+
+	code, err := ForSeat(order, seat)
+	if assert.Nil(t, err) {
+		assert.Equal(t, Code128, code.Symbology)
+	}
+
+	order.SupportedBarcodeTypes = nil
+	code, err = ForSeat(order, seat)
+	if assert.Nil(t, err) {
+		assert.Equal(t, QR, code.Symbology)
+	}
+
+	order.SupportedBarcodeTypes = []string{"pdf417"}
+	code, err = ForSeat(order, seat)
+	if assert.Nil(t, err) {
+		assert.Equal(t, PDF417, code.Symbology)
+	}
+
+	order.SupportedBarcodeTypes = []string{"aztec"}
+	_, err = ForSeat(order, seat)
+	assert.Equal(t, ErrUnsupported, err)
+
+	order.BarcodeAllowsEntry = false
+	_, err = ForSeat(order, seat)
+	assert.Equal(t, ErrNotForEntry, err)
+
+	_, err = ForSeat(order, &ticketswitch.Seat{})
+	assert.Equal(t, ErrNoBarcode, err)
+}
+
+func TestNewApplePass(t *testing.T) {
+	seat := &ticketswitch.Seat{FullID: "A1", Barcode: "5B0AAC93"}
+	pass, err := NewApplePass(testOrder(), seat, PDF417)
+	if !assert.Nil(t, err) {
+		return
+	}
+	data, err := json.Marshal(pass)
+	if assert.Nil(t, err) {
+		var doc map[string]interface{}
+		assert.Nil(t, json.Unmarshal(data, &doc))
+		assert.Equal(t, float64(1), doc["formatVersion"])
+		assert.Equal(t, "2030-01-02T19:30:00Z", doc["relevantDate"])
+		barcode := doc["barcodes"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "PKBarcodeFormatPDF417", barcode["format"])
+		assert.Equal(t, "5B0AAC93", barcode["message"])
+	}
+	assert.Equal(t, "Wed, 2nd January 2030, 7.30 PM", pass.EventTicket.SecondaryFields[1].Value)
+
+	_, err = NewApplePass(testOrder(), seat, QR)
+	assert.Equal(t, ErrUnsupported, err)
+}
+
+func TestNewGooglePass(t *testing.T) {
+	seat := &ticketswitch.Seat{FullID: "A1", RowID: "A", ColumnID: "1", Barcode: "5B0AAC93"}
+	pass, err := NewGooglePass(testOrder(), seat, Code128, "en")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "CODE_128", pass.Barcode.Type)
+		assert.Equal(t, "ACTIVE", pass.State)
+		assert.Equal(t, "A", pass.SeatInfo.Row.DefaultValue.Value)
+		assert.Equal(t, "Stalls", pass.TicketType.DefaultValue.Value)
+	}
+}
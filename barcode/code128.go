@@ -0,0 +1,135 @@
+package barcode
+
+import "errors"
+
+// code128Patterns are the bar and space widths of each Code 128 symbol value,
+// starting with a bar. The last is the stop pattern.
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128CodeC  = 99
+	code128CodeB  = 100
+	code128CodeA  = 101
+	code128StartA = 103
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// code128Set is one of the Code 128 character sets.
+type code128Set int
+
+const (
+	code128SetA code128Set = iota
+	code128SetB
+	code128SetC
+)
+
+// digitRun returns the number of digits at the start of data.
+func digitRun(data string) int {
+	n := 0
+	for n < len(data) && data[n] >= '0' && data[n] <= '9' {
+		n++
+	}
+	return n
+}
+
+// code128Values returns the symbol values encoding data, including the start
+// symbol and check symbol. Runs of four or more digits, or a string of only
+// digits, are encoded in set C; control characters in set A and everything
+// else in set B.
+func code128Values(data string) ([]int, error) {
+	if data == "" {
+		return nil, errors.New("ticketswitch: no data to encode")
+	}
+	for i := 0; i < len(data); i++ {
+		if data[i] > 127 {
+			return nil, errors.New("ticketswitch: Code 128 can only encode ASCII")
+		}
+	}
+
+	var values []int
+	set := code128Set(-1)
+	switchTo := func(next code128Set) {
+		if set == next {
+			return
+		}
+		if set < 0 {
+			values = append(values, code128StartA+int(next))
+		} else {
+			values = append(values, [...]int{code128CodeA, code128CodeB, code128CodeC}[next])
+		}
+		set = next
+	}
+
+	for i := 0; i < len(data); {
+		run := digitRun(data[i:])
+		if run >= 4 || (run >= 2 && run == len(data)) {
+			if run%2 == 1 {
+				// the odd digit goes in the current set, or set B at the start.
+				if set < 0 || set == code128SetC {
+					switchTo(code128SetB)
+				}
+				values = append(values, int(data[i])-' ')
+				i++
+				run--
+			}
+			switchTo(code128SetC)
+			for ; run > 0; run -= 2 {
+				values = append(values, int(data[i]-'0')*10+int(data[i+1]-'0'))
+				i += 2
+			}
+			continue
+		}
+
+		c := data[i]
+		switch {
+		case c < ' ':
+			switchTo(code128SetA)
+			values = append(values, int(c)+64)
+		case set == code128SetA && c < '`':
+			values = append(values, int(c)-' ')
+		default:
+			switchTo(code128SetB)
+			values = append(values, int(c)-' ')
+		}
+		i++
+	}
+
+	check := values[0]
+	for i, value := range values[1:] {
+		check += (i + 1) * value
+	}
+	return append(values, check%103), nil
+}
+
+// EncodeCode128 encodes ASCII data as a Code 128 barcode. The code is one
+// module high; renderers stretch it to the bar height.
+func EncodeCode128(data string) (*Code, error) {
+	values, err := code128Values(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []bool
+	for _, value := range append(values, code128Stop) {
+		for i, width := range code128Patterns[value] {
+			for j := 0; j < int(width-'0'); j++ {
+				modules = append(modules, i%2 == 0)
+			}
+		}
+	}
+	return &Code{Symbology: Code128, Width: len(modules), Height: 1, modules: modules}, nil
+}
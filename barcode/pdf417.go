@@ -0,0 +1,331 @@
+package barcode
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	pdf417Start = 0x1fea8 // 17 modules
+	pdf417Stop  = 0x3fa29 // 18 modules
+
+	pdf417LatchByte      = 901
+	pdf417LatchNumeric   = 902
+	pdf417LatchByteMulti = 924
+	pdf417Pad            = 900
+
+	pdf417MaxCodewords = 928
+	pdf417MinRows      = 3
+	pdf417MaxRows      = 90
+	pdf417MaxColumns   = 30
+	// the height of each row in modules.
+	pdf417RowHeight = 3
+)
+
+// The text compaction sub-modes.
+const (
+	pdf417Alpha = iota
+	pdf417Lower
+	pdf417Mixed
+	pdf417Punct
+)
+
+// The characters of the mixed and punctuation sub-modes, by value.
+const (
+	pdf417MixedChars = "0123456789&\r\t,:#-.$/+%*=^"
+	pdf417PunctChars = ";<>@[\\]_`~!\r\t,:\n-.$/\"|*()?{}'"
+)
+
+// pdf417TextValues returns the text compaction values encoding data, or false
+// when data has characters text compaction can't encode.
+func pdf417TextValues(data string) ([]int, bool) {
+	values := make([]int, 0, len(data))
+	mode := pdf417Alpha
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		mixed := strings.IndexByte(pdf417MixedChars, c)
+		punct := strings.IndexByte(pdf417PunctChars, c)
+		upper := c >= 'A' && c <= 'Z'
+		lower := c >= 'a' && c <= 'z'
+		if !upper && !lower && c != ' ' && mixed < 0 && punct < 0 {
+			return nil, false
+		}
+
+		switch mode {
+		case pdf417Alpha:
+			switch {
+			case upper:
+				values = append(values, int(c-'A'))
+			case c == ' ':
+				values = append(values, 26)
+			case lower:
+				values = append(values, 27, int(c-'a'))
+				mode = pdf417Lower
+			case mixed >= 0:
+				values = append(values, 28, mixed)
+				mode = pdf417Mixed
+			default:
+				// shift to punctuation for one character.
+				values = append(values, 29, punct)
+			}
+		case pdf417Lower:
+			switch {
+			case lower:
+				values = append(values, int(c-'a'))
+			case c == ' ':
+				values = append(values, 26)
+			case upper:
+				// shift to alpha for one character.
+				values = append(values, 27, int(c-'A'))
+			case mixed >= 0:
+				values = append(values, 28, mixed)
+				mode = pdf417Mixed
+			default:
+				values = append(values, 29, punct)
+			}
+		case pdf417Mixed:
+			switch {
+			case mixed >= 0:
+				values = append(values, mixed)
+			case c == ' ':
+				values = append(values, 26)
+			case upper:
+				values = append(values, 28, int(c-'A'))
+				mode = pdf417Alpha
+			case lower:
+				values = append(values, 27, int(c-'a'))
+				mode = pdf417Lower
+			case i+1 < len(data) && strings.IndexByte(pdf417PunctChars, data[i+1]) >= 0:
+				// latch to punctuation for a run of it.
+				values = append(values, 25, punct)
+				mode = pdf417Punct
+			default:
+				values = append(values, 29, punct)
+			}
+		case pdf417Punct:
+			if punct >= 0 {
+				values = append(values, punct)
+				continue
+			}
+			// latch back to alpha and encode the character from there.
+			values = append(values, 29)
+			mode = pdf417Alpha
+			i--
+		}
+	}
+	return values, true
+}
+
+// pdf417NumericCodewords returns the numeric compaction codewords encoding a
+// string of digits. Each group of up to 44 digits is prefixed with a 1 and
+// written in base 900.
+func pdf417NumericCodewords(digits string) []int {
+	codewords := []int{pdf417LatchNumeric}
+	nineHundred := big.NewInt(900)
+	for len(digits) > 0 {
+		n := len(digits)
+		if n > 44 {
+			n = 44
+		}
+		value, _ := new(big.Int).SetString("1"+digits[:n], 10)
+		var group []int
+		mod := new(big.Int)
+		for value.Sign() > 0 {
+			value.DivMod(value, nineHundred, mod)
+			group = append([]int{int(mod.Int64())}, group...)
+		}
+		codewords = append(codewords, group...)
+		digits = digits[n:]
+	}
+	return codewords
+}
+
+// pdf417DataCodewords returns the codewords encoding data, using numeric
+// compaction for 13 or more digits, text compaction when it can and byte
+// compaction otherwise.
+func pdf417DataCodewords(data string) []int {
+	if n := digitRun(data); n == len(data) && n >= 13 {
+		return pdf417NumericCodewords(data)
+	}
+	if values, ok := pdf417TextValues(data); ok {
+		if len(values)%2 == 1 {
+			values = append(values, 29)
+		}
+		codewords := make([]int, 0, len(values)/2)
+		for i := 0; i < len(values); i += 2 {
+			codewords = append(codewords, 30*values[i]+values[i+1])
+		}
+		return codewords
+	}
+
+	codewords := []int{pdf417LatchByte}
+	if len(data)%6 == 0 {
+		codewords[0] = pdf417LatchByteMulti
+	}
+	i := 0
+	// six bytes are encoded as five base 900 codewords.
+	for ; i+6 <= len(data); i += 6 {
+		var value uint64
+		for j := 0; j < 6; j++ {
+			value = value<<8 | uint64(data[i+j])
+		}
+		var group [5]int
+		for j := 4; j >= 0; j-- {
+			group[j] = int(value % 900)
+			value /= 900
+		}
+		codewords = append(codewords, group[:]...)
+	}
+	for ; i < len(data); i++ {
+		codewords = append(codewords, int(data[i]))
+	}
+	return codewords
+}
+
+// pdf417SecurityLevel returns the error correction level recommended for
+// the number of data codewords.
+func pdf417SecurityLevel(dataCodewords int) int {
+	switch {
+	case dataCodewords <= 40:
+		return 2
+	case dataCodewords <= 160:
+		return 3
+	case dataCodewords <= 320:
+		return 4
+	}
+	return 5
+}
+
+// pdf417Generator returns the coefficients, lowest degree first and without
+// the leading one, of the error correction generator polynomial
+// (x - 3)(x - 3^2)...(x - 3^k) modulo 929.
+func pdf417Generator(k int) []int {
+	poly := []int{1}
+	root := 1
+	for i := 1; i <= k; i++ {
+		root = root * 3 % 929
+		next := make([]int, len(poly)+1)
+		for j, coefficient := range poly {
+			next[j+1] = (next[j+1] + coefficient) % 929
+			next[j] = (next[j] + 929 - coefficient*root%929) % 929
+		}
+		poly = next
+	}
+	return poly[:k]
+}
+
+// pdf417ECC returns the k error correction codewords of the data codewords.
+func pdf417ECC(data []int, k int) []int {
+	generator := pdf417Generator(k)
+	remainder := make([]int, k)
+	for _, codeword := range data {
+		t := (codeword + remainder[k-1]) % 929
+		for j := k - 1; j > 0; j-- {
+			remainder[j] = (remainder[j-1] + 929 - t*generator[j]%929) % 929
+		}
+		remainder[0] = (929 - t*generator[0]%929) % 929
+	}
+	ecc := make([]int, k)
+	for j := range remainder {
+		ecc[k-1-j] = (929 - remainder[j]) % 929
+	}
+	return ecc
+}
+
+// pdf417Dimensions returns the number of columns and rows of a symbol holding
+// the codewords, closest to three times as wide as it is high.
+func pdf417Dimensions(codewords int) (int, int) {
+	columns, rows := 0, 0
+	best := 0.0
+	for c := 1; c <= pdf417MaxColumns; c++ {
+		r := (codewords + c - 1) / c
+		if r < pdf417MinRows {
+			r = pdf417MinRows
+		}
+		if r > pdf417MaxRows {
+			continue
+		}
+		ratio := float64(17*c+69) / float64(r*pdf417RowHeight)
+		if ratio < 3 {
+			ratio = 3 / ratio
+		} else {
+			ratio /= 3
+		}
+		if columns == 0 || ratio < best {
+			columns, rows, best = c, r, ratio
+		}
+	}
+	return columns, rows
+}
+
+// EncodePDF417 encodes data as a PDF417 code with the error correction level
+// recommended for its length. Long numbers are encoded with numeric
+// compaction, printable ASCII with text compaction and anything else as
+// bytes.
+func EncodePDF417(data string) (*Code, error) {
+	if data == "" {
+		return nil, errors.New("ticketswitch: no data to encode")
+	}
+	codewords := pdf417DataCodewords(data)
+	level := pdf417SecurityLevel(len(codewords) + 1)
+	k := 2 << uint(level)
+	if len(codewords)+1+k > pdf417MaxCodewords {
+		return nil, errors.New("ticketswitch: data too long for a PDF417 code")
+	}
+	columns, rows := pdf417Dimensions(len(codewords) + 1 + k)
+
+	// the length descriptor counts itself, the data and the padding.
+	length := columns*rows - k
+	symbol := make([]int, 0, columns*rows)
+	symbol = append(symbol, length)
+	symbol = append(symbol, codewords...)
+	for len(symbol) < length {
+		symbol = append(symbol, pdf417Pad)
+	}
+	symbol = append(symbol, pdf417ECC(symbol, k)...)
+
+	width := 17*(columns+4) + 1
+	code := &Code{
+		Symbology: PDF417,
+		Width:     width,
+		Height:    rows * pdf417RowHeight,
+		modules:   make([]bool, width*rows*pdf417RowHeight),
+	}
+	for row := 0; row < rows; row++ {
+		cluster := row % 3
+		base := 30 * (row / 3)
+		var left, right int
+		switch cluster {
+		case 0:
+			left = base + (rows-1)/3
+			right = base + columns - 1
+		case 1:
+			left = base + 3*level + (rows-1)%3
+			right = base + (rows-1)/3
+		case 2:
+			left = base + columns - 1
+			right = base + 3*level + (rows-1)%3
+		}
+
+		x := 0
+		put := func(pattern uint32, modules int) {
+			for i := modules - 1; i >= 0; i-- {
+				if pattern&(1<<uint(i)) != 0 {
+					for y := 0; y < pdf417RowHeight; y++ {
+						code.modules[(row*pdf417RowHeight+y)*width+x] = true
+					}
+				}
+				x++
+			}
+		}
+		put(pdf417Start, 17)
+		put(pdf417Patterns[cluster][left], 17)
+		for _, codeword := range symbol[row*columns : (row+1)*columns] {
+			put(pdf417Patterns[cluster][codeword], 17)
+		}
+		put(pdf417Patterns[cluster][right], 17)
+		put(pdf417Stop, 18)
+	}
+	return code, nil
+}
@@ -0,0 +1,544 @@
+package barcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// Level is the error correction level of a QR code, the share of the code
+// that can be damaged and still read.
+type Level int
+
+const (
+	// Low recovers about 7% of the code.
+	Low Level = iota
+	// Medium recovers about 15% of the code.
+	Medium
+	// Quartile recovers about 25% of the code.
+	Quartile
+	// High recovers about 30% of the code.
+	High
+)
+
+// formatBits are the bits identifying each level in the format information.
+var formatBits = [...]int{Low: 1, Medium: 0, Quartile: 3, High: 2}
+
+// eccCodewordsPerBlock and eccBlocks are indexed by level and version.
+var eccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+var eccBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+const alphanumericChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// qrMode is a QR data encoding mode.
+type qrMode struct {
+	indicator int
+	// the character count bits for versions 1-9, 10-26 and 27-40.
+	countBits [3]int
+}
+
+var (
+	numericMode      = qrMode{0x1, [3]int{10, 12, 14}}
+	alphanumericMode = qrMode{0x2, [3]int{9, 11, 13}}
+	byteMode         = qrMode{0x4, [3]int{8, 16, 16}}
+)
+
+func (mode qrMode) charCountBits(version int) int {
+	switch {
+	case version <= 9:
+		return mode.countBits[0]
+	case version <= 26:
+		return mode.countBits[1]
+	}
+	return mode.countBits[2]
+}
+
+// bitBuffer is a sequence of bits, most significant first.
+type bitBuffer []bool
+
+func (buf *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*buf = append(*buf, (value>>uint(i))&1 == 1)
+	}
+}
+
+// qrSegment is the data encoded in the most compact mode it allows.
+type qrSegment struct {
+	mode  qrMode
+	count int
+	bits  bitBuffer
+}
+
+func newQRSegment(data string) qrSegment {
+	numeric, alphanumeric := true, true
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if c < '0' || c > '9' {
+			numeric = false
+		}
+		if strings.IndexByte(alphanumericChars, c) < 0 {
+			alphanumeric = false
+		}
+	}
+
+	var bits bitBuffer
+	switch {
+	case numeric:
+		for i := 0; i < len(data); i += 3 {
+			end := i + 3
+			if end > len(data) {
+				end = len(data)
+			}
+			value := 0
+			for _, c := range data[i:end] {
+				value = value*10 + int(c-'0')
+			}
+			bits.append(value, (end-i)*3+1)
+		}
+		return qrSegment{numericMode, len(data), bits}
+	case alphanumeric:
+		for i := 0; i+1 < len(data); i += 2 {
+			value := strings.IndexByte(alphanumericChars, data[i])*45 + strings.IndexByte(alphanumericChars, data[i+1])
+			bits.append(value, 11)
+		}
+		if len(data)%2 == 1 {
+			bits.append(strings.IndexByte(alphanumericChars, data[len(data)-1]), 6)
+		}
+		return qrSegment{alphanumericMode, len(data), bits}
+	}
+	for i := 0; i < len(data); i++ {
+		bits.append(int(data[i]), 8)
+	}
+	return qrSegment{byteMode, len(data), bits}
+}
+
+// rawDataModules returns the number of modules of a version that can hold
+// data, after the function patterns.
+func rawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		alignments := version/7 + 2
+		result -= (25*alignments-10)*alignments - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+func dataCodewords(version int, level Level) int {
+	return rawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*eccBlocks[level][version]
+}
+
+// EncodeQR encodes data as a QR code with the error correction level, using
+// the smallest version the data fits in.
+func EncodeQR(data string, level Level) (*Code, error) {
+	if level < Low || level > High {
+		return nil, errors.New("ticketswitch: invalid QR error correction level")
+	}
+	segment := newQRSegment(data)
+
+	version := 1
+	for ; version <= 40; version++ {
+		countBits := segment.mode.charCountBits(version)
+		if segment.count < 1<<uint(countBits) && 4+countBits+len(segment.bits) <= dataCodewords(version, level)*8 {
+			break
+		}
+	}
+	if version > 40 {
+		return nil, errors.New("ticketswitch: data too long for a QR code")
+	}
+
+	qr := newQRSymbol(version)
+	qr.drawFunctionPatterns()
+	qr.drawCodewords(addECCAndInterleave(qrDataCodewords(segment, version, level), version, level))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		qr.applyMask(mask)
+		qr.drawFormatBits(level, mask)
+		if penalty := qr.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		qr.applyMask(mask)
+	}
+	qr.applyMask(best)
+	qr.drawFormatBits(level, best)
+
+	return &Code{Symbology: QR, Width: qr.size, Height: qr.size, modules: qr.modules}, nil
+}
+
+// qrDataCodewords returns the data codewords of the segment in the version,
+// terminated and padded to its capacity.
+func qrDataCodewords(segment qrSegment, version int, level Level) []byte {
+	var bits bitBuffer
+	bits.append(segment.mode.indicator, 4)
+	bits.append(segment.count, segment.mode.charCountBits(version))
+	bits = append(bits, segment.bits...)
+	capacity := dataCodewords(version, level) * 8
+	terminator := capacity - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	codewords := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			codewords[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+
+	return codewords
+}
+
+// addECCAndInterleave splits the data codewords into blocks, appends the
+// error correction codewords of each and interleaves the blocks.
+func addECCAndInterleave(data []byte, version int, level Level) []byte {
+	numBlocks := eccBlocks[level][version]
+	blockECCLen := eccCodewordsPerBlock[level][version]
+	rawCodewords := rawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(blockECCLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		length := shortBlockLen - blockECCLen
+		if i >= numShortBlocks {
+			length++
+		}
+		block := append([]byte(nil), data[k:k+length]...)
+		k += length
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShortBlocks {
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			// skip the padding of the short blocks.
+			if i != shortBlockLen-blockECCLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= gfMultiply(coef, factor)
+		}
+	}
+	return result
+}
+
+// qrSymbol is a QR code being drawn.
+type qrSymbol struct {
+	version  int
+	size     int
+	modules  []bool
+	function []bool
+}
+
+func newQRSymbol(version int) *qrSymbol {
+	size := version*4 + 17
+	return &qrSymbol{
+		version:  version,
+		size:     size,
+		modules:  make([]bool, size*size),
+		function: make([]bool, size*size),
+	}
+}
+
+func (qr *qrSymbol) setFunction(x, y int, dark bool) {
+	qr.modules[y*qr.size+x] = dark
+	qr.function[y*qr.size+x] = true
+}
+
+func (qr *qrSymbol) alignmentPositions() []int {
+	if qr.version == 1 {
+		return nil
+	}
+	count := qr.version/7 + 2
+	step := 26
+	if qr.version != 32 {
+		step = (qr.version*4 + count*2 + 1) / (count*2 - 2) * 2
+	}
+	positions := make([]int, count)
+	positions[0] = 6
+	for i, pos := count-1, qr.size-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+func (qr *qrSymbol) drawFunctionPatterns() {
+	for i := 0; i < qr.size; i++ {
+		qr.setFunction(6, i, i%2 == 0)
+		qr.setFunction(i, 6, i%2 == 0)
+	}
+
+	for _, corner := range [][2]int{{3, 3}, {qr.size - 4, 3}, {3, qr.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := corner[0]+dx, corner[1]+dy
+				if x >= 0 && x < qr.size && y >= 0 && y < qr.size {
+					dist := max(abs(dx), abs(dy))
+					qr.setFunction(x, y, dist != 2 && dist != 4)
+				}
+			}
+		}
+	}
+
+	positions := qr.alignmentPositions()
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					qr.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// reserve the format information until the mask is chosen.
+	qr.drawFormatBits(Low, 0)
+
+	if qr.version >= 7 {
+		rem := qr.version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := qr.version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>uint(i))&1 == 1
+			a, b := qr.size-11+i%3, i/3
+			qr.setFunction(a, b, dark)
+			qr.setFunction(b, a, dark)
+		}
+	}
+}
+
+func qrFormatBits(level Level, mask int) int {
+	data := formatBits[level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+func (qr *qrSymbol) drawFormatBits(level Level, mask int) {
+	bits := qrFormatBits(level, mask)
+	bit := func(i int) bool { return (bits>>uint(i))&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		qr.setFunction(8, i, bit(i))
+	}
+	qr.setFunction(8, 7, bit(6))
+	qr.setFunction(8, 8, bit(7))
+	qr.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		qr.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		qr.setFunction(qr.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		qr.setFunction(8, qr.size-15+i, bit(i))
+	}
+	qr.setFunction(8, qr.size-8, true)
+}
+
+// drawCodewords places the codewords in the zigzag order, skipping the
+// function patterns.
+func (qr *qrSymbol) drawCodewords(data []byte) {
+	i := 0
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < qr.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = qr.size - 1 - vert
+				}
+				if !qr.function[y*qr.size+x] && i < len(data)*8 {
+					qr.modules[y*qr.size+x] = (data[i>>3]>>uint(7-(i&7)))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+func qrMask(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	}
+	return ((x+y)%2+x*y%3)%2 == 0
+}
+
+// applyMask inverts the data modules selected by the mask. Applying the same
+// mask twice undoes it.
+func (qr *qrSymbol) applyMask(mask int) {
+	for y := 0; y < qr.size; y++ {
+		for x := 0; x < qr.size; x++ {
+			if !qr.function[y*qr.size+x] && qrMask(mask, x, y) {
+				qr.modules[y*qr.size+x] = !qr.modules[y*qr.size+x]
+			}
+		}
+	}
+}
+
+var finderLike = [][]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// penalty scores how hard the symbol is to read, lower being better.
+func (qr *qrSymbol) penalty() int {
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			x, y = y, x
+		}
+		return qr.modules[y*qr.size+x]
+	}
+
+	result := 0
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < qr.size; y++ {
+			run := 1
+			for x := 1; x < qr.size; x++ {
+				if at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					result += run - 2
+				}
+				run = 1
+			}
+			if run >= 5 {
+				result += run - 2
+			}
+
+			for x := 0; x+len(finderLike[0]) <= qr.size; x++ {
+				for _, pattern := range finderLike {
+					matched := true
+					for k, dark := range pattern {
+						if at(x+k, y, transpose) != dark {
+							matched = false
+							break
+						}
+					}
+					if matched {
+						result += 40
+					}
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < qr.size; y++ {
+		for x := 0; x < qr.size; x++ {
+			if qr.modules[y*qr.size+x] {
+				dark++
+			}
+			if x+1 < qr.size && y+1 < qr.size {
+				c := qr.modules[y*qr.size+x]
+				if c == qr.modules[y*qr.size+x+1] && c == qr.modules[(y+1)*qr.size+x] && c == qr.modules[(y+1)*qr.size+x+1] {
+					result += 3
+				}
+			}
+		}
+	}
+	total := qr.size * qr.size
+	result += abs(dark*20-total*10) / total * 10
+	return result
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,117 @@
+package barcode
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// Options control how a Code is rendered.
+type Options struct {
+	// the size of a module in pixels, or user units for SVG. Defaults to 4
+	// for QR codes and 2 for linear codes.
+	Scale int
+	// the light margin around the code in modules. Defaults to the minimum
+	// the symbology requires, 4 for QR codes and 10 for linear codes.
+	QuietZone int
+	// the height of the bars of linear codes in modules. Defaults to 50.
+	BarHeight int
+}
+
+func (code *Code) options(opts *Options) Options {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	linear := code.Height == 1
+	if o.Scale <= 0 {
+		o.Scale = 4
+		if linear {
+			o.Scale = 2
+		}
+	}
+	if o.QuietZone <= 0 {
+		o.QuietZone = 4
+		if linear {
+			o.QuietZone = 10
+		}
+	}
+	if o.BarHeight <= 0 {
+		o.BarHeight = 50
+	}
+	return o
+}
+
+// rowHeight returns the number of module rows drawn for each row of the code.
+func (code *Code) rowHeight(o Options) int {
+	if code.Height == 1 {
+		return o.BarHeight
+	}
+	return 1
+}
+
+// Image renders the code as a black on white image.
+func (code *Code) Image(opts *Options) image.Image {
+	o := code.options(opts)
+	rowHeight := code.rowHeight(o)
+	width := (code.Width + 2*o.QuietZone) * o.Scale
+	height := (code.Height*rowHeight + 2*o.QuietZone) * o.Scale
+
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	for y := 0; y < code.Height; y++ {
+		for x := 0; x < code.Width; x++ {
+			if !code.Dark(x, y) {
+				continue
+			}
+			left := (x + o.QuietZone) * o.Scale
+			top := (y*rowHeight + o.QuietZone) * o.Scale
+			for py := top; py < top+rowHeight*o.Scale; py++ {
+				for px := left; px < left+o.Scale; px++ {
+					img.SetGray(px, py, color.Gray{})
+				}
+			}
+		}
+	}
+	return img
+}
+
+// PNG writes the code as a PNG image.
+func (code *Code) PNG(w io.Writer, opts *Options) error {
+	return png.Encode(w, code.Image(opts))
+}
+
+// SVG writes the code as an SVG image, drawing each run of dark modules in a
+// row as one rectangle.
+func (code *Code) SVG(w io.Writer, opts *Options) error {
+	o := code.options(opts)
+	rowHeight := code.rowHeight(o)
+	width := (code.Width + 2*o.QuietZone) * o.Scale
+	height := (code.Height*rowHeight + 2*o.QuietZone) * o.Scale
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, width, height, width, height)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, width, height)
+	for y := 0; y < code.Height; y++ {
+		for x := 0; x < code.Width; {
+			if !code.Dark(x, y) {
+				x++
+				continue
+			}
+			start := x
+			for x < code.Width && code.Dark(x, y) {
+				x++
+			}
+			fmt.Fprintf(bw, "M%d %dh%dv%dh-%dz",
+				(start+o.QuietZone)*o.Scale, (y*rowHeight+o.QuietZone)*o.Scale,
+				(x-start)*o.Scale, rowHeight*o.Scale, (x-start)*o.Scale)
+		}
+	}
+	fmt.Fprint(bw, `"/></svg>`)
+	return bw.Flush()
+}
@@ -0,0 +1,49 @@
+package barcode
+
+import (
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+)
+
+// DefaultPreference is the order symbologies are chosen in when the caller
+// has no preference.
+var DefaultPreference = []Symbology{QR, Code128, PDF417}
+
+// Choose returns the first of the preferred symbologies, or of
+// DefaultPreference when none are given, that the order supports and that
+// can be rendered. Orders that don't list any supported barcode types are
+// taken to support every symbology.
+func Choose(order *ticketswitch.Order, preferred ...Symbology) (Symbology, error) {
+	if len(preferred) == 0 {
+		preferred = DefaultPreference
+	}
+
+	supported := make(map[Symbology]bool, len(order.SupportedBarcodeTypes))
+	for _, name := range order.SupportedBarcodeTypes {
+		if symbology, ok := ParseSymbology(name); ok {
+			supported[symbology] = true
+		}
+	}
+	for _, symbology := range preferred {
+		if symbology.Renderable() && (len(order.SupportedBarcodeTypes) == 0 || supported[symbology]) {
+			return symbology, nil
+		}
+	}
+	return "", ErrUnsupported
+}
+
+// ForSeat encodes the barcode of a seat of a purchased order in the first of
+// the preferred symbologies the order supports, see Choose. It returns
+// ErrNotForEntry when the order's barcodes don't allow entry.
+func ForSeat(order *ticketswitch.Order, seat *ticketswitch.Seat, preferred ...Symbology) (*Code, error) {
+	if seat.Barcode == "" {
+		return nil, ErrNoBarcode
+	}
+	if !order.BarcodeAllowsEntry {
+		return nil, ErrNotForEntry
+	}
+	symbology, err := Choose(order, preferred...)
+	if err != nil {
+		return nil, err
+	}
+	return Encode(symbology, seat.Barcode)
+}
@@ -0,0 +1,219 @@
+package barcode
+
+import (
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+)
+
+// ApplePass is the skeleton of the pass.json of an Apple Wallet event ticket.
+// The identifiers, organisation and any styling must be filled in before the
+// pass is signed and packaged.
+type ApplePass struct {
+	FormatVersion      int              `json:"formatVersion"`
+	PassTypeIdentifier string           `json:"passTypeIdentifier"`
+	SerialNumber       string           `json:"serialNumber"`
+	TeamIdentifier     string           `json:"teamIdentifier"`
+	OrganizationName   string           `json:"organizationName"`
+	Description        string           `json:"description"`
+	RelevantDate       string           `json:"relevantDate,omitempty"`
+	Barcodes           []AppleBarcode   `json:"barcodes"`
+	EventTicket        AppleEventTicket `json:"eventTicket"`
+	Locations          []AppleLocation  `json:"locations,omitempty"`
+}
+
+// AppleBarcode is a barcode on an Apple Wallet pass.
+type AppleBarcode struct {
+	Format          string `json:"format"`
+	Message         string `json:"message"`
+	MessageEncoding string `json:"messageEncoding"`
+	AltText         string `json:"altText,omitempty"`
+}
+
+// AppleEventTicket holds the fields shown on an Apple Wallet event ticket.
+type AppleEventTicket struct {
+	HeaderFields    []AppleField `json:"headerFields,omitempty"`
+	PrimaryFields   []AppleField `json:"primaryFields,omitempty"`
+	SecondaryFields []AppleField `json:"secondaryFields,omitempty"`
+	AuxiliaryFields []AppleField `json:"auxiliaryFields,omitempty"`
+	BackFields      []AppleField `json:"backFields,omitempty"`
+}
+
+// AppleField is a labelled value on an Apple Wallet pass.
+type AppleField struct {
+	Key   string `json:"key"`
+	Label string `json:"label,omitempty"`
+	Value string `json:"value"`
+}
+
+// AppleLocation is a place an Apple Wallet pass is relevant at.
+type AppleLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+var appleFormats = map[Symbology]string{
+	QR:      "PKBarcodeFormatQR",
+	Code128: "PKBarcodeFormatCode128",
+	PDF417:  "PKBarcodeFormatPDF417",
+}
+
+// NewApplePass returns the skeleton of an Apple Wallet pass for a seat of a
+// purchased order, with the barcode in the symbology and the event,
+// performance and seat filled in. Wallet renders the barcode itself, so any
+// symbology the order supports can be used.
+func NewApplePass(order *ticketswitch.Order, seat *ticketswitch.Seat, symbology Symbology) (*ApplePass, error) {
+	if err := checkPassSeat(order, seat, symbology); err != nil {
+		return nil, err
+	}
+
+	pass := &ApplePass{
+		FormatVersion: 1,
+		SerialNumber:  seat.Barcode,
+		Description:   order.Event.Description,
+		Barcodes: []AppleBarcode{{
+			Format:          appleFormats[symbology],
+			Message:         seat.Barcode,
+			MessageEncoding: "iso-8859-1",
+			AltText:         seat.Barcode,
+		}},
+		EventTicket: AppleEventTicket{
+			PrimaryFields: []AppleField{{Key: "event", Label: "Event", Value: order.Event.Description}},
+			SecondaryFields: []AppleField{
+				{Key: "venue", Label: "Venue", Value: order.Event.Venue},
+				{Key: "date", Label: "Date", Value: performanceDesc(&order.Performance)},
+			},
+			AuxiliaryFields: []AppleField{
+				{Key: "ticket-type", Label: "Ticket", Value: order.TicketTypeDesc},
+				{Key: "seat", Label: "Seat", Value: seat.FullID},
+			},
+		},
+	}
+	if !order.Performance.Datetime.IsZero() {
+		pass.RelevantDate = order.Performance.Datetime.Format(time.RFC3339)
+	}
+	if geo := order.Event.GeoData; geo.Latitude != 0 || geo.Longitude != 0 {
+		pass.Locations = []AppleLocation{{Latitude: geo.Latitude, Longitude: geo.Longitude}}
+	}
+	return pass, nil
+}
+
+// GooglePass is the skeleton of a Google Wallet event ticket object. The id
+// and class id must be filled in before the object is saved.
+type GooglePass struct {
+	ID                string              `json:"id"`
+	ClassID           string              `json:"classId"`
+	State             string              `json:"state"`
+	Barcode           GoogleBarcode       `json:"barcode"`
+	TicketNumber      string              `json:"ticketNumber,omitempty"`
+	TicketType        *GoogleLocalized    `json:"ticketType,omitempty"`
+	SeatInfo          *GoogleSeatInfo     `json:"seatInfo,omitempty"`
+	ValidTimeInterval *GoogleTimeInterval `json:"validTimeInterval,omitempty"`
+}
+
+// GoogleBarcode is a barcode on a Google Wallet pass.
+type GoogleBarcode struct {
+	Type          string `json:"type"`
+	Value         string `json:"value"`
+	AlternateText string `json:"alternateText,omitempty"`
+}
+
+// GoogleLocalized is a localised string on a Google Wallet pass.
+type GoogleLocalized struct {
+	DefaultValue GoogleTranslated `json:"defaultValue"`
+}
+
+// GoogleTranslated is a string in a language.
+type GoogleTranslated struct {
+	Language string `json:"language"`
+	Value    string `json:"value"`
+}
+
+// GoogleSeatInfo describes the seat of a Google Wallet event ticket.
+type GoogleSeatInfo struct {
+	Seat *GoogleLocalized `json:"seat,omitempty"`
+	Row  *GoogleLocalized `json:"row,omitempty"`
+}
+
+// GoogleTimeInterval is when a Google Wallet pass is valid.
+type GoogleTimeInterval struct {
+	Start GoogleDateTime `json:"start"`
+}
+
+// GoogleDateTime is an ISO 8601 date and time on a Google Wallet pass.
+type GoogleDateTime struct {
+	Date string `json:"date"`
+}
+
+var googleFormats = map[Symbology]string{
+	QR:      "QR_CODE",
+	Code128: "CODE_128",
+	PDF417:  "PDF_417",
+}
+
+// NewGooglePass returns the skeleton of a Google Wallet event ticket object
+// for a seat of a purchased order, with the barcode in the symbology and the
+// ticket and seat filled in. Strings are in the language, such as "en".
+func NewGooglePass(order *ticketswitch.Order, seat *ticketswitch.Seat, symbology Symbology, language string) (*GooglePass, error) {
+	if err := checkPassSeat(order, seat, symbology); err != nil {
+		return nil, err
+	}
+	localized := func(value string) *GoogleLocalized {
+		if value == "" {
+			return nil
+		}
+		return &GoogleLocalized{DefaultValue: GoogleTranslated{Language: language, Value: value}}
+	}
+
+	pass := &GooglePass{
+		State: "ACTIVE",
+		Barcode: GoogleBarcode{
+			Type:          googleFormats[symbology],
+			Value:         seat.Barcode,
+			AlternateText: seat.Barcode,
+		},
+		TicketNumber: seat.Barcode,
+		TicketType:   localized(order.TicketTypeDesc),
+	}
+	if seat.ColumnID != "" || seat.RowID != "" {
+		pass.SeatInfo = &GoogleSeatInfo{Seat: localized(seat.ColumnID), Row: localized(seat.RowID)}
+	}
+	if !order.Performance.Datetime.IsZero() {
+		pass.ValidTimeInterval = &GoogleTimeInterval{
+			Start: GoogleDateTime{Date: order.Performance.Datetime.Format(time.RFC3339)},
+		}
+	}
+	return pass, nil
+}
+
+// checkPassSeat checks a wallet pass can be made for the seat.
+func checkPassSeat(order *ticketswitch.Order, seat *ticketswitch.Seat, symbology Symbology) error {
+	if seat.Barcode == "" {
+		return ErrNoBarcode
+	}
+	if !order.BarcodeAllowsEntry {
+		return ErrNotForEntry
+	}
+	if _, ok := appleFormats[symbology]; !ok {
+		return ErrUnsupported
+	}
+	if len(order.SupportedBarcodeTypes) > 0 {
+		for _, name := range order.SupportedBarcodeTypes {
+			if supported, ok := ParseSymbology(name); ok && supported == symbology {
+				return nil
+			}
+		}
+		return ErrUnsupported
+	}
+	return nil
+}
+
+func performanceDesc(performance *ticketswitch.Performance) string {
+	switch {
+	case performance.DateDesc != "" && performance.TimeDesc != "":
+		return performance.DateDesc + ", " + performance.TimeDesc
+	case performance.DateDesc != "":
+		return performance.DateDesc
+	}
+	return performance.TimeDesc
+}
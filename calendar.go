@@ -0,0 +1,195 @@
+package ticketswitch
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultCalendarProdID identifies the product that made a calendar written
+// by a Calendar.
+const DefaultCalendarProdID = "-//Ingresso//goticketswitch//EN"
+
+// Calendar writes the performances of a trolley as an RFC 5545 iCalendar
+// document, for "add to calendar" links on confirmation pages and emails.
+type Calendar struct {
+	// the PRODID of the calendar, defaults to DefaultCalendarProdID.
+	ProdID string
+	// appended to the UIDs of the events after an @, such as your domain.
+	Domain string
+	// the DTSTAMP of the events, defaults to the current time.
+	Stamp time.Time
+}
+
+// WriteICalendar writes the trolley as an iCalendar document with the default
+// Calendar settings.
+func WriteICalendar(w io.Writer, trolley *Trolley) error {
+	var calendar Calendar
+	return calendar.Write(w, trolley)
+}
+
+// Write writes the trolley of a purchased transaction, from MakePurchase or
+// GetStatus, as an iCalendar document with a VEVENT for each order.
+//
+// Events start at Performance.Datetime and end RunningTime minutes later, or
+// have no end when the running time isn't known. Times are written in UTC so
+// that they are the same instant wherever the calendar is opened. Orders whose
+// performance has no time are skipped. The UID of each event is made from the
+// transaction UUID and item number, so writing the same transaction again
+// updates the events rather than duplicating them. Cancelled orders are
+// written with STATUS:CANCELLED.
+func (calendar *Calendar) Write(w io.Writer, trolley *Trolley) error {
+	prodID := calendar.ProdID
+	if prodID == "" {
+		prodID = DefaultCalendarProdID
+	}
+	stamp := calendar.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+
+	cw := &calendarWriter{w: bufio.NewWriter(w)}
+	cw.line("BEGIN", "VCALENDAR")
+	cw.line("VERSION", "2.0")
+	cw.text("PRODID", prodID)
+	cw.line("CALSCALE", "GREGORIAN")
+	cw.line("METHOD", "PUBLISH")
+	for _, bundle := range trolley.Bundles {
+		for i := range bundle.Orders {
+			order := &bundle.Orders[i]
+			if order.Performance.Datetime.IsZero() {
+				continue
+			}
+			calendar.writeEvent(cw, trolley.TransactionUUID, order, stamp)
+		}
+	}
+	cw.line("END", "VCALENDAR")
+	if cw.err != nil {
+		return cw.err
+	}
+	return cw.w.Flush()
+}
+
+func (calendar *Calendar) writeEvent(cw *calendarWriter, transactionUUID string, order *Order, stamp time.Time) {
+	uid := fmt.Sprintf("%s-%d", transactionUUID, order.ItemNumber)
+	if calendar.Domain != "" {
+		uid += "@" + calendar.Domain
+	}
+	start := order.Performance.Datetime
+
+	cw.line("BEGIN", "VEVENT")
+	cw.text("UID", uid)
+	cw.line("DTSTAMP", calendarTime(stamp))
+	cw.line("DTSTART", calendarTime(start))
+	if order.Performance.RunningTime > 0 {
+		end := start.Add(time.Duration(order.Performance.RunningTime) * time.Minute)
+		cw.line("DTEND", calendarTime(end))
+	}
+
+	summary := order.Event.Description
+	if order.Performance.Name != "" {
+		summary += " - " + order.Performance.Name
+	}
+	cw.text("SUMMARY", summary)
+
+	if description := orderDescription(order); description != "" {
+		cw.text("DESCRIPTION", description)
+	}
+	// the venue address may already include the postcode and city.
+	location := ""
+	for _, part := range []string{order.Event.Venue, order.Event.VenueAddr, order.Event.Postcode, order.Event.City} {
+		if part = strings.TrimSpace(part); part == "" || strings.Contains(location, part) {
+			continue
+		}
+		if location != "" {
+			location += ", "
+		}
+		location += part
+	}
+	if location != "" {
+		cw.text("LOCATION", location)
+	}
+	if geo := order.Event.GeoData; geo.Latitude != 0 || geo.Longitude != 0 {
+		cw.line("GEO", fmt.Sprintf("%.6f;%.6f", geo.Latitude, geo.Longitude))
+	}
+
+	status := "CONFIRMED"
+	if order.CancellationStatus == "cancelled" {
+		status = "CANCELLED"
+	}
+	cw.line("STATUS", status)
+	cw.line("TRANSP", "OPAQUE")
+	cw.line("END", "VEVENT")
+}
+
+// orderDescription describes the tickets of an order, such as
+// "2 x Stalls (A1, A2)".
+func orderDescription(order *Order) string {
+	var seats []string
+	for _, ticketOrder := range order.TicketOrdersHolder.TicketOrders {
+		for _, seat := range ticketOrder.Seats {
+			if seat.FullID != "" {
+				seats = append(seats, seat.FullID)
+			}
+		}
+	}
+
+	description := order.TicketTypeDesc
+	if order.TotalNumberOfSeats > 0 {
+		description = fmt.Sprintf("%d x %s", order.TotalNumberOfSeats, description)
+	}
+	if len(seats) > 0 {
+		description += " (" + strings.Join(seats, ", ") + ")"
+	}
+	return strings.TrimSpace(description)
+}
+
+// calendarTime formats a time as an iCalendar UTC date and time.
+func calendarTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// calendarEscaper escapes the characters iCalendar TEXT values can't contain.
+var calendarEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// calendarWriter writes iCalendar content lines, folding them at 75 octets.
+type calendarWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// text writes a property with a TEXT value.
+func (cw *calendarWriter) text(name, value string) {
+	cw.line(name, calendarEscaper.Replace(value))
+}
+
+func (cw *calendarWriter) line(name, value string) {
+	if cw.err != nil {
+		return
+	}
+	line := name + ":" + value
+	var b strings.Builder
+	width := 0
+	for len(line) > 0 {
+		_, size := utf8.DecodeRuneInString(line)
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteString(line[:size])
+		width += size
+		line = line[size:]
+	}
+	b.WriteString("\r\n")
+	_, cw.err = cw.w.WriteString(b.String())
+}
@@ -0,0 +1,97 @@
+package ticketswitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar_Write(t *testing.T) {
+	data, err := os.ReadFile("testdata/status.json")
+	if err != nil {
+		t.Fatalf("Cannot find testdata/status.json")
+	}
+	var status StatusResult
+	if err := json.Unmarshal(data, &status); err != nil {
+		t.Fatal(err)
+	}
+
+	calendar := Calendar{Domain: "example.com", Stamp: time.Date(2018, 5, 27, 13, 3, 15, 0, time.UTC)}
+	var buf bytes.Buffer
+	if !assert.Nil(t, calendar.Write(&buf, &status.Trolley)) {
+		return
+	}
+	ics := buf.String()
+	lines := strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n")
+
+	assert.Equal(t, "BEGIN:VCALENDAR", lines[0])
+	assert.Equal(t, "END:VCALENDAR", lines[len(lines)-1])
+	assert.Contains(t, lines, "PRODID:"+DefaultCalendarProdID)
+	assert.Contains(t, lines, "UID:4df498e9-2daa-4393-a6bb-cc3dfefa7cc1-1@example.com")
+	assert.Contains(t, lines, "DTSTAMP:20180527T130315Z")
+	assert.Contains(t, lines, "DTSTART:20190101T153000Z")
+	assert.Contains(t, lines, "DTEND:20190101T170000Z")
+	assert.Contains(t, lines, `DESCRIPTION:2 x Stalls (A1\, A2)`)
+	assert.Contains(t, lines, "STATUS:CONFIRMED")
+	assert.Equal(t, 1, strings.Count(ics, "BEGIN:VEVENT"))
+	for _, line := range lines {
+		assert.True(t, len(line) <= 75, line)
+	}
+}
+
+func TestCalendar_Write_time_zones(t *testing.T) {
+	newYork := time.FixedZone("EST", -5*60*60)
+	trolley := &Trolley{
+		TransactionUUID: "abc",
+		Bundles: []Bundle{{Orders: []Order{
+			{
+				ItemNumber: 1,
+				Event: Event{
+					Description: "Hamilton; the musical, on Broadway",
+					Venue:       "Richard Rodgers Theatre",
+					VenueAddr:   "226 W 46th St\nNew York",
+					City:        "New York",
+					GeoData:     GeoData{Latitude: 40.759, Longitude: -73.9865},
+				},
+				Performance:        Performance{Datetime: time.Date(2030, 1, 2, 20, 0, 0, 0, newYork)},
+				CancellationStatus: "cancelled",
+			},
+			{ItemNumber: 2, Event: Event{Description: "Open dated"}},
+		}}},
+	}
+
+	var buf bytes.Buffer
+	if !assert.Nil(t, WriteICalendar(&buf, trolley)) {
+		return
+	}
+	ics := buf.String()
+	assert.Contains(t, ics, "UID:abc-1\r\n")
+	assert.Contains(t, ics, "DTSTART:20300103T010000Z\r\n")
+	assert.NotContains(t, ics, "DTEND")
+	assert.Contains(t, ics, `SUMMARY:Hamilton\; the musical\, on Broadway`)
+	assert.Contains(t, ics, "LOCATION:Richard Rodgers Theatre\\, 226 W 46th St\\nNew York\r\n")
+	assert.Contains(t, ics, "GEO:40.759000;-73.986500\r\n")
+	assert.Contains(t, ics, "STATUS:CANCELLED\r\n")
+	assert.NotContains(t, ics, "abc-2")
+}
+
+func TestCalendarWriter_folding(t *testing.T) {
+	var buf bytes.Buffer
+	calendar := Calendar{Stamp: time.Now()}
+	trolley := &Trolley{Bundles: []Bundle{{Orders: []Order{{
+		Event:       Event{Description: strings.Repeat("é", 60)},
+		Performance: Performance{Datetime: time.Now()},
+	}}}}}
+	assert.Nil(t, calendar.Write(&buf, trolley))
+
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		assert.True(t, len(line) <= 75, line)
+	}
+	unfolded := strings.ReplaceAll(buf.String(), "\r\n ", "")
+	assert.Contains(t, unfolded, "SUMMARY:"+strings.Repeat("é", 60)+"\r\n")
+}
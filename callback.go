@@ -0,0 +1,82 @@
+package ticketswitch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// CallbackParams are the parameters that can be passed to the Callback call.
+type CallbackParams struct {
+	UniversalParams
+	// the transaction being purchased, only used to journal the call.
+	TransactionUUID string
+	// the ReturnToken of the Callout the customer is returning from.
+	ReturnToken string
+	// a new unique token identifying the customer's return from any further
+	// callout.
+	NextReturnToken string
+	// the parameters the customer returned with, as query or form values.
+	Returned map[string]string
+}
+
+// Params returns the call parameters as a map
+func (params *CallbackParams) Params() map[string]string {
+	values := make(map[string]string, len(params.Returned))
+	for k, v := range params.Returned {
+		values[k] = v
+	}
+	for k, v := range params.Universal() {
+		values[k] = v
+	}
+	return values
+}
+
+// Callback continues a purchase once the customer returns from a Callout,
+// sending on the parameters they returned with. The result may contain a
+// further Callout to redirect the customer to. An Error with
+// CallbackGoneError set means the return token has already been used.
+func (client *Client) Callback(ctx context.Context, params *CallbackParams) (result *MakePurchaseResult, err error) {
+	if params.ReturnToken == "" || params.NextReturnToken == "" {
+		return nil, fmt.Errorf("ticketswitch: callback requires a return token and a next return token")
+	}
+	values := params.Params()
+	entry, err := client.startJournal(&JournalEntry{
+		Call:            "callback.v1",
+		TransactionUUID: params.TransactionUUID,
+		Params:          sanitizeParams(values, nil),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if entry != nil && result != nil {
+			entry.Status = result.Status
+			if entry.TransactionUUID == "" {
+				entry.TransactionUUID = result.Trolley.TransactionUUID
+			}
+		}
+		client.finishJournal(entry, err)
+	}()
+
+	endpoint := fmt.Sprintf("callback.v1/this.%s/next.%s",
+		url.PathEscape(params.ReturnToken), url.PathEscape(params.NextReturnToken))
+	req := NewRequest(http.MethodPost, endpoint, values)
+
+	resp, err := client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var purchase MakePurchaseResult
+	decoder := json.NewDecoder(resp.Body)
+	err = decoder.Decode(&purchase)
+	if err != nil {
+		return nil, err
+	}
+
+	return &purchase, nil
+}
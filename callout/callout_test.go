@@ -0,0 +1,253 @@
+package callout
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/ingresso-group/goticketswitch.v2/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func purchased(status string) *ticketswitch.MakePurchaseResult {
+	return &ticketswitch.MakePurchaseResult{
+		Status:  status,
+		Trolley: ticketswitch.Trolley{TransactionUUID: "abc-123"},
+	}
+}
+
+// newHandler returns a handler with a pending callout under token abc, and
+// the outcomes passed to done.
+func newHandler(t *testing.T, api *mock.API) (*Handler, *MemoryStore, *[]*Outcome) {
+	store := NewMemoryStore()
+	err := store.Put(context.Background(), NewPending("abc", purchased("attempting"), map[string]string{"order": "42"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcomes := make([]*Outcome, 0)
+	handler := New(api, store, func(w http.ResponseWriter, r *http.Request, outcome *Outcome) {
+		outcomes = append(outcomes, outcome)
+		w.WriteHeader(http.StatusSeeOther)
+	})
+	return handler, store, &outcomes
+}
+
+func serve(handler *Handler, method, target string, form url.Values) *httptest.ResponseRecorder {
+	var r *http.Request
+	if form != nil {
+		r = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		r = httptest.NewRequest(method, target, nil)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandler_get(t *testing.T) {
+	api := &mock.API{
+		CallbackFunc: func(ctx context.Context, params *ticketswitch.CallbackParams) (*ticketswitch.MakePurchaseResult, error) {
+			assert.Equal(t, "abc", params.ReturnToken)
+			assert.Len(t, params.NextReturnToken, 32)
+			assert.Equal(t, "abc-123", params.TransactionUUID)
+			assert.Equal(t, map[string]string{"PaRes": "Y", "MD": "1"}, params.Returned)
+			return purchased("purchased"), nil
+		},
+	}
+	handler, store, outcomes := newHandler(t, api)
+
+	w := serve(handler, http.MethodGet, "/return/abc?PaRes=Y&MD=1", nil)
+
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	if assert.Len(t, *outcomes, 1) {
+		outcome := (*outcomes)[0]
+		assert.Nil(t, outcome.Err)
+		assert.False(t, outcome.Repeated)
+		assert.Nil(t, outcome.Next)
+		assert.Equal(t, "purchased", outcome.Pending.Status)
+		assert.Equal(t, "42", outcome.Pending.Data["order"])
+	}
+	pending, err := store.Get(context.Background(), "abc")
+	if assert.Nil(t, err) {
+		assert.Equal(t, StateCompleted, pending.State)
+		assert.Equal(t, "purchased", pending.Status)
+		assert.False(t, pending.CompletedAt.IsZero())
+	}
+}
+
+func TestHandler_post(t *testing.T) {
+	api := &mock.API{
+		CallbackFunc: func(ctx context.Context, params *ticketswitch.CallbackParams) (*ticketswitch.MakePurchaseResult, error) {
+			assert.Equal(t, map[string]string{"PaRes": "Y", "session": "s1"}, params.Returned)
+			return purchased("purchased"), nil
+		},
+	}
+	handler, _, outcomes := newHandler(t, api)
+
+	serve(handler, http.MethodPost, "/return/abc?session=s1", url.Values{"PaRes": {"Y"}})
+
+	assert.Len(t, *outcomes, 1)
+	assert.Len(t, api.CallsTo("Callback"), 1)
+}
+
+func TestHandler_repeated(t *testing.T) {
+	api := &mock.API{
+		CallbackFunc: func(ctx context.Context, params *ticketswitch.CallbackParams) (*ticketswitch.MakePurchaseResult, error) {
+			return purchased("purchased"), nil
+		},
+	}
+	handler, _, outcomes := newHandler(t, api)
+
+	serve(handler, http.MethodGet, "/return/abc?PaRes=Y", nil)
+	serve(handler, http.MethodPost, "/return/abc", url.Values{"PaRes": {"Y"}})
+
+	assert.Len(t, api.CallsTo("Callback"), 1)
+	if assert.Len(t, *outcomes, 2) {
+		second := (*outcomes)[1]
+		assert.True(t, second.Repeated)
+		assert.Equal(t, "purchased", second.Pending.Status)
+	}
+}
+
+func TestHandler_in_progress(t *testing.T) {
+	api := &mock.API{}
+	handler, store, outcomes := newHandler(t, api)
+	_, claimed, err := store.Claim(context.Background(), "abc")
+	assert.Nil(t, err)
+	assert.True(t, claimed)
+
+	serve(handler, http.MethodGet, "/return/abc", nil)
+
+	assert.Len(t, api.Calls(), 0)
+	if assert.Len(t, *outcomes, 1) {
+		assert.True(t, (*outcomes)[0].InProgress)
+	}
+}
+
+func TestHandler_gone(t *testing.T) {
+	api := &mock.API{
+		CallbackFunc: func(ctx context.Context, params *ticketswitch.CallbackParams) (*ticketswitch.MakePurchaseResult, error) {
+			return nil, ticketswitch.Error{Description: "Gone", CallbackGoneError: true}
+		},
+		GetStatusFunc: func(ctx context.Context, params *ticketswitch.TransactionParams) (*ticketswitch.StatusResult, error) {
+			assert.Equal(t, "abc-123", params.TransactionUUID)
+			return &ticketswitch.StatusResult{Status: "purchased"}, nil
+		},
+	}
+	handler, store, outcomes := newHandler(t, api)
+
+	serve(handler, http.MethodGet, "/return/abc", nil)
+
+	if assert.Len(t, *outcomes, 1) {
+		outcome := (*outcomes)[0]
+		assert.Nil(t, outcome.Err)
+		assert.True(t, outcome.Pending.Gone)
+		assert.Equal(t, "purchased", outcome.Pending.Status)
+	}
+	pending, _ := store.Get(context.Background(), "abc")
+	assert.Equal(t, StateCompleted, pending.State)
+}
+
+func TestHandler_rejected(t *testing.T) {
+	api := &mock.API{
+		CallbackFunc: func(ctx context.Context, params *ticketswitch.CallbackParams) (*ticketswitch.MakePurchaseResult, error) {
+			return nil, ticketswitch.Error{Code: 1001, Description: "payment declined"}
+		},
+	}
+	handler, _, outcomes := newHandler(t, api)
+
+	serve(handler, http.MethodGet, "/return/abc", nil)
+	serve(handler, http.MethodGet, "/return/abc", nil)
+
+	assert.Len(t, api.CallsTo("Callback"), 1)
+	if assert.Len(t, *outcomes, 2) {
+		assert.Equal(t, "payment declined", (*outcomes)[0].Pending.Error)
+		assert.True(t, (*outcomes)[1].Repeated)
+	}
+}
+
+func TestHandler_retry_after_failure(t *testing.T) {
+	failed := false
+	api := &mock.API{
+		CallbackFunc: func(ctx context.Context, params *ticketswitch.CallbackParams) (*ticketswitch.MakePurchaseResult, error) {
+			if !failed {
+				failed = true
+				return nil, errors.New("connection reset")
+			}
+			return purchased("purchased"), nil
+		},
+	}
+	handler, store, outcomes := newHandler(t, api)
+
+	serve(handler, http.MethodGet, "/return/abc", nil)
+	pending, _ := store.Get(context.Background(), "abc")
+	assert.Equal(t, StatePending, pending.State)
+
+	serve(handler, http.MethodGet, "/return/abc", nil)
+
+	if assert.Len(t, *outcomes, 2) {
+		assert.EqualError(t, (*outcomes)[0].Err, "connection reset")
+		assert.Nil(t, (*outcomes)[1].Err)
+		assert.Equal(t, "purchased", (*outcomes)[1].Pending.Status)
+	}
+}
+
+func TestHandler_next_callout(t *testing.T) {
+	api := &mock.API{
+		CallbackFunc: func(ctx context.Context, params *ticketswitch.CallbackParams) (*ticketswitch.MakePurchaseResult, error) {
+			result := purchased("attempting")
+			result.Callout = &ticketswitch.Callout{ReturnToken: params.NextReturnToken, Destination: "https://pay.example.com"}
+			return result, nil
+		},
+	}
+	handler, store, outcomes := newHandler(t, api)
+
+	serve(handler, http.MethodGet, "/return/abc", nil)
+
+	if assert.Len(t, *outcomes, 1) && assert.NotNil(t, (*outcomes)[0].Next) {
+		next := (*outcomes)[0].Next
+		assert.Equal(t, (*outcomes)[0].Pending.Result.Callout.ReturnToken, next.ReturnToken)
+		saved, err := store.Get(context.Background(), next.ReturnToken)
+		if assert.Nil(t, err) {
+			assert.Equal(t, StatePending, saved.State)
+			assert.Equal(t, "abc-123", saved.TransactionUUID)
+			assert.Equal(t, "42", saved.Data["order"])
+		}
+	}
+}
+
+func TestHandler_not_found(t *testing.T) {
+	handler, _, outcomes := newHandler(t, &mock.API{})
+
+	w := serve(handler, http.MethodGet, "/return/xyz", nil)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Len(t, *outcomes, 0)
+}
+
+func TestHandler_method_not_allowed(t *testing.T) {
+	handler, _, _ := newHandler(t, &mock.API{})
+
+	w := serve(handler, http.MethodDelete, "/return/abc", nil)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, POST", w.Header().Get("Allow"))
+}
+
+func TestLastPathSegment(t *testing.T) {
+	for path, token := range map[string]string{
+		"/return/abc":  "abc",
+		"/return/abc/": "abc",
+		"/abc":         "abc",
+		"/":            "",
+	} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		assert.Equal(t, token, LastPathSegment(r), path)
+	}
+}
@@ -0,0 +1,211 @@
+// Package callout completes purchases when customers return from a payment
+// page.
+//
+// When a purchase made with ticketswitch.RedirectionDetails returns a
+// Callout, save it in a Store under the return token and redirect the
+// customer to it:
+//
+//	token, err := callout.NewToken()
+//	result, err := client.MakePurchase(ctx, &ticketswitch.MakePurchaseParams{
+//		TransactionUUID: uuid,
+//		PaymentMethod: ticketswitch.RedirectionDetails{
+//			Token: token,
+//			URL:   "https://example.com/return/" + token,
+//			...
+//		},
+//	})
+//	if result.Callout != nil {
+//		err = store.Put(ctx, callout.NewPending(token, result, nil))
+//		...
+//	}
+//
+// A Handler serving the return URL sends the parameters the customer returns
+// with on to the API and passes the Outcome to the application:
+//
+//	http.Handle("/return/", callout.New(client, store, done))
+//
+// Each return token is completed at most once. Repeated submissions are
+// given the outcome of the first.
+package callout
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+)
+
+// Outcome is the outcome of a customer's return from a callout.
+type Outcome struct {
+	// the callout the customer returned from. Once completed it has the
+	// result of the callback.
+	Pending *Pending
+	// the callout the customer must be redirected to next, already saved in
+	// the store. Redirect them to Pending.Result.Callout.
+	Next *Pending
+	// the callout had already been completed by an earlier submission.
+	Repeated bool
+	// the callout is being completed by another submission.
+	InProgress bool
+	// the callback couldn't be completed, for example because the API
+	// couldn't be reached. The callout is left pending so the customer can
+	// try again.
+	Err error
+}
+
+// Handler is an http.Handler serving the URL customers return to from
+// callouts. It accepts GET and POST requests.
+type Handler struct {
+	// returns the return token of a request. Defaults to the last segment of
+	// the path.
+	Token func(r *http.Request) string
+	// called when the store fails. The handler responds with 500 Internal
+	// Server Error.
+	OnError func(r *http.Request, err error)
+
+	api   ticketswitch.API
+	store Store
+	done  func(w http.ResponseWriter, r *http.Request, outcome *Outcome)
+}
+
+// New returns a Handler completing callouts from store with api. Done is
+// called with the outcome of every return of a known token and writes the
+// response, for example a redirect to a confirmation page or to the next
+// callout.
+func New(api ticketswitch.API, store Store, done func(w http.ResponseWriter, r *http.Request, outcome *Outcome)) *Handler {
+	return &Handler{
+		Token: LastPathSegment,
+		api:   api,
+		store: store,
+		done:  done,
+	}
+}
+
+// LastPathSegment returns the last segment of the request path, for example
+// abc for /return/abc.
+func LastPathSegment(r *http.Request) string {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// ServeHTTP completes the callout the customer returned from.
+func (handler *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	token := handler.Token(r)
+	if token == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	pending, claimed, err := handler.store.Claim(ctx, token)
+	if errors.Is(err, ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		handler.fail(w, r, err)
+		return
+	}
+	if !claimed {
+		handler.done(w, r, &Outcome{
+			Pending:    pending,
+			Repeated:   pending.State == StateCompleted,
+			InProgress: pending.State == StateCompleting,
+		})
+		return
+	}
+
+	returned := make(map[string]string, len(r.Form))
+	for key, values := range r.Form {
+		if len(values) > 0 {
+			returned[key] = values[0]
+		}
+	}
+	outcome, err := handler.complete(ctx, pending, returned)
+	if err != nil {
+		handler.fail(w, r, err)
+		return
+	}
+	handler.done(w, r, outcome)
+}
+
+// complete makes the callback for a claimed callout and saves the result. It
+// only returns an error when the store fails.
+func (handler *Handler) complete(ctx context.Context, pending *Pending, returned map[string]string) (*Outcome, error) {
+	outcome := &Outcome{Pending: pending}
+	next, err := NewToken()
+	if err != nil {
+		outcome.Err = err
+		return outcome, handler.release(ctx, pending)
+	}
+
+	result, err := handler.api.Callback(ctx, &ticketswitch.CallbackParams{
+		TransactionUUID: pending.TransactionUUID,
+		ReturnToken:     pending.ReturnToken,
+		NextReturnToken: next,
+		Returned:        returned,
+	})
+	var apiErr ticketswitch.Error
+	switch {
+	case err == nil:
+		pending.Result = result
+		pending.Status = result.Status
+	case errors.As(err, &apiErr) && apiErr.CallbackGoneError:
+		// the token was used before, most likely by a return that was
+		// interrupted before it was saved.
+		status, statusErr := handler.api.GetStatus(ctx, &ticketswitch.TransactionParams{TransactionUUID: pending.TransactionUUID})
+		if statusErr != nil {
+			outcome.Err = statusErr
+			return outcome, handler.release(ctx, pending)
+		}
+		pending.Gone = true
+		pending.Status = status.Status
+	case errors.As(err, &apiErr):
+		pending.Error = apiErr.Description
+	default:
+		outcome.Err = err
+		return outcome, handler.release(ctx, pending)
+	}
+
+	if result != nil && result.Callout != nil {
+		outcome.Next = &Pending{
+			ReturnToken:     next,
+			TransactionUUID: pending.TransactionUUID,
+			Data:            pending.Data,
+			State:           StatePending,
+			CreatedAt:       time.Now(),
+		}
+		if err := handler.store.Put(ctx, outcome.Next); err != nil {
+			return outcome, err
+		}
+	}
+	pending.State = StateCompleted
+	pending.CompletedAt = time.Now()
+	return outcome, handler.store.Put(ctx, pending)
+}
+
+// release returns a claimed callout to pending so the customer can try
+// again.
+func (handler *Handler) release(ctx context.Context, pending *Pending) error {
+	pending.State = StatePending
+	return handler.store.Put(ctx, pending)
+}
+
+func (handler *Handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if handler.OnError != nil {
+		handler.OnError(r, err)
+	}
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
@@ -0,0 +1,135 @@
+package callout
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+)
+
+// ErrNotFound is returned by a Store when it has no callout with a token.
+var ErrNotFound = errors.New("ticketswitch: callout not found")
+
+// State is the state of a callout the customer was redirected to.
+type State string
+
+const (
+	// StatePending callouts are waiting for the customer to return.
+	StatePending State = "pending"
+	// StateCompleting callouts are being completed with the API.
+	StateCompleting State = "completing"
+	// StateCompleted callouts have been completed, successfully or not.
+	StateCompleted State = "completed"
+)
+
+// Pending is a callout the customer was redirected to, stored under the
+// return token they come back with.
+type Pending struct {
+	ReturnToken     string `json:"return_token"`
+	TransactionUUID string `json:"transaction_uuid"`
+	// anything the application needs when the customer returns, for example
+	// its own order id.
+	Data      map[string]string `json:"data,omitempty"`
+	State     State             `json:"state"`
+	CreatedAt time.Time         `json:"created_at"`
+
+	// set once completed.
+	CompletedAt time.Time `json:"completed_at,omitempty"`
+	// the result of the callback. It has a Callout when the customer must be
+	// redirected again.
+	Result *ticketswitch.MakePurchaseResult `json:"result,omitempty"`
+	// the transaction status after the callback.
+	Status string `json:"status,omitempty"`
+	// the API had already seen the return token, Status was looked up.
+	Gone bool `json:"gone,omitempty"`
+	// the description of the error the API rejected the callback with.
+	Error string `json:"error,omitempty"`
+}
+
+// NewPending returns a pending callout for a purchase that returned one.
+// Token is the return token the purchase was made with, see
+// ticketswitch.RedirectionDetails.
+func NewPending(token string, result *ticketswitch.MakePurchaseResult, data map[string]string) *Pending {
+	return &Pending{
+		ReturnToken:     token,
+		TransactionUUID: result.Trolley.TransactionUUID,
+		Data:            data,
+		State:           StatePending,
+		CreatedAt:       time.Now(),
+	}
+}
+
+// Store keeps the callouts customers were redirected to. Implementations must
+// be safe for concurrent use.
+type Store interface {
+	// Get returns the callout with the return token, or ErrNotFound.
+	Get(ctx context.Context, token string) (*Pending, error)
+	// Claim atomically moves the callout with the return token from pending
+	// to completing and returns it with true. A callout in any other state
+	// is returned unchanged with false. Missing callouts return ErrNotFound.
+	Claim(ctx context.Context, token string) (*Pending, bool, error)
+	// Put saves a callout, replacing any with the same return token.
+	Put(ctx context.Context, pending *Pending) error
+}
+
+// MemoryStore is a Store that keeps callouts in memory, suitable for tests
+// and single process applications.
+type MemoryStore struct {
+	mu      sync.Mutex
+	pending map[string]Pending
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{pending: make(map[string]Pending)}
+}
+
+// Get returns the callout with the return token.
+func (store *MemoryStore) Get(ctx context.Context, token string) (*Pending, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	pending, ok := store.pending[token]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &pending, nil
+}
+
+// Claim moves the callout with the return token to completing if it's
+// pending.
+func (store *MemoryStore) Claim(ctx context.Context, token string) (*Pending, bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	pending, ok := store.pending[token]
+	if !ok {
+		return nil, false, ErrNotFound
+	}
+	if pending.State != StatePending {
+		return &pending, false, nil
+	}
+	pending.State = StateCompleting
+	store.pending[token] = pending
+	return &pending, true, nil
+}
+
+// Put saves a callout.
+func (store *MemoryStore) Put(ctx context.Context, pending *Pending) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.pending[pending.ReturnToken] = *pending
+	return nil
+}
+
+// NewToken returns a new random return token.
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("ticketswitch: generating return token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1,5 +1,11 @@
 package ticketswitch
 
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
 // CancellationResult contains the results of the cancel API call.
 type CancellationResult struct {
 	//nolint:misspell
@@ -27,3 +33,167 @@ func (result *CancellationResult) IsFullyCancelled() bool {
 	}
 	return true
 }
+
+// CancelOrdersParams are the parameters that can be passed to the
+// CancelOrders call.
+type CancelOrdersParams struct {
+	UniversalParams
+	TransactionUUID string
+	// the item numbers of the orders the caller wants to cancel.
+	ItemNumbers []int
+	// when the API says other orders must also be cancelled, add them to the
+	// request and cancel again rather than returning a preview.
+	ConfirmMustAlsoCancel bool
+}
+
+// CancellationOutcome describes what happened to a single order during a
+// CancelOrders call.
+type CancellationOutcome struct {
+	// the item number of the order within the transaction.
+	ItemNumber int
+	// indicates the order was asked for by the caller rather than required by
+	// the API.
+	Requested bool
+	// the cancellation status reported by the API. Empty when the order was
+	// not part of the response.
+	Status string
+	// any comment about the cancellation reported by the backend system.
+	Comment string
+	// the backend system's reference for the cancellation.
+	BackendCancellationReference string
+}
+
+// Cancelled checks if the order was cancelled.
+func (outcome *CancellationOutcome) Cancelled() bool {
+	//nolint:misspell
+	return outcome.Status == "cancelled"
+}
+
+// CancelOrdersResult contains the results of the CancelOrders call.
+type CancelOrdersResult struct {
+	// item numbers the API said must be cancelled alongside the requested
+	// orders.
+	MustAlsoCancel []int
+	// indicates the must also cancel orders were added to the request and
+	// the cancellation was retried.
+	Confirmed bool
+	// an outcome for every requested order and every order the API said must
+	// also be cancelled, ordered by item number.
+	Outcomes []CancellationOutcome
+	// the result of the last call to the cancel endpoint.
+	Result *CancellationResult
+}
+
+// NeedsConfirmation checks if nothing was cancelled because the API requires
+// the orders in MustAlsoCancel to be cancelled as well. Call CancelOrders
+// again with ConfirmMustAlsoCancel set to go ahead.
+func (result *CancelOrdersResult) NeedsConfirmation() bool {
+	return result.Result != nil && len(result.Result.MustAlsoCancel) > 0
+}
+
+// Outcome returns the outcome for the order with the given item number.
+func (result *CancelOrdersResult) Outcome(itemNumber int) (*CancellationOutcome, bool) {
+	for i := range result.Outcomes {
+		if result.Outcomes[i].ItemNumber == itemNumber {
+			return &result.Outcomes[i], true
+		}
+	}
+	return nil, false
+}
+
+// findOrder looks for an order with the given item number in the trolley and
+// then in the must also cancel orders of a CancellationResult.
+func (result *CancellationResult) findOrder(itemNumber int) (*Order, bool) {
+	for i := range result.Trolley.Bundles {
+		orders := result.Trolley.Bundles[i].Orders
+		for j := range orders {
+			if orders[j].ItemNumber == itemNumber {
+				return &orders[j], true
+			}
+		}
+	}
+	for i := range result.MustAlsoCancel {
+		if result.MustAlsoCancel[i].ItemNumber == itemNumber {
+			return &result.MustAlsoCancel[i], true
+		}
+	}
+	return nil, false
+}
+
+// CancelOrders cancels some of the orders in a purchased transaction.
+//
+// Backend systems may refuse to cancel an order without also cancelling
+// others, in which case the API cancels nothing and lists the other orders as
+// must also cancel. By default CancelOrders returns that list as a preview,
+// see CancelOrdersResult.NeedsConfirmation. When ConfirmMustAlsoCancel is set
+// the listed orders are added to the request and the cancellation is retried
+// until the API stops asking for more.
+func (client *Client) CancelOrders(ctx context.Context, params *CancelOrdersParams) (*CancelOrdersResult, error) {
+	if params == nil || len(params.ItemNumbers) == 0 {
+		return nil, errors.New("ticketswitch: no item numbers to cancel")
+	}
+
+	requested := make(map[int]bool)
+	items := make(CancelItemsList, 0, len(params.ItemNumbers))
+	for _, item := range params.ItemNumbers {
+		if !requested[item] {
+			requested[item] = true
+			items = append(items, item)
+		}
+	}
+
+	output := &CancelOrdersResult{}
+	included := make(map[int]bool)
+	for item := range requested {
+		included[item] = true
+	}
+
+	for {
+		result, err := client.Cancel(ctx, &CancellationParams{
+			UniversalParams: params.UniversalParams,
+			TransactionUUID: params.TransactionUUID,
+			CancelItemsList: items,
+		})
+		if err != nil {
+			return nil, err
+		}
+		output.Result = result
+
+		extra := make([]int, 0)
+		for _, order := range result.MustAlsoCancel {
+			if !included[order.ItemNumber] {
+				included[order.ItemNumber] = true
+				extra = append(extra, order.ItemNumber)
+			}
+		}
+		output.MustAlsoCancel = append(output.MustAlsoCancel, extra...)
+
+		if !params.ConfirmMustAlsoCancel || len(extra) == 0 {
+			break
+		}
+		items = append(items, extra...)
+		output.Confirmed = true
+	}
+
+	itemNumbers := make([]int, 0, len(included))
+	for item := range included {
+		itemNumbers = append(itemNumbers, item)
+	}
+	sort.Ints(itemNumbers)
+
+	for _, item := range itemNumbers {
+		outcome := CancellationOutcome{
+			ItemNumber: item,
+			Requested:  requested[item],
+		}
+		if order, ok := output.Result.findOrder(item); ok {
+			outcome.Status = order.CancellationStatus
+			outcome.Comment = order.CancellationComment
+			outcome.BackendCancellationReference = order.BackendCancellationReference
+		}
+		output.Outcomes = append(output.Outcomes, outcome)
+	}
+	sort.Ints(output.MustAlsoCancel)
+
+	return output, nil
+}
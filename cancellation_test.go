@@ -1,7 +1,10 @@
 package ticketswitch
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -46,3 +49,149 @@ func TestCancellationResult_IsFullyCancelled(t *testing.T) {
 		})
 	}
 }
+
+func TestCancelOrders(t *testing.T) {
+	mustAlsoCancel, err := os.ReadFile("testdata/must_also_cancel.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, err := os.ReadFile("testdata/cancel_with_dependencies.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transUUID := "4df498e9-2daa-4393-a6bb-cc3dfefa7cc1"
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/f13/cancel.v1", r.URL.Path)
+			assert.Equal(t, transUUID, r.URL.Query().Get("transaction_uuid"))
+			items := r.URL.Query().Get("cancel_items_list")
+			calls = append(calls, items)
+			if items == "1" {
+				w.Write(mustAlsoCancel)
+				return
+			}
+			w.Write(cancelled)
+		}))
+	defer server.Close()
+	client := NewClient(&Config{
+		BaseURL:  server.URL,
+		User:     "bill",
+		Password: "hahaha",
+	})
+
+	t.Run("preview", func(t *testing.T) {
+		calls = nil
+		result, err := client.CancelOrders(context.Background(), &CancelOrdersParams{
+			TransactionUUID: transUUID,
+			ItemNumbers:     []int{1},
+		})
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, []string{"1"}, calls)
+		assert.True(t, result.NeedsConfirmation())
+		assert.False(t, result.Confirmed)
+		assert.Equal(t, []int{2}, result.MustAlsoCancel)
+		if assert.Len(t, result.Outcomes, 2) {
+			assert.Equal(t, CancellationOutcome{ItemNumber: 1, Requested: true}, result.Outcomes[0])
+			assert.Equal(t, 2, result.Outcomes[1].ItemNumber)
+			assert.False(t, result.Outcomes[1].Requested)
+			assert.Equal(t, "possible", result.Outcomes[1].Status)
+			assert.False(t, result.Outcomes[1].Cancelled())
+		}
+	})
+
+	t.Run("confirm and retry", func(t *testing.T) {
+		calls = nil
+		result, err := client.CancelOrders(context.Background(), &CancelOrdersParams{
+			TransactionUUID:       transUUID,
+			ItemNumbers:           []int{1, 1},
+			ConfirmMustAlsoCancel: true,
+		})
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, []string{"1", "1,2"}, calls)
+		assert.False(t, result.NeedsConfirmation())
+		assert.True(t, result.Confirmed)
+		assert.Equal(t, []int{2}, result.MustAlsoCancel)
+		assert.Equal(t, []int{1, 2}, result.Result.CancelledItemNumbers)
+
+		first, ok := result.Outcome(1)
+		if assert.True(t, ok) {
+			assert.True(t, first.Requested)
+			assert.True(t, first.Cancelled())
+			assert.Equal(t, "ATTEMPT-482290", first.BackendCancellationReference)
+		}
+		second, ok := result.Outcome(2)
+		if assert.True(t, ok) {
+			assert.False(t, second.Requested)
+			assert.True(t, second.Cancelled())
+			assert.Equal(t, "Cancelled together with item 1", second.Comment)
+			assert.Equal(t, "ATTEMPT-482291", second.BackendCancellationReference)
+		}
+		_, ok = result.Outcome(3)
+		assert.False(t, ok)
+	})
+
+	t.Run("no dependencies", func(t *testing.T) {
+		calls = nil
+		result, err := client.CancelOrders(context.Background(), &CancelOrdersParams{
+			TransactionUUID:       transUUID,
+			ItemNumbers:           []int{2, 1},
+			ConfirmMustAlsoCancel: true,
+		})
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, []string{"2,1"}, calls)
+		assert.False(t, result.Confirmed)
+		assert.Empty(t, result.MustAlsoCancel)
+		if assert.Len(t, result.Outcomes, 2) {
+			assert.Equal(t, 1, result.Outcomes[0].ItemNumber)
+			assert.True(t, result.Outcomes[0].Requested)
+			assert.True(t, result.Outcomes[1].Requested)
+		}
+	})
+
+	t.Run("no items", func(t *testing.T) {
+		_, err := client.CancelOrders(context.Background(), &CancelOrdersParams{TransactionUUID: transUUID})
+		assert.NotNil(t, err)
+		_, err = client.CancelOrders(context.Background(), nil)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("request error", func(t *testing.T) {
+		client := NewClient(&Config{BaseURL: "not a real url", User: "bill", Password: "hahaha"})
+		_, err := client.CancelOrders(context.Background(), &CancelOrdersParams{
+			TransactionUUID: transUUID,
+			ItemNumbers:     []int{1},
+		})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestCancellationResult_in_desired_currency(t *testing.T) {
+	data, err := os.ReadFile("testdata/must_also_cancel.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cancellation CancellationResult
+	if err := json.Unmarshal(data, &cancellation); err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, cancellation.MustAlsoCancel, 1) {
+		order := cancellation.MustAlsoCancel[0]
+		assert.Equal(t, "367.83", order.TotalSaleSeatpriceInDesired.String())
+		assert.Equal(t, "39.41", order.TotalSaleSurchargeInDesired.String())
+		assert.Equal(t, "407.24", order.TotalSaleCombinedInDesired.String())
+		assert.Equal(t, "8.76", order.SendMethod.CostInDesired.String())
+		ticketOrder := order.TicketOrdersHolder.TicketOrders[0]
+		assert.Equal(t, "122.61", ticketOrder.SaleSeatpriceInDesired.String())
+		assert.Equal(t, "13.14", ticketOrder.SaleSurchargeInDesired.String())
+		assert.Equal(t, "135.75", ticketOrder.SaleCombinedInDesired.String())
+		assert.Equal(t, "407.24", ticketOrder.TotalSaleCombinedInDesired.String())
+	}
+}
@@ -45,6 +45,8 @@ type FunctionParams interface {
 type Client struct {
 	Config     *Config
 	HTTPClient *http.Client
+	// optionally records every transactional call, see Journal.
+	Journal Journal
 }
 
 // NewClient returns a pointer to a newly created client.
@@ -83,6 +85,12 @@ func (client *Client) getURL(r *Request) (*url.URL, error) {
 		q.Set("sub_id", client.Config.SubUser)
 	}
 
+	if client.Config.DesiredCurrency != "" && q.Get("desired_currency") == "" {
+		if body, ok := r.Body.(map[string]string); !ok || body["desired_currency"] == "" {
+			q.Set("desired_currency", client.Config.DesiredCurrency)
+		}
+	}
+
 	u.RawQuery = q.Encode()
 	u.Path = fmt.Sprintf("%s/f13/%s", u.Path, r.Endpoint)
 	return u, nil
@@ -126,6 +134,11 @@ func (client *Client) Do(ctx context.Context, req *Request) (resp *http.Response
 	if err != nil {
 		return nil, err
 	}
+	if subUser, ok := GetSubUser(ctx); ok && subUser != "" {
+		q := u.Query()
+		q.Set("sub_id", subUser)
+		u.RawQuery = q.Encode()
+	}
 	err = client.setHeaders(ctx, req)
 	if err != nil {
 		return nil, err
@@ -213,7 +226,10 @@ type UniversalParams struct {
 	CostRangeDetails             bool
 	SourceInfo                   bool
 	TrackingID                   string
-	Misc                         map[string]string
+	// the ISO 4217 code of the currency prices should also be given in,
+	// overriding Config.DesiredCurrency.
+	DesiredCurrency string
+	Misc            map[string]string
 }
 
 // Universal returns the parameters as a map of parameters
@@ -280,6 +296,9 @@ func (params *UniversalParams) Universal() map[string]string {
 	if params.AddCustomer {
 		v["add_customer"] = "1"
 	}
+	if params.DesiredCurrency != "" {
+		v["desired_currency"] = params.DesiredCurrency
+	}
 
 	for k, val := range params.Misc {
 		v[k] = val
@@ -407,6 +426,17 @@ func (client *Client) ListEvents(ctx context.Context, params *ListEventsParams)
 		results.Currencies = currencies
 	}
 
+	if raw, ok := doc["currency_code"]; ok {
+		if err := json.Unmarshal(raw, &results.DefaultCurrencyCode); err != nil {
+			return nil, err
+		}
+	}
+	if raw, ok := doc["desired_currency_code"]; ok {
+		if err := json.Unmarshal(raw, &results.DesiredCurrencyCode); err != nil {
+			return nil, err
+		}
+	}
+
 	return &results, nil
 }
 
@@ -537,6 +567,7 @@ func (client *Client) ListPerformances(ctx context.Context, params *ListPerforma
 	if err != nil {
 		return nil, err
 	}
+	doc.Results.AutoSelect = doc.AutoSelect
 
 	return &doc.Results, nil
 }
@@ -669,9 +700,52 @@ func (client *Client) GetSendMethods(ctx context.Context, perf string, params *U
 	return &results, nil
 }
 
-// MakeReservation places a hold on products in the inventory via the API
+// MakeReservation places a hold on products in the inventory via the API.
+// When specific seats are requested ReservationResult.SeatRequestOutcome says
+// which were reserved; with params.RequireRequestedSeats set anything other
+// than the exact seats is released and returned as a SeatRequestError.
 func (client *Client) MakeReservation(ctx context.Context, params *MakeReservationParams) (*ReservationResult, error) {
-	req := NewRequest(http.MethodPost, "reserve.v1", params.Params())
+	result, err := client.reserve(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// the outcome is only nil when no seats were requested, as seats the
+	// reservation doesn't list count as missing.
+	result.SeatRequestOutcome = result.seatRequestOutcome(params.Seats)
+	if !params.RequireRequestedSeats || result.SeatRequestOutcome == nil || result.SeatRequestOutcome.Exact() {
+		return result, nil
+	}
+
+	seatErr := &SeatRequestError{
+		Outcome:         result.SeatRequestOutcome,
+		TransactionUUID: result.Trolley.TransactionUUID,
+	}
+	if seatErr.TransactionUUID != "" {
+		seatErr.Released, seatErr.ReleaseErr = client.ReleaseReservation(ctx, &TransactionParams{
+			UniversalParams: params.UniversalParams,
+			TransactionUUID: seatErr.TransactionUUID,
+		})
+	}
+	return nil, seatErr
+}
+
+// reserve makes the reserve.v1 call.
+func (client *Client) reserve(ctx context.Context, params *MakeReservationParams) (result *ReservationResult, err error) {
+	values := params.Params()
+	entry, err := client.startJournal(&JournalEntry{Call: "reserve.v1", Params: sanitizeParams(values, nil)})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if entry != nil && result != nil {
+			entry.TransactionUUID = result.Trolley.TransactionUUID
+			entry.Status = result.Status
+		}
+		client.finishJournal(entry, err)
+	}()
+
+	req := NewRequest(http.MethodPost, "reserve.v1", values)
 
 	resp, err := client.Do(ctx, req)
 	if err != nil {
@@ -711,7 +785,26 @@ func (params *TransactionParams) Params() map[string]string {
 // ReleaseReservation makes a best effort attempt to release any reservations
 // made on backend systems for a transaction.
 func (client *Client) ReleaseReservation(ctx context.Context, params *TransactionParams) (success bool, err error) {
-	req := NewRequest(http.MethodPost, "release.v1", params.Params())
+	values := params.Params()
+	entry, err := client.startJournal(&JournalEntry{
+		Call:            "release.v1",
+		TransactionUUID: params.TransactionUUID,
+		Params:          sanitizeParams(values, nil),
+	})
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if entry != nil && err == nil {
+			entry.Status = "not_released"
+			if success {
+				entry.Status = "released"
+			}
+		}
+		client.finishJournal(entry, err)
+	}()
+
+	req := NewRequest(http.MethodPost, "release.v1", values)
 
 	resp, err := client.Do(ctx, req)
 	if err != nil {
@@ -779,10 +872,57 @@ func (params *MakePurchaseParams) Params() map[string]string {
 	return values
 }
 
+// Validate checks the purchase parameters against the requirements of the
+// reservation being purchased, returning ValidationErrors listing every
+// problem with the customer information. An agent reference on the params
+// satisfies the reservation's NeedsAgentReference.
+func (params *MakePurchaseParams) Validate(reservation *ReservationResult) error {
+	customer := params.Customer
+	if params.AgentReference != "" {
+		customer.AgentReference = params.AgentReference
+	}
+
+	var errs ValidationErrors
+	if err := customer.Validate(reservation); err != nil {
+		errs = append(errs, err.(ValidationErrors)...)
+	}
+	if params.SendConfirmationEmail && customer.EmailAddress == "" {
+		if _, ok := errs.Field("email_address"); !ok {
+			errs = append(errs, FieldError{Field: "email_address", Message: "is required to send a confirmation email"})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
 // MakePurchase attempts to purchase a previously reserved transaction via the
 // API
-func (client *Client) MakePurchase(ctx context.Context, params *MakePurchaseParams) (*MakePurchaseResult, error) {
-	req := NewRequest(http.MethodPost, "purchase.v1", params.Params())
+func (client *Client) MakePurchase(ctx context.Context, params *MakePurchaseParams) (result *MakePurchaseResult, err error) {
+	values := params.Params()
+	var payment map[string]string
+	if params.PaymentMethod != nil {
+		payment = params.PaymentMethod.PaymentParams()
+	}
+	entry, err := client.startJournal(&JournalEntry{
+		Call:            "purchase.v1",
+		TransactionUUID: params.TransactionUUID,
+		AgentReference:  params.AgentReference,
+		Params:          sanitizeParams(values, payment),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if entry != nil && result != nil {
+			entry.Status = result.Status
+		}
+		client.finishJournal(entry, err)
+	}()
+
+	req := NewRequest(http.MethodPost, "purchase.v1", values)
 
 	resp, err := client.Do(ctx, req)
 	if err != nil {
@@ -790,14 +930,14 @@ func (client *Client) MakePurchase(ctx context.Context, params *MakePurchasePara
 	}
 	defer resp.Body.Close()
 
-	var result MakePurchaseResult
+	var purchase MakePurchaseResult
 	decoder := json.NewDecoder(resp.Body)
-	err = decoder.Decode(&result)
+	err = decoder.Decode(&purchase)
 	if err != nil {
 		return nil, err
 	}
 
-	return &result, nil
+	return &purchase, nil
 }
 
 // nolint:dupl
@@ -856,25 +996,48 @@ func (params *CancellationParams) Params() map[string]string {
 
 // nolint:dupl
 // Cancel cancels transactions via the API
-func (client *Client) Cancel(ctx context.Context, params *CancellationParams) (*CancellationResult, error) {
+func (client *Client) Cancel(ctx context.Context, params *CancellationParams) (result *CancellationResult, err error) {
 	req := NewRequest(http.MethodPost, "cancel.v1", nil)
 	if params != nil {
 		req.SetValues(params.Params())
 	}
+
+	journalled := &JournalEntry{Call: "cancel.v1", Params: map[string]string{}}
+	if params != nil {
+		journalled.TransactionUUID = params.TransactionUUID
+		journalled.Params = sanitizeParams(params.Params(), nil)
+	}
+	entry, err := client.startJournal(journalled)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if entry != nil && result != nil {
+			switch {
+			case result.IsFullyCancelled():
+				entry.Status = "cancelled"
+			case len(result.CancelledItemNumbers) > 0:
+				entry.Status = "partially_cancelled"
+			default:
+				entry.Status = "not_cancelled"
+			}
+		}
+		client.finishJournal(entry, err)
+	}()
 	resp, err := client.Do(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	var result CancellationResult
+	var cancellation CancellationResult
 	decoder := json.NewDecoder(resp.Body)
-	err = decoder.Decode(&result)
+	err = decoder.Decode(&cancellation)
 	if err != nil {
 		return nil, err
 	}
 
-	return &result, nil
+	return &cancellation, nil
 }
 
 // EmailCheck will check whether email passed meets
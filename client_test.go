@@ -94,6 +94,33 @@ func TestGetURL_with_sub_user(t *testing.T) {
 	}
 }
 
+func TestGetURL_with_desired_currency(t *testing.T) {
+	config := &Config{
+		BaseURL:         "https://super.awesome.tickets",
+		DesiredCurrency: "usd",
+	}
+	client := NewClient(config)
+
+	req := NewRequest("GET", "events.v1", nil)
+	u, err := client.getURL(req)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "https://super.awesome.tickets/f13/events.v1?desired_currency=usd", u.String())
+	}
+
+	// the request's own desired currency wins, in the query or the body.
+	req.Values.Set("desired_currency", "eur")
+	u, err = client.getURL(req)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "https://super.awesome.tickets/f13/events.v1?desired_currency=eur", u.String())
+	}
+
+	req = NewRequest("POST", "reserve.v1", map[string]string{"desired_currency": "eur"})
+	u, err = client.getURL(req)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "https://super.awesome.tickets/f13/reserve.v1", u.String())
+	}
+}
+
 func TestSetHeaders(t *testing.T) {
 	config := &Config{
 		User:     "fred_flintstone",
@@ -175,6 +202,28 @@ func TestDo_post(t *testing.T) {
 	}
 }
 
+func TestDo_sub_user(t *testing.T) {
+	subUsers := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			subUsers = append(subUsers, r.URL.Query().Get("sub_id"))
+		}))
+	defer server.Close()
+
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha", SubUser: "box-office"})
+	for _, ctx := range []context.Context{
+		context.Background(),
+		SetSubUser(context.Background(), "web"),
+		SetSubUser(context.Background(), ""),
+	} {
+		resp, err := client.Do(ctx, NewRequest("GET", "events.v1", nil))
+		if assert.Nil(t, err) {
+			resp.Body.Close()
+		}
+	}
+	assert.Equal(t, []string{"box-office", "web", "box-office"}, subUsers)
+}
+
 func TestDo_get(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
@@ -565,6 +614,12 @@ func TestUniversalParams_Universal(t *testing.T) {
 	}
 	values = params.Universal()
 	assert.Equal(t, "1", values["add_customer"])
+
+	params = UniversalParams{
+		DesiredCurrency: "usd",
+	}
+	values = params.Universal()
+	assert.Equal(t, "usd", values["desired_currency"])
 }
 
 func TestPaginationParams_Pagination(t *testing.T) {
@@ -704,6 +759,59 @@ func TestListEvents(t *testing.T) {
 	}
 }
 
+func TestListEvents_desired_currency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "usd", r.URL.Query().Get("desired_currency"))
+			w.Write([]byte(`
+                {
+                  "currency_code": "gbp",
+                  "desired_currency_code": "usd",
+                  "currency_details": {
+                    "gbp": {"currency_code": "gbp"},
+                    "usd": {"currency_code": "usd"}
+                  },
+                  "results": {
+                    "event": [
+                      {
+                        "event_id": "6KT",
+                        "cost_range": {
+                          "currency_code": "gbp",
+                          "desired_currency_code": "usd",
+                          "min_seatprice": 20,
+                          "min_seatprice_in_desired": 25.5,
+                          "max_surcharge_in_desired": 3.1,
+                          "best_value_offer": {
+                            "offer_seatprice": 15,
+                            "offer_seatprice_in_desired": 19.13
+                          }
+                        }
+                      }
+                    ]
+                  }
+                }
+            `))
+		}))
+	defer server.Close()
+
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha", DesiredCurrency: "usd"})
+	results, err := client.ListEvents(context.Background(), &ListEventsParams{})
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "gbp", results.DefaultCurrencyCode)
+		assert.Equal(t, "usd", results.DesiredCurrencyCode)
+		assert.Contains(t, results.Currencies, "usd")
+		if assert.Len(t, results.Events, 1) {
+			costRange := results.Events[0].CostRange
+			assert.Equal(t, "usd", costRange.DesiredCurrencyCode)
+			assert.Equal(t, "20", costRange.MinSeatPrice.String())
+			assert.Equal(t, "25.5", costRange.MinSeatPriceInDesired.String())
+			assert.Equal(t, "3.1", costRange.MaxSurchargeInDesired.String())
+			assert.Equal(t, "19.13", costRange.BestValueOffer.SeatPriceInDesired.String())
+		}
+	}
+}
+
 func TestListEvents_request_error(t *testing.T) {
 	config := &Config{
 		// Invalid protocol will result in a http.Do error
@@ -1098,6 +1206,24 @@ func TestListPerformanceTimesWithNoDates(t *testing.T) {
 	assert.Equal(t, len(results.Times), 0)
 }
 
+func TestPriceBand_Desc(t *testing.T) {
+	availabilityJSON, err := os.ReadFile("testdata/availability.json")
+	if err != nil {
+		t.Fatalf("Cannot find testdata/availability.json")
+	}
+	var results AvailabilityResult
+	if !assert.Nil(t, json.Unmarshal(availabilityJSON, &results)) {
+		return
+	}
+	var descs []string
+	for _, ticketType := range results.Availability.TicketTypes {
+		for _, priceBand := range ticketType.PriceBands {
+			descs = append(descs, priceBand.Desc)
+		}
+	}
+	assert.Equal(t, []string{"TEST PB1", "TEST PB2", "TEST PB3", "TEST PB4"}, descs)
+}
+
 func TestGetAvailability(t *testing.T) {
 	availabilityJSON, err := os.ReadFile("testdata/availability.json")
 	if err != nil {
@@ -1441,6 +1567,7 @@ func TestMakeReservationFailure(t *testing.T) {
 		assert.Equal(t, len(results.UnreservedOrders), 1)
 		assert.Equal(t, results.UnreservedOrders[0].ItemNumber, 1)
 		assert.Equal(t, results.UnreservedOrders[0].RequestedSeatIDs, []string{"H9", "H10"})
+		assert.Equal(t, []string{"H9", "H10"}, results.SeatRequestOutcome.Missing)
 	}
 }
 
@@ -1622,6 +1749,76 @@ func TestMakePurchase_success(t *testing.T) {
 	}
 }
 
+func TestMakePurchaseParams_with_redirection_details(t *testing.T) {
+	params := MakePurchaseParams{
+		PaymentMethod: RedirectionDetails{
+			Token:      "abc",
+			URL:        "https://example.com/return/abc",
+			UserAgent:  "Mozilla/5.0",
+			Accept:     "text/html",
+			RemoteSite: "https://example.com",
+		},
+	}
+
+	values := params.Params()
+	assert.Equal(t, "abc", values["return_token"])
+	assert.Equal(t, "https://example.com/return/abc", values["return_url"])
+	assert.Equal(t, "Mozilla/5.0", values["client_http_user_agent"])
+	assert.Equal(t, "text/html", values["client_http_accept"])
+	assert.Equal(t, "https://example.com", values["remote_site"])
+}
+
+func TestCallback(t *testing.T) {
+	data, err := os.ReadFile("testdata/purchase-credit-success.json")
+	if err != nil {
+		t.Fatalf("testdata/purchase-credit-success.json")
+	}
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/f13/callback.v1/this.abc/next.def", r.URL.Path)
+			assert.Equal(t, http.MethodPost, r.Method)
+			var inputs map[string]interface{}
+			decoder := json.NewDecoder(r.Body)
+			if err2 := decoder.Decode(&inputs); err2 != nil {
+				t.Fatal(err2)
+			}
+			assert.Equal(t, "Y", inputs["PaRes"])
+			w.Write(data)
+		}))
+	defer server.Close()
+
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+	result, err := client.Callback(context.Background(), &CallbackParams{
+		ReturnToken:     "abc",
+		NextReturnToken: "def",
+		Returned:        map[string]string{"PaRes": "Y"},
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "purchased", result.Status)
+		assert.Equal(t, "4df498e9-2daa-4393-a6bb-cc3dfefa7cc1", result.Trolley.TransactionUUID)
+	}
+}
+
+func TestCallback_gone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusGone)
+		}))
+	defer server.Close()
+
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+	_, err := client.Callback(context.Background(), &CallbackParams{ReturnToken: "abc", NextReturnToken: "def"})
+	if assert.Error(t, err) {
+		assert.True(t, err.(Error).CallbackGoneError)
+	}
+}
+
+func TestCallback_without_tokens(t *testing.T) {
+	client := NewClient(&Config{BaseURL: "http://localhost", User: "bill", Password: "hahaha"})
+	_, err := client.Callback(context.Background(), &CallbackParams{ReturnToken: "abc"})
+	assert.Error(t, err)
+}
+
 func TestGetStatusWithCustomer(t *testing.T) {
 	data, err := os.ReadFile("testdata/status_with_customer.json")
 	if err != nil {
@@ -0,0 +1,622 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+)
+
+const dateLayout = "2006-01-02"
+
+func init() {
+	register(&command{name: "test", usage: "check the credentials and show the user", run: runTest})
+	register(&command{name: "events", args: "[flags] [keyword...]", usage: "search for events", run: runEvents})
+	register(&command{name: "event", args: "[flags] <event-id>", usage: "show an event", run: runEvent})
+	register(&command{name: "performances", args: "[flags] <event-id>", usage: "list the performances of an event", run: runPerformances})
+	register(&command{name: "times", args: "[flags] <event-id>", usage: "list the performance times of an event", run: runTimes})
+	register(&command{name: "availability", args: "[flags] <perf-id>|-event <event-id>", usage: "show the availability of a performance or product", run: runAvailability})
+	register(&command{name: "discounts", args: "<perf-id> <ticket-type> <price-band>", usage: "list the discounts for a price band", run: runDiscounts})
+	register(&command{name: "send-methods", args: "[flags] <perf-id>", usage: "list the send methods for a performance", run: runSendMethods})
+	register(&command{name: "sources", usage: "list the backend systems", run: runSources})
+	register(&command{name: "reserve", args: "-perf <perf-id> -ticket-type <code> -price-band <code> [flags]", usage: "reserve tickets", run: runReserve})
+	register(&command{name: "purchase", args: "[flags] <transaction-uuid>", usage: "purchase a reserved transaction", run: runPurchase})
+	register(&command{name: "status", args: "[flags] <transaction-uuid>", usage: "show the status of a transaction", run: runStatus})
+	register(&command{name: "voucher", args: "[flags] <transaction-uuid>", usage: "write the self print voucher of a purchased order", run: runVoucher})
+	register(&command{name: "calendar", args: "[flags] <transaction-uuid>", usage: "write the performances of a transaction as an iCalendar document", run: runCalendar})
+	register(&command{name: "release", args: "<transaction-uuid>", usage: "release a reserved transaction", run: runRelease})
+	register(&command{name: "cancel", args: "[flags] <transaction-uuid> [item-number...]", usage: "cancel a purchased transaction or some of its orders", run: runCancel})
+	register(&command{name: "watch", args: "[flags] <perf-id...>", usage: "report availability changes until interrupted", run: runWatch, untimed: true})
+	register(&command{name: "export", args: "[flags]", usage: "write every event and performance as CSV or JSON lines", run: runExport, untimed: true})
+	register(&command{name: "reconcile", args: "[flags] [transaction-uuid...]", usage: "compare transactions with their recorded state and report discrepancies", run: runReconcile, untimed: true})
+	register(&command{name: "commission", args: "[flags] [transaction-uuid...]", usage: "write the commission earned on purchased transactions as CSV", run: runCommission, untimed: true})
+	register(&command{name: "email-check", args: "<email-address>", usage: "check an email address is acceptable to the API", run: runEmailCheck})
+}
+
+// universalFlags binds the commonly used UniversalParams to flags.
+func universalFlags(flags *flag.FlagSet, params *ticketswitch.UniversalParams) {
+	flags.BoolVar(&params.CostRange, "cost-range", false, "include cost ranges")
+	flags.BoolVar(&params.Availability, "avail-details", false, "include cached availability details")
+	flags.BoolVar(&params.ExtraInfo, "extra-info", false, "include extra information")
+	flags.BoolVar(&params.Media, "media", false, "include media")
+	flags.BoolVar(&params.Reviews, "reviews", false, "include reviews")
+	flags.BoolVar(&params.SourceInfo, "source-info", false, "include source information")
+	flags.StringVar(&params.TrackingID, "custom-tracking-id", "", "custom tracking id for the call")
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, usageErrorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+	return date, nil
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	parts := strings.Split(value, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func runTest(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "test")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+	user, err := env.client.Test(ctx)
+	if err != nil {
+		return err
+	}
+	return env.out.print(user, fields(
+		"user", user.ID,
+		"name", user.Name,
+		"sub user", user.SubUser,
+		"country", user.Country,
+		"b2b", user.IsB2B,
+		"backend group", user.BackendGroup,
+		"content group", user.ContentGroup,
+	))
+}
+
+func eventsTable(events []ticketswitch.Event) *table {
+	t := newTable("EVENT", "DESCRIPTION", "VENUE", "CITY", "STATUS", "MIN PRICE", "MAX PRICE")
+	for _, event := range events {
+		var min, max string
+		if !event.CostRange.MinSeatPrice.IsZero() || !event.CostRange.MaxSeatPrice.IsZero() {
+			min = event.CostRange.MinSeatPrice.Add(event.CostRange.MinSurcharge).StringFixed(2)
+			max = event.CostRange.MaxSeatPrice.Add(event.CostRange.MaxSurcharge).StringFixed(2)
+		}
+		t.add(event.ID, event.Description, event.Venue, event.City, event.Status, min, max)
+	}
+	return t
+}
+
+func runEvents(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "events")
+	params := &ticketswitch.ListEventsParams{}
+	universalFlags(flags, &params.UniversalParams)
+	flags.StringVar(&params.CountryCode, "country", "", "ISO 3166-1 country code")
+	flags.StringVar(&params.CityCode, "city", "", "city code")
+	flags.StringVar(&params.SortOrder, "sort", "", "sort order, e.g. most_popular or alphabetic")
+	flags.BoolVar(&params.IncludeDead, "include-dead", false, "include dead events")
+	flags.IntVar(&params.PageNumber, "page", 0, "page number")
+	flags.IntVar(&params.PageLength, "page-length", 0, "results per page")
+	from := flags.String("from", "", "start date, YYYY-MM-DD")
+	to := flags.String("to", "", "end date, YYYY-MM-DD")
+	if err := parseFlags(flags, args, 0, -1); err != nil {
+		return err
+	}
+	var err error
+	if params.StartDate, err = parseDate(*from); err != nil {
+		return err
+	}
+	if params.EndDate, err = parseDate(*to); err != nil {
+		return err
+	}
+	params.Keywords = flags.Args()
+
+	results, err := env.client.ListEvents(ctx, params)
+	if err != nil {
+		return err
+	}
+	return env.out.print(results, eventsTable(results.Events))
+}
+
+func runEvent(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "event")
+	params := &ticketswitch.UniversalParams{}
+	universalFlags(flags, params)
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	event, err := env.client.GetEvent(ctx, flags.Arg(0), params)
+	if err != nil {
+		return err
+	}
+
+	codes := make([]string, 0, len(event.Classes))
+	for code := range event.Classes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	classes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		classes = append(classes, event.Classes[code])
+	}
+	return env.out.print(event, fields(
+		"event", event.ID,
+		"description", event.Description,
+		"status", event.Status,
+		"type", event.EventType,
+		"source", event.SourceCode,
+		"venue", event.Venue,
+		"city", event.City,
+		"country", event.CountryCode,
+		"postcode", event.Postcode,
+		"classes", classes,
+		"seated", event.IsSeated,
+		"has no performances", event.HasNoPerformances,
+		"needs performance", event.NeedsPerformance,
+		"needs departure date", event.NeedsDepartureDate,
+		"needs duration", event.NeedsDuration,
+		"min running time", event.MinRunningTime,
+		"max running time", event.MaxRunningTime,
+	))
+}
+
+func performanceFlags(flags *flag.FlagSet) (*ticketswitch.ListPerformancesParams, func() error) {
+	params := &ticketswitch.ListPerformancesParams{}
+	universalFlags(flags, &params.UniversalParams)
+	flags.IntVar(&params.PageNumber, "page", 0, "page number")
+	flags.IntVar(&params.PageLength, "page-length", 0, "results per page")
+	from := flags.String("from", "", "start date, YYYY-MM-DD")
+	to := flags.String("to", "", "end date, YYYY-MM-DD")
+	return params, func() error {
+		var err error
+		if params.StartDate, err = parseDate(*from); err != nil {
+			return err
+		}
+		params.EndDate, err = parseDate(*to)
+		return err
+	}
+}
+
+func runPerformances(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "performances")
+	params, dates := performanceFlags(flags)
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	if err := dates(); err != nil {
+		return err
+	}
+	params.EventID = flags.Arg(0)
+
+	results, err := env.client.ListPerformances(ctx, params)
+	if err != nil {
+		return err
+	}
+	t := newTable("PERFORMANCE", "DATE", "NAME", "RUNNING TIME", "LIMITED", "GHOST")
+	for _, perf := range results.Performances {
+		t.add(perf.ID, formatTime(perf.Datetime), perf.Name, perf.RunningTime, perf.IsLimited, perf.IsGhost)
+	}
+	return env.out.print(results, t)
+}
+
+func runTimes(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "times")
+	params, dates := performanceFlags(flags)
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	if err := dates(); err != nil {
+		return err
+	}
+	params.EventID = flags.Arg(0)
+
+	results, err := env.client.ListPerformanceTimes(ctx, params)
+	if err != nil {
+		return err
+	}
+	t := newTable("TIME", "DESCRIPTION")
+	for _, perfTime := range results.Times {
+		t.add(formatTime(perfTime.Datetime), perfTime.TimeDesc)
+	}
+	return env.out.print(results, t)
+}
+
+func runAvailability(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "availability")
+	params := &ticketswitch.GetAvailabilityParams{}
+	flags.IntVar(&params.NumberOfSeats, "seats", 0, "number of seats wanted")
+	flags.BoolVar(&params.Discounts, "discounts", false, "include possible discounts")
+	flags.BoolVar(&params.ExampleSeats, "example-seats", false, "include example seats")
+	flags.BoolVar(&params.SeatBlocks, "seat-blocks", false, "include seat blocks")
+	flags.BoolVar(&params.UserCommission, "commission", false, "include predicted commission")
+	flags.IntVar(&params.Duration, "duration", 0, "duration, for products that need one")
+	departure := flags.String("departure-date", "", "departure date, YYYY-MM-DD, for products that need one")
+	event := flags.Bool("event", false, "the argument is the id of an event booked without choosing a performance")
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	var err error
+	if params.DepartureDate, err = parseDate(*departure); err != nil {
+		return err
+	}
+
+	var results interface{}
+	var availability *ticketswitch.AvailabilityResult
+	tables := make([]*table, 0, 2)
+	if *event {
+		product, err2 := getProductAvailability(ctx, env, flags.Arg(0), params)
+		if err2 != nil {
+			return err2
+		}
+		results, availability = product, product.AvailabilityResult
+		tables = append(tables, fields("performance", product.PerformanceID))
+	} else {
+		if availability, err = env.client.GetAvailability(ctx, flags.Arg(0), params); err != nil {
+			return err
+		}
+		results = availability
+	}
+	t := newTable("TICKET TYPE", "PRICE BAND", "DESCRIPTION", "AVAILABLE", "SEATPRICE", "SURCHARGE", "OFFER")
+	for _, ticketType := range availability.Availability.TicketTypes {
+		for _, band := range ticketType.PriceBands {
+			t.add(ticketType.Code, band.Code, band.Desc, band.NumberAvailable, band.Seatprice, band.Surcharge, band.IsOffer)
+		}
+	}
+	return env.out.print(results, append(tables, t)...)
+}
+
+func getProductAvailability(ctx context.Context, env *environment, eventID string, params *ticketswitch.GetAvailabilityParams) (*ticketswitch.ProductAvailability, error) {
+	event, err := env.client.GetEvent(ctx, eventID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return env.client.GetProductAvailability(ctx, event, params)
+}
+
+func runDiscounts(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "discounts")
+	if err := parseFlags(flags, args, 3, 3); err != nil {
+		return err
+	}
+	results, err := env.client.GetDiscounts(ctx, flags.Arg(0), flags.Arg(1), flags.Arg(2), nil)
+	if err != nil {
+		return err
+	}
+	t := newTable("DISCOUNT", "DESCRIPTION", "AVAILABLE", "SEATPRICE", "SURCHARGE", "MIN AGE", "MAX AGE")
+	for _, discount := range results.DiscountsHolder.Discounts {
+		t.add(discount.Code, discount.Description, discount.NumberAvailable, discount.Seatprice, discount.Surcharge,
+			discount.MinimumEligibleAge, discount.MaximumEligibleAge)
+	}
+	return env.out.print(results, t)
+}
+
+func runSendMethods(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "send-methods")
+	country := flags.String("country", "", "only send methods for this ISO 3166-1 country code, best first")
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	results, err := env.client.GetSendMethods(ctx, flags.Arg(0), nil)
+	if err != nil {
+		return err
+	}
+	methods := results.SendMethodsHolder.SendMethods
+	if *country != "" {
+		methods = results.ForCountry(*country)
+	}
+	t := newTable("SEND METHOD", "DESCRIPTION", "TYPE", "COST", "COUNTRIES")
+	for _, method := range methods {
+		countries := make([]string, 0, len(method.PermittedCountries.Countries))
+		for _, country := range method.PermittedCountries.Countries {
+			countries = append(countries, country.Code)
+		}
+		t.add(method.Code, method.Desc, method.Type, method.Cost, countries)
+	}
+	return env.out.print(results, t)
+}
+
+func runSources(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "sources")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+	results, err := env.client.GetSources(ctx, &ticketswitch.UniversalParams{SourceInfo: true})
+	if err != nil {
+		return err
+	}
+	t := newTable("SOURCE", "DESCRIPTION", "TYPE", "EMAIL")
+	for _, source := range results.Sources {
+		t.add(source.Code, source.Description, source.Type, source.Email)
+	}
+	return env.out.print(results.Sources, t)
+}
+
+// trolleyTable lists the orders in a trolley.
+func trolleyTable(trolley *ticketswitch.Trolley) *table {
+	t := newTable("ITEM", "SOURCE", "EVENT", "PERFORMANCE", "TICKET TYPE", "PRICE BAND", "SEATS", "SEATPRICE", "SURCHARGE", "CANCELLATION")
+	for _, bundle := range trolley.Bundles {
+		for _, order := range bundle.Orders {
+			t.add(order.ItemNumber, bundle.SourceCode, order.Event.ID, order.Performance.ID, order.TicketTypeCode,
+				order.PriceBandCode, order.TotalNumberOfSeats, order.TotalSaleSeatprice, order.TotalSaleSurcharge,
+				order.CancellationStatus)
+		}
+	}
+	return t
+}
+
+func runReserve(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "reserve")
+	params := &ticketswitch.MakeReservationParams{}
+	flags.StringVar(&params.PerformanceID, "perf", "", "performance id (required)")
+	flags.StringVar(&params.TicketTypeCode, "ticket-type", "", "ticket type code (required)")
+	flags.StringVar(&params.PriceBandCode, "price-band", "", "price band code (required)")
+	flags.IntVar(&params.NumberOfSeats, "seats", 1, "number of seats")
+	flags.StringVar(&params.SendMethod, "send-method", "", "send method code, requires -source")
+	flags.StringVar(&params.SourceCode, "source", "", "source code of the send method")
+	flags.StringVar(&params.TrolleyToken, "trolley-token", "", "trolley token to add to")
+	flags.BoolVar(&params.UserCommission, "commission", false, "include predicted commission")
+	discounts := flags.String("discounts", "", "comma separated discount codes, one per seat")
+	seats := flags.String("seat-ids", "", "comma separated seat ids to request")
+	flags.BoolVar(&params.RequireRequestedSeats, "exact-seats", false, "release the reservation unless exactly the -seat-ids are reserved")
+	flags.IntVar(&params.Duration, "duration", 0, "duration, for products that need one")
+	departure := flags.String("departure-date", "", "departure date, YYYY-MM-DD")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+	if params.PerformanceID == "" || params.TicketTypeCode == "" || params.PriceBandCode == "" {
+		return usageErrorf("-perf, -ticket-type and -price-band are required")
+	}
+	params.Discounts = splitList(*discounts)
+	params.Seats = splitList(*seats)
+	var err error
+	if params.DepartureDate, err = parseDate(*departure); err != nil {
+		return err
+	}
+
+	result, err := env.client.MakeReservation(ctx, params)
+	if err != nil {
+		return err
+	}
+	return env.out.print(result, fields(
+		"transaction", result.Trolley.TransactionUUID,
+		"status", result.Status,
+		"minutes left", result.MinutesLeftOnReserve,
+		"needs email address", result.NeedsEmailAddress,
+		"needs agent reference", result.NeedsAgentReference,
+		"needs payment card", result.NeedsPaymentCard,
+	), trolleyTable(&result.Trolley))
+}
+
+func runPurchase(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "purchase")
+	params := &ticketswitch.MakePurchaseParams{}
+	customer := &params.Customer
+	flags.StringVar(&params.AgentReference, "agent-ref", "", "agent reference")
+	flags.BoolVar(&params.SendConfirmationEmail, "send-email", false, "ask the API to send a confirmation email")
+	flags.StringVar(&customer.Title, "title", "", "customer title")
+	flags.StringVar(&customer.FirstName, "first-name", "", "customer first name")
+	flags.StringVar(&customer.LastName, "last-name", "", "customer last name")
+	flags.StringVar(&customer.EmailAddress, "email", "", "customer email address")
+	flags.StringVar(&customer.Phone, "phone", "", "customer phone number")
+	flags.StringVar(&customer.AddressLineOne, "address-1", "", "first line of the customer's address")
+	flags.StringVar(&customer.AddressLineTwo, "address-2", "", "second line of the customer's address")
+	flags.StringVar(&customer.Town, "town", "", "customer town")
+	flags.StringVar(&customer.County, "county", "", "customer county")
+	flags.StringVar(&customer.Postcode, "postcode", "", "customer postcode")
+	flags.StringVar(&customer.CountryCode, "country", "", "customer ISO 3166-1 country code")
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	params.TransactionUUID = flags.Arg(0)
+	if err := params.Validate(nil); err != nil {
+		return usageErrorf("%s", err)
+	}
+
+	result, err := env.client.MakePurchase(ctx, params)
+	if err != nil {
+		return err
+	}
+	summary := fields(
+		"transaction", result.Trolley.TransactionUUID,
+		"status", result.Status,
+		"purchased", formatTime(result.PurchaseDatetime),
+	)
+	if result.Callout != nil {
+		summary.add("callout", result.Callout.Destination)
+	}
+	return env.out.print(result, summary, trolleyTable(&result.Trolley))
+}
+
+func runStatus(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "status")
+	params := &ticketswitch.TransactionParams{}
+	flags.BoolVar(&params.AddCustomer, "customer", false, "include the customer")
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	params.TransactionUUID = flags.Arg(0)
+
+	result, err := env.client.GetStatus(ctx, params)
+	if err != nil {
+		return err
+	}
+	return env.out.print(result, fields(
+		"transaction", result.Trolley.TransactionUUID,
+		"status", result.Status,
+		"reserved", formatTime(result.ReserveDatetime),
+		"purchased", formatTime(result.PurchaseDatetime),
+		"minutes left", result.MinutesLeftOnReserve,
+	), trolleyTable(&result.Trolley))
+}
+
+func runVoucher(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "voucher")
+	item := flags.Int("item", 0, "item number of the order, defaults to the first with a voucher")
+	path := flags.String("o", "", "file to write to instead of standard output")
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	status, err := env.client.GetStatus(ctx, &ticketswitch.TransactionParams{TransactionUUID: flags.Arg(0)})
+	if err != nil {
+		return err
+	}
+
+	var order *ticketswitch.Order
+	for i := range status.Trolley.Bundles {
+		for j := range status.Trolley.Bundles[i].Orders {
+			candidate := &status.Trolley.Bundles[i].Orders[j]
+			if order != nil || candidate.SendMethod.SelfPrintVoucherURL == "" {
+				continue
+			}
+			if *item == 0 || candidate.ItemNumber == *item {
+				order = candidate
+			}
+		}
+	}
+	if order == nil {
+		return fmt.Errorf("no order of %s has a self print voucher", flags.Arg(0))
+	}
+
+	voucher, err := env.client.GetSelfPrintVoucher(ctx, order)
+	if err != nil {
+		return err
+	}
+	if *path == "" {
+		_, err = env.out.w.Write(voucher.Body)
+		return err
+	}
+	return os.WriteFile(*path, voucher.Body, 0644)
+}
+
+func runCalendar(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "calendar")
+	calendar := &ticketswitch.Calendar{}
+	flags.StringVar(&calendar.Domain, "domain", "", "domain appended to the event UIDs")
+	path := flags.String("o", "", "file to write to instead of standard output")
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	status, err := env.client.GetStatus(ctx, &ticketswitch.TransactionParams{TransactionUUID: flags.Arg(0)})
+	if err != nil {
+		return err
+	}
+	if *path == "" {
+		return calendar.Write(env.out.w, &status.Trolley)
+	}
+	file, err := os.Create(*path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := calendar.Write(file, &status.Trolley); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func runRelease(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "release")
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	released, err := env.client.ReleaseReservation(ctx, &ticketswitch.TransactionParams{
+		TransactionUUID: flags.Arg(0),
+	})
+	if err != nil {
+		return err
+	}
+	result := map[string]bool{"released_ok": released}
+	if err := env.out.print(result, fields("released", strconv.FormatBool(released))); err != nil {
+		return err
+	}
+	if !released {
+		return errors.New("the reservation was not released")
+	}
+	return nil
+}
+
+func runCancel(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "cancel")
+	confirm := flags.Bool("confirm", false, "also cancel any orders the API says must be cancelled with the requested ones")
+	if err := parseFlags(flags, args, 1, -1); err != nil {
+		return err
+	}
+	uuid := flags.Arg(0)
+
+	if flags.NArg() == 1 {
+		result, err := env.client.Cancel(ctx, &ticketswitch.CancellationParams{TransactionUUID: uuid})
+		if err != nil {
+			return err
+		}
+		return env.out.print(result, fields(
+			"transaction", uuid,
+			"fully cancelled", strconv.FormatBool(result.IsFullyCancelled()),
+		), trolleyTable(&result.Trolley))
+	}
+
+	params := &ticketswitch.CancelOrdersParams{
+		TransactionUUID:       uuid,
+		ConfirmMustAlsoCancel: *confirm,
+	}
+	for _, arg := range flags.Args()[1:] {
+		item, err := strconv.Atoi(arg)
+		if err != nil {
+			return usageErrorf("invalid item number %q", arg)
+		}
+		params.ItemNumbers = append(params.ItemNumbers, item)
+	}
+
+	result, err := env.client.CancelOrders(ctx, params)
+	if err != nil {
+		return err
+	}
+	t := newTable("ITEM", "REQUESTED", "STATUS", "REFERENCE", "COMMENT")
+	for _, outcome := range result.Outcomes {
+		t.add(outcome.ItemNumber, outcome.Requested, outcome.Status, outcome.BackendCancellationReference, outcome.Comment)
+	}
+	if err := env.out.print(result, t); err != nil {
+		return err
+	}
+	if result.NeedsConfirmation() {
+		return errors.New("nothing was cancelled, the API says the other listed orders must also be cancelled; run again with -confirm")
+	}
+	return nil
+}
+
+func runEmailCheck(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "email-check")
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	err := env.client.EmailCheck(ctx, &ticketswitch.EmailCheckParams{EmailAddress: flags.Arg(0)})
+	if err != nil {
+		return err
+	}
+	return env.out.print(map[string]bool{"valid": true}, fields("valid", "yes"))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/ingresso-group/goticketswitch.v2/reporting"
+)
+
+func runCommission(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "commission")
+	journalPath := flags.String("from-journal", "", "journal file to take purchased transactions from")
+	period := flags.String("period", string(reporting.Monthly), "period of each row: daily, monthly, yearly or all")
+	path := flags.String("o", "", "file to write the CSV to instead of standard output")
+	if err := parseFlags(flags, args, 0, -1); err != nil {
+		return err
+	}
+	switch reporting.Period(*period) {
+	case reporting.Daily, reporting.Monthly, reporting.Yearly, reporting.AllTime:
+	default:
+		return usageErrorf("unknown period %q", *period)
+	}
+
+	params := ticketswitch.UniversalParams{SourceInfo: true}
+	var statuses []*ticketswitch.StatusResult
+	if *journalPath != "" {
+		file, err := os.Open(*journalPath)
+		if err != nil {
+			return err
+		}
+		entries, err := ticketswitch.ReadJournal(file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		if statuses, err = reporting.FromJournal(ctx, env.client, entries, params); err != nil {
+			return err
+		}
+	}
+	for _, uuid := range flags.Args() {
+		status, err := env.client.GetStatus(ctx, &ticketswitch.TransactionParams{UniversalParams: params, TransactionUUID: uuid})
+		if err != nil {
+			return err
+		}
+		statuses = append(statuses, status)
+	}
+	if len(statuses) == 0 && *journalPath == "" {
+		return usageErrorf("no transactions given")
+	}
+
+	report := reporting.New(reporting.Period(*period))
+	for _, status := range statuses {
+		report.Add(status)
+	}
+	if *path == "" {
+		return report.WriteCSV(env.out.w)
+	}
+	file, err := os.Create(*path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := report.WriteCSV(file); err != nil {
+		return err
+	}
+	return file.Close()
+}
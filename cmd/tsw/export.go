@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+)
+
+func runExport(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "export")
+	exporter := ticketswitch.NewExporter(env.client)
+	flags.StringVar(&exporter.Params.CountryCode, "country", "", "ISO 3166-1 country code")
+	flags.StringVar(&exporter.Params.CityCode, "city", "", "city code")
+	flags.BoolVar(&exporter.Params.IncludeDead, "include-dead", false, "include dead events")
+	flags.IntVar(&exporter.Concurrency, "concurrency", ticketswitch.DefaultExportConcurrency, "events whose performances are fetched at once")
+	rowFormat := flags.String("rows", "csv", "row format, csv or jsonl")
+	path := flags.String("o", "", "file to write to instead of standard output")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	w := env.out.w
+	var file *os.File
+	if *path != "" {
+		var err error
+		if file, err = os.Create(*path); err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	var rows ticketswitch.RowWriter
+	switch *rowFormat {
+	case "csv":
+		rows = ticketswitch.NewCSVRowWriter(w)
+	case "jsonl":
+		rows = ticketswitch.NewJSONLRowWriter(w)
+	default:
+		return usageErrorf("unknown row format %q", *rowFormat)
+	}
+
+	exporter.OnError = func(err *ticketswitch.ExportError) {
+		fmt.Fprintf(env.stderr, "tsw export: %s\n", err)
+	}
+	summary, err := exporter.Export(ctx, rows)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.stderr, "tsw export: %d events, %d performances, %d errors\n",
+		summary.Events, summary.Performances, len(summary.Errors))
+	if file != nil {
+		return file.Close()
+	}
+	return nil
+}
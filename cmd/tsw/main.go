@@ -0,0 +1,247 @@
+// Command tsw is a command line client for the ticketswitch f13 API.
+//
+// It exposes the calls made by ticketswitch.Client as subcommands so that
+// transactions can be inspected and fixed without writing Go:
+//
+//	tsw [global flags] <command> [command flags] [arguments]
+//
+// Credentials are read from the global flags, falling back to the TSW_USER,
+// TSW_PASSWORD, TSW_SUB_USER, TSW_CRYPTO_BLOCK, TSW_LANGUAGE and TSW_BASE_URL
+// environment variables and then to a profile in the config file named by
+// -config or TSW_CONFIG (see ticketswitch.LoadConfig). Results are printed as
+// aligned tables by default or as JSON with -format=json.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+)
+
+// command describes a tsw subcommand.
+type command struct {
+	name  string
+	args  string
+	usage string
+	run   func(ctx context.Context, env *environment, args []string) error
+	// the command runs until interrupted, so -timeout only applies when it
+	// is given explicitly.
+	untimed bool
+}
+
+// environment is the state shared by all the subcommands.
+type environment struct {
+	client *ticketswitch.Client
+	out    *output
+	stderr io.Writer
+}
+
+var commands = map[string]*command{}
+
+func register(cmd *command) {
+	commands[cmd.name] = cmd
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run parses the global flags, builds a client and runs the requested
+// subcommand, returning the exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("tsw", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { usage(flags, stderr) }
+
+	var overrides ticketswitch.Config
+	configPath := flags.String("config", "", "config file with named profiles (default $TSW_CONFIG)")
+	profile := flags.String("profile", "", "profile to use from the config file (default $TSW_PROFILE)")
+	flags.StringVar(&overrides.BaseURL, "base-url", "", "API base URL (default $TSW_BASE_URL or "+ticketswitch.DefaultBaseURL+")")
+	flags.StringVar(&overrides.User, "user", "", "API user (default $TSW_USER)")
+	flags.StringVar(&overrides.Password, "password", "", "API password (default $TSW_PASSWORD)")
+	flags.StringVar(&overrides.SubUser, "sub-user", "", "API sub user (default $TSW_SUB_USER)")
+	flags.StringVar(&overrides.CryptoBlock, "crypto-block", "", "crypto block to use instead of a password (default $TSW_CRYPTO_BLOCK)")
+	flags.StringVar(&overrides.Language, "language", "", "Accept-Language for responses (default $TSW_LANGUAGE)")
+	flags.StringVar(&overrides.DesiredCurrency, "desired-currency", "", "currency to also give prices in (default $TSW_DESIRED_CURRENCY)")
+	flags.BoolVar(&overrides.DebugMode, "debug", false, "print requests and responses")
+	format := flags.String("format", "table", "output format, table or json")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout for each command")
+	trackingID := flags.String("tracking-id", "", "session tracking id sent with every request")
+	journalPath := flags.String("journal", os.Getenv("TSW_JOURNAL"), "file to journal reserve, purchase, release and cancel calls to")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		usage(flags, stderr)
+		return 2
+	}
+
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "tsw: unknown command %q\n", flags.Arg(0))
+		usage(flags, stderr)
+		return 2
+	}
+
+	out, err := newOutput(*format, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "tsw: %s\n", err)
+		return 2
+	}
+
+	config, err := loadConfig(flags, *configPath, *profile, &overrides)
+	if err != nil {
+		fmt.Fprintf(stderr, "tsw: %s\n", err)
+		return 1
+	}
+
+	env := &environment{
+		client: ticketswitch.NewClient(config),
+		out:    out,
+		stderr: stderr,
+	}
+	if *journalPath != "" {
+		journal, err := ticketswitch.OpenFileJournal(*journalPath, 0)
+		if err != nil {
+			fmt.Fprintf(stderr, "tsw: %s\n", err)
+			return 1
+		}
+		defer journal.Close()
+		journal.OnError = func(err error) {
+			fmt.Fprintf(stderr, "tsw: %s\n", err)
+		}
+		env.client.Journal = journal
+	}
+
+	timeoutSet := false
+	flags.Visit(func(f *flag.Flag) {
+		timeoutSet = timeoutSet || f.Name == "timeout"
+	})
+	if *timeout > 0 && (!cmd.untimed || timeoutSet) {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	if *trackingID != "" {
+		ctx = ticketswitch.SetSessionTrackingID(ctx, *trackingID)
+	}
+
+	if err := cmd.run(ctx, env, flags.Args()[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		var usageErr usageError
+		if errors.As(err, &usageErr) {
+			fmt.Fprintf(stderr, "tsw %s: %s\n", cmd.name, usageErr.message)
+			fmt.Fprintf(stderr, "usage: tsw %s %s\n", cmd.name, cmd.args)
+			return 2
+		}
+		fmt.Fprintf(stderr, "tsw %s: %s\n", cmd.name, err)
+		return 1
+	}
+	return 0
+}
+
+func usage(flags *flag.FlagSet, w io.Writer) {
+	fmt.Fprintf(w, "usage: tsw [global flags] <command> [command flags] [arguments]\n\n")
+	fmt.Fprintf(w, "commands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-14s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintf(w, "\nglobal flags:\n")
+	flags.PrintDefaults()
+}
+
+// loadConfig reads the config file and environment and applies the global
+// flags that were set on the command line over the top.
+func loadConfig(flags *flag.FlagSet, path, profile string, overrides *ticketswitch.Config) (*ticketswitch.Config, error) {
+	config, err := ticketswitch.ReadConfig(path, profile)
+	if err != nil {
+		return nil, err
+	}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "base-url":
+			config.BaseURL = overrides.BaseURL
+		case "user":
+			config.User = overrides.User
+		case "password":
+			config.Password = overrides.Password
+		case "sub-user":
+			config.SubUser = overrides.SubUser
+		case "crypto-block":
+			config.CryptoBlock = overrides.CryptoBlock
+		case "language":
+			config.Language = overrides.Language
+		case "desired-currency":
+			config.DesiredCurrency = overrides.DesiredCurrency
+		case "debug":
+			config.DebugMode = overrides.DebugMode
+		}
+	})
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// usageError is returned by a subcommand when it was called incorrectly.
+type usageError struct {
+	message string
+}
+
+func (err usageError) Error() string {
+	return err.message
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// newFlagSet returns a flag set for a subcommand that reports errors as
+// usage errors rather than exiting.
+func newFlagSet(env *environment, cmd string) *flag.FlagSet {
+	flags := flag.NewFlagSet("tsw "+cmd, flag.ContinueOnError)
+	flags.SetOutput(env.stderr)
+	return flags
+}
+
+// parseFlags parses the subcommand flags and checks the number of remaining
+// positional arguments is between min and max. A max below zero means there
+// is no limit.
+func parseFlags(flags *flag.FlagSet, args []string, min, max int) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{message: err.Error()}
+	}
+	if flags.NArg() < min {
+		return usageErrorf("expected at least %d argument(s)", min)
+	}
+	if max >= 0 && flags.NArg() > max {
+		return usageErrorf("expected at most %d argument(s)", max)
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer returns a server that responds to each endpoint with a file
+// from the repository's testdata.
+func newTestServer(t *testing.T, files map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint := strings.TrimPrefix(r.URL.Path, "/f13/")
+		file, ok := files[endpoint]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error_code": 8, "error_desc": "unexpected endpoint"}`))
+			return
+		}
+		data, err := os.ReadFile("../../testdata/" + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}))
+}
+
+func runTSW(server *httptest.Server, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	args = append([]string{"-base-url", server.URL, "-user", "bill", "-password", "hahaha"}, args...)
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_availability_table(t *testing.T) {
+	server := newTestServer(t, map[string]string{"availability.v1": "availability.json"})
+	defer server.Close()
+
+	code, stdout, stderr := runTSW(server, "availability", "-seats", "2", "7AA-5")
+	assert.Equal(t, 0, code, stderr)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if assert.Len(t, lines, 5) {
+		assert.Regexp(t, `^TICKET TYPE\s+PRICE BAND\s+DESCRIPTION\s+AVAILABLE`, lines[0])
+		assert.Regexp(t, `^\S+\s+A/pool\s+TEST PB1\s+6\s+50\s+5`, lines[1])
+	}
+}
+
+func TestRun_event_classes_sorted(t *testing.T) {
+	server := newTestServer(t, map[string]string{"events_by_id.v1": "events_by_id.json"})
+	defer server.Close()
+
+	// map iteration order varies, so run a few times.
+	for i := 0; i < 10; i++ {
+		code, stdout, stderr := runTSW(server, "event", "6IF")
+		assert.Equal(t, 0, code, stderr)
+		assert.Regexp(t, `(?m)^classes\s+Ballet,Christmas,Classical,Dance,Family,Theatre$`, stdout)
+	}
+}
+
+func TestRun_send_methods_country(t *testing.T) {
+	server := newTestServer(t, map[string]string{"send_methods.v1": "send_methods.json"})
+	defer server.Close()
+
+	code, stdout, stderr := runTSW(server, "send-methods", "-country", "us", "6IF-C30")
+	assert.Equal(t, 0, code, stderr)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Regexp(t, `^COBO\s+Collect from the venue`, lines[1])
+	}
+}
+
+func TestRun_calendar(t *testing.T) {
+	server := newTestServer(t, map[string]string{"status.v1": "status.json"})
+	defer server.Close()
+
+	code, stdout, stderr := runTSW(server, "calendar", "4df498e9-2daa-4393-a6bb-cc3dfefa7cc1")
+	assert.Equal(t, 0, code, stderr)
+	assert.True(t, strings.HasPrefix(stdout, "BEGIN:VCALENDAR\r\n"))
+	assert.Contains(t, stdout, "DTSTART:20190101T153000Z\r\n")
+}
+
+func TestRun_commission(t *testing.T) {
+	server := newTestServer(t, map[string]string{"status.v1": "status.json"})
+	defer server.Close()
+
+	code, stdout, stderr := runTSW(server, "commission", "-period", "yearly", "4df498e9-2daa-4393-a6bb-cc3dfefa7cc1")
+	assert.Equal(t, 0, code, stderr)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.True(t, strings.HasPrefix(lines[1], "2018,gbp,ext_test1,"))
+	}
+
+	code, _, _ = runTSW(server, "commission", "-period", "weekly", "4df498e9")
+	assert.Equal(t, 2, code)
+}
+
+func TestRun_json(t *testing.T) {
+	server := newTestServer(t, map[string]string{"status.v1": "status.json"})
+	defer server.Close()
+
+	code, stdout, stderr := runTSW(server, "-format", "json", "status", "4df498e9-2daa-4393-a6bb-cc3dfefa7cc1")
+	assert.Equal(t, 0, code, stderr)
+	var result map[string]interface{}
+	if assert.Nil(t, json.Unmarshal([]byte(stdout), &result)) {
+		assert.Equal(t, "purchased", result["transaction_status"])
+		assert.Contains(t, result, "trolley_contents")
+	}
+}
+
+func TestRun_cancel_needs_confirmation(t *testing.T) {
+	server := newTestServer(t, map[string]string{"cancel.v1": "must_also_cancel.json"})
+	defer server.Close()
+
+	code, stdout, stderr := runTSW(server, "cancel", "4df498e9-2daa-4393-a6bb-cc3dfefa7cc1", "1")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout, "possible")
+	assert.Contains(t, stderr, "-confirm")
+}
+
+func TestRun_usage_errors(t *testing.T) {
+	server := newTestServer(t, nil)
+	defer server.Close()
+
+	code, _, stderr := runTSW(server, "nope")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown command "nope"`)
+
+	code, _, stderr = runTSW(server, "reserve", "-perf", "6IF-C5O")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "-ticket-type and -price-band are required")
+
+	code, _, stderr = runTSW(server, "discounts", "6IF-C5O")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "usage: tsw discounts")
+
+	code, _, _ = runTSW(server, "-format", "xml", "sources")
+	assert.Equal(t, 2, code)
+
+	code, _, _ = runTSW(server)
+	assert.Equal(t, 2, code)
+}
+
+func TestRun_api_error(t *testing.T) {
+	server := newTestServer(t, nil)
+	defer server.Close()
+
+	code, _, stderr := runTSW(server, "events", "-cost-range", "nutcracker")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "unexpected endpoint")
+}
+
+func TestRun_config_profile(t *testing.T) {
+	server := newTestServer(t, map[string]string{"sources.v1": "sources.json"})
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "tsw.yaml")
+	config := "profiles:\n  staging:\n    base_url: " + server.URL + "\n    user: bill\n    password: hahaha\n"
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"-config", path, "-profile", "staging", "sources"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+
+	stderr.Reset()
+	code = run(context.Background(), []string{"-config", path, "-profile", "staging", "-user", "", "sources"}, &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "does not specify a user")
+}
+
+func TestRun_watch(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/availability.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Write(data)
+			return
+		}
+		w.Write(bytes.Replace(data, []byte(`"sale_seatprice": 50`), []byte(`"sale_seatprice": 45`), 1))
+	}))
+	defer server.Close()
+
+	code, stdout, stderr := runTSW(server, "watch", "-interval", "1ms", "-request-delay", "0", "-count", "1", "7AA-5")
+	assert.Equal(t, 0, code, stderr)
+	assert.Regexp(t, `7AA-5  price_changed  STALLS/A/pool  55.00 -> 50.00\n$`, stdout)
+}
+
+func TestRun_export(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": {"event": [{"event_id": "6KU", "has_no_perfs": true}]}}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "catalogue.jsonl")
+	code, _, stderr := runTSW(server, "export", "-rows", "jsonl", "-o", path)
+	assert.Equal(t, 0, code, stderr)
+	assert.Contains(t, stderr, "1 events, 0 performances, 0 errors")
+	data, err := os.ReadFile(path)
+	if assert.Nil(t, err) {
+		assert.Contains(t, string(data), `"event_id":"6KU"`)
+	}
+
+	code, _, _ = runTSW(server, "export", "-rows", "xml")
+	assert.Equal(t, 2, code)
+}
+
+func TestRun_journal(t *testing.T) {
+	server := newTestServer(t, map[string]string{"purchase.v1": "purchase-credit-success.json"})
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	code, _, stderr := runTSW(server, "-journal", path, "purchase", "-first-name", "Fred", "-last-name", "Flintstone",
+		"-country", "uk", "-address-1", "1 Cobblestone Way", "4df498e9-2daa-4393-a6bb-cc3dfefa7cc1")
+	assert.Equal(t, 0, code, stderr)
+	f, err := os.Open(path)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer f.Close()
+	entries, err := ticketswitch.ReadJournal(f)
+	if assert.Nil(t, err) && assert.Len(t, entries, 2) {
+		assert.Equal(t, ticketswitch.OutcomeSucceeded, entries[1].Outcome)
+		assert.Equal(t, "purchased", entries[1].Status)
+	}
+}
+
+func TestRun_reconcile(t *testing.T) {
+	server := newTestServer(t, map[string]string{"status.v1": "status.json"})
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "records.csv")
+	records := "transaction_uuid,state,total,seats\n" +
+		"4df498e9-2daa-4393-a6bb-cc3dfefa7cc1,purchased,110,2\n" +
+		"e18c20fc-042e-11e7-975c-002590326962,purchased,76.5,3\n"
+	assert.Nil(t, os.WriteFile(path, []byte(records), 0600))
+
+	code, stdout, stderr := runTSW(server, "reconcile", "-records", path)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "tsw reconcile: 1 of 2 transactions have discrepancies\n", stderr)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if assert.Len(t, lines, 6) {
+		assert.Regexp(t, `^TRANSACTION\s+STATE\s+RECORDED`, lines[0])
+		assert.Regexp(t, `^e18c20fc-042e-11e7-975c-002590326962\s+purchased\s+purchased\s+110 gbp\s+2\s+total_mismatch: expected 76.5, got 110; seats_mismatch`, lines[1])
+		assert.Regexp(t, `^seats_mismatch\s+1$`, lines[4])
+	}
+
+	code, _, stderr = runTSW(server, "reconcile")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "no transactions given")
+}
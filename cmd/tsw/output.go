@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// table is a set of rows printed as aligned columns.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func newTable(header ...string) *table {
+	return &table{header: header}
+}
+
+func (t *table) add(values ...interface{}) {
+	row := make([]string, len(values))
+	for i, value := range values {
+		row[i] = cell(value)
+	}
+	t.rows = append(t.rows, row)
+}
+
+// cell formats a value for a table, leaving empty strings, false and nil
+// blank so that tables stay readable.
+func cell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		if v {
+			return "yes"
+		}
+		return ""
+	case []string:
+		return strings.Join(v, ",")
+	case []int:
+		parts := make([]string, len(v))
+		for i, n := range v {
+			parts[i] = fmt.Sprint(n)
+		}
+		return strings.Join(parts, ",")
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// output prints command results in the selected format.
+type output struct {
+	json bool
+	w    io.Writer
+}
+
+func newOutput(format string, w io.Writer) (*output, error) {
+	switch format {
+	case "table", "":
+		return &output{w: w}, nil
+	case "json":
+		return &output{json: true, w: w}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// print writes the raw result as JSON or the tables built from it.
+func (out *output) print(result interface{}, tables ...*table) error {
+	if out.json || len(tables) == 0 {
+		encoder := json.NewEncoder(out.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	for i, t := range tables {
+		if i > 0 {
+			fmt.Fprintln(out.w)
+		}
+		if err := out.writeTable(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// line writes a single streamed result, as a compact JSON line or as text.
+func (out *output) line(result interface{}, text string) error {
+	if out.json {
+		return json.NewEncoder(out.w).Encode(result)
+	}
+	_, err := fmt.Fprintln(out.w, text)
+	return err
+}
+
+func (out *output) writeTable(t *table) error {
+	w := tabwriter.NewWriter(out.w, 0, 0, 2, ' ', 0)
+	if len(t.header) > 0 {
+		fmt.Fprintln(w, strings.Join(t.header, "\t"))
+	}
+	for _, row := range t.rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// fields builds a two column table of names and values.
+func fields(pairs ...interface{}) *table {
+	t := newTable()
+	for i := 0; i+1 < len(pairs); i += 2 {
+		t.add(pairs[i], pairs[i+1])
+	}
+	return t
+}
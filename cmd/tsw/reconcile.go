@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/ingresso-group/goticketswitch.v2/reconcile"
+)
+
+func runReconcile(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "reconcile")
+	reconciler := reconcile.New(env.client)
+	journalPath := flags.String("from-journal", "", "journal file to take transactions from")
+	recordsPath := flags.String("records", "", "CSV file of recorded transactions, see reconcile.RecordColumns")
+	flags.IntVar(&reconciler.Concurrency, "concurrency", reconcile.DefaultConcurrency, "transactions looked up at once")
+	flags.DurationVar(&reconciler.ExpiringWithin, "expiring", reconcile.DefaultExpiringWithin, "report reservations with less than this left as expiring")
+	all := flags.Bool("all", false, "show every transaction rather than only discrepancies")
+	if err := parseFlags(flags, args, 0, -1); err != nil {
+		return err
+	}
+
+	records := make([]reconcile.Record, 0)
+	if *journalPath != "" {
+		file, err := os.Open(*journalPath)
+		if err != nil {
+			return err
+		}
+		entries, err := ticketswitch.ReadJournal(file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		records = append(records, reconcile.FromJournal(entries)...)
+	}
+	if *recordsPath != "" {
+		file, err := os.Open(*recordsPath)
+		if err != nil {
+			return err
+		}
+		read, err := reconcile.ReadRecords(file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		records = append(records, read...)
+	}
+	for _, uuid := range flags.Args() {
+		records = append(records, reconcile.Record{TransactionUUID: uuid})
+	}
+	if len(records) == 0 {
+		return usageErrorf("no transactions given")
+	}
+
+	report, err := reconciler.Reconcile(ctx, records)
+	if err != nil {
+		return err
+	}
+	results := report.Results
+	if !*all {
+		results = report.Discrepant()
+	}
+
+	t := newTable("TRANSACTION", "STATE", "RECORDED", "TOTAL", "SEATS", "MINUTES LEFT", "DISCREPANCIES")
+	for _, result := range results {
+		discrepancies := make([]string, len(result.Discrepancies))
+		for i, discrepancy := range result.Discrepancies {
+			discrepancies[i] = discrepancy.String()
+		}
+		minutesLeft := ""
+		if result.State == reconcile.StateReserved || result.State == reconcile.StateExpiring {
+			minutesLeft = fmt.Sprint(result.MinutesLeft)
+		}
+		total := ""
+		if result.State != reconcile.StateError {
+			total = strings.TrimSpace(result.Total.String() + " " + result.CurrencyCode)
+		}
+		t.add(result.Record.TransactionUUID, result.State, result.Record.State, total, result.Seats,
+			minutesLeft, strings.Join(discrepancies, "; "))
+	}
+	summary := newTable("KIND", "COUNT")
+	for _, kind := range report.Kinds() {
+		summary.add(kind, report.Counts()[kind])
+	}
+	if err := env.out.print(report, t, summary); err != nil {
+		return err
+	}
+
+	if discrepant := len(report.Discrepant()); discrepant > 0 {
+		return fmt.Errorf("%d of %d transactions have discrepancies", discrepant, len(report.Results))
+	}
+	return nil
+}
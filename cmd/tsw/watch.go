@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/shopspring/decimal"
+)
+
+func runWatch(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "watch")
+	params := &ticketswitch.GetAvailabilityParams{}
+	flags.IntVar(&params.NumberOfSeats, "seats", 0, "number of seats wanted")
+	interval := flags.Duration("interval", ticketswitch.DefaultWatchInterval, "wait between polls")
+	jitter := flags.Float64("jitter", ticketswitch.DefaultWatchJitter, "fraction of the interval to randomly vary each wait by")
+	requestDelay := flags.Duration("request-delay", ticketswitch.DefaultWatchRequestDelay, "minimum gap between requests")
+	threshold := flags.Int("threshold", 1, "number available that triggers quantity events")
+	cached := flags.Bool("cached", false, "watch the cached availability from the performance list")
+	count := flags.Int("count", 0, "stop after this many events")
+	if err := parseFlags(flags, args, 1, -1); err != nil {
+		return err
+	}
+
+	watcher := ticketswitch.NewWatcher(env.client, flags.Args()...)
+	watcher.Interval = *interval
+	watcher.Jitter = *jitter
+	watcher.RequestDelay = *requestDelay
+	watcher.Threshold = *threshold
+	watcher.Cached = *cached
+	watcher.Params = params
+	watcher.OnError = func(err error) {
+		fmt.Fprintf(env.stderr, "tsw watch: %s\n", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	seen := 0
+	var printErr error
+	err := watcher.Run(ctx, func(event ticketswitch.WatchEvent) {
+		if printErr != nil {
+			return
+		}
+		if printErr = env.out.line(event, describeWatchEvent(event)); printErr != nil {
+			cancel()
+			return
+		}
+		seen++
+		if *count > 0 && seen >= *count {
+			cancel()
+		}
+	})
+	if printErr != nil {
+		return printErr
+	}
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func price(band *ticketswitch.PriceBand) string {
+	return band.Seatprice.Add(band.Surcharge).StringFixed(2)
+}
+
+// describeWatchEvent formats an event as a single line of text.
+func describeWatchEvent(event ticketswitch.WatchEvent) string {
+	var detail string
+	prev, cur := event.Previous, event.Current
+	switch event.Type {
+	case ticketswitch.PriceBandAdded:
+		detail = fmt.Sprintf("%d available at %s", cur.NumberAvailable, price(cur))
+	case ticketswitch.PriceBandRemoved:
+		detail = fmt.Sprintf("was %d available at %s", prev.NumberAvailable, price(prev))
+	case ticketswitch.PriceChanged:
+		detail = fmt.Sprintf("%s -> %s", price(prev), price(cur))
+	case ticketswitch.QuantityAboveThreshold, ticketswitch.QuantityBelowThreshold:
+		detail = fmt.Sprintf("%d -> %d available", prev.NumberAvailable, cur.NumberAvailable)
+	case ticketswitch.OfferStarted:
+		full := cur.NonOfferSeatprice.Add(cur.NonOfferSurcharge)
+		if full.GreaterThan(decimal.Zero) {
+			detail = fmt.Sprintf("%s, was %s", price(cur), full.StringFixed(2))
+		} else {
+			detail = price(cur)
+		}
+	case ticketswitch.OfferEnded:
+		detail = price(cur)
+	}
+	return fmt.Sprintf("%s  %s  %s  %s/%s  %s", event.Time.Format(time.RFC3339), event.PerformanceID,
+		event.Type, event.TicketTypeCode, event.PriceBandCode, detail)
+}
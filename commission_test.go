@@ -0,0 +1,28 @@
+package ticketswitch
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommission_vat(t *testing.T) {
+	data, err := os.ReadFile("testdata/status.json")
+	if err != nil {
+		t.Fatalf("Cannot find testdata/status.json")
+	}
+	var status StatusResult
+	if !assert.Nil(t, json.Unmarshal(data, &status)) {
+		return
+	}
+
+	order := status.Trolley.Bundles[0].Orders[0]
+	assert.True(t, order.UserCommission.IncVat.Equal(decimal.NewFromInt(10)))
+	assert.True(t, order.UserCommission.ExVat.Equal(decimal.RequireFromString("8.33")))
+	assert.Equal(t, "gbp", order.UserCommission.CurrencyCode)
+	assert.True(t, order.GrossCommission.IncVat.Equal(decimal.NewFromInt(15)))
+	assert.True(t, order.GrossCommission.ExVat.Equal(decimal.RequireFromString("12.5")))
+}
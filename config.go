@@ -1,5 +1,23 @@
 package ticketswitch
 
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultBaseURL is the base URL of the production API.
+const DefaultBaseURL = "https://api.ticketswitch.com"
+
+// DefaultProfile is the name of the profile used by LoadConfig when none is
+// specified.
+const DefaultProfile = "default"
+
 // Config defines the credentials used to access the API
 type Config struct {
 	BaseURL     string
@@ -8,14 +26,200 @@ type Config struct {
 	SubUser     string
 	Language    string
 	CryptoBlock string
-	DebugMode   bool
+	// the ISO 4217 code of the currency prices should also be given in, sent
+	// with every request that doesn't ask for its own.
+	DesiredCurrency string
+	DebugMode       bool
 }
 
 // NewConfig returns a pointer to a newly created Config.
 func NewConfig(user, password string) *Config {
 	return &Config{
-		BaseURL:  "https://api.ticketswitch.com",
+		BaseURL:  DefaultBaseURL,
 		User:     user,
 		Password: password,
 	}
 }
+
+// Validate checks that the config has everything needed to make a request.
+// It reports the same problems that would otherwise only be found when a
+// request is made.
+func (config *Config) Validate() error {
+	if config.BaseURL == "" {
+		return errors.New("ticketswitch: config does not specify a base url")
+	}
+	u, err := url.Parse(config.BaseURL)
+	if err != nil {
+		return fmt.Errorf("ticketswitch: config has an invalid base url: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("ticketswitch: config base url %q is not an absolute http(s) url", config.BaseURL)
+	}
+
+	if config.CryptoBlock != "" {
+		if config.User == "" {
+			return errors.New("ticketswitch: config specifies cryptoblock but doesn't supply a user")
+		}
+		return nil
+	}
+
+	if config.User == "" {
+		return errors.New("ticketswitch: config does not specify a user")
+	}
+	if config.Password == "" {
+		return errors.New("ticketswitch: config does not specify a password")
+	}
+	return nil
+}
+
+// configProfile is a named set of settings in a config file.
+type configProfile struct {
+	BaseURL         string `yaml:"base_url"`
+	User            string `yaml:"user"`
+	Password        string `yaml:"password"`
+	PasswordFile    string `yaml:"password_file"`
+	SubUser         string `yaml:"sub_user"`
+	Language        string `yaml:"language"`
+	CryptoBlock     string `yaml:"crypto_block"`
+	DesiredCurrency string `yaml:"desired_currency"`
+	DebugMode       *bool  `yaml:"debug"`
+}
+
+// configFile is the layout of a config file.
+type configFile struct {
+	DefaultProfile string                   `yaml:"default_profile"`
+	Profiles       map[string]configProfile `yaml:"profiles"`
+}
+
+// apply overwrites the config with the settings that are set in the profile.
+func (profile *configProfile) apply(config *Config, passwordFile *string) {
+	set := func(dst *string, value string) {
+		if value != "" {
+			*dst = value
+		}
+	}
+	set(&config.BaseURL, profile.BaseURL)
+	set(&config.User, profile.User)
+	set(&config.SubUser, profile.SubUser)
+	set(&config.Language, profile.Language)
+	set(&config.CryptoBlock, profile.CryptoBlock)
+	set(&config.DesiredCurrency, profile.DesiredCurrency)
+	if profile.Password != "" {
+		config.Password = profile.Password
+		*passwordFile = ""
+	}
+	set(passwordFile, profile.PasswordFile)
+	if profile.DebugMode != nil {
+		config.DebugMode = *profile.DebugMode
+	}
+}
+
+// ReadConfig builds a Config from a config file and the environment without
+// validating it. It is useful for callers that apply their own overrides
+// before calling Config.Validate; most callers want LoadConfig.
+func ReadConfig(path, profile string) (*Config, error) {
+	config := &Config{BaseURL: DefaultBaseURL}
+	var passwordFile string
+
+	if path == "" {
+		path = os.Getenv("TSW_CONFIG")
+	}
+	if profile == "" {
+		profile = os.Getenv("TSW_PROFILE")
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("ticketswitch: unable to read config file: %w", err)
+		}
+		var file configFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("ticketswitch: unable to parse config file %s: %w", path, err)
+		}
+
+		name := profile
+		if name == "" {
+			name = file.DefaultProfile
+		}
+		if name == "" {
+			name = DefaultProfile
+		}
+		settings, ok := file.Profiles[name]
+		if !ok && (profile != "" || file.DefaultProfile != "") {
+			return nil, fmt.Errorf("ticketswitch: config file %s has no profile %q", path, name)
+		}
+		settings.apply(config, &passwordFile)
+	} else if profile != "" {
+		return nil, fmt.Errorf("ticketswitch: profile %q requested without a config file", profile)
+	}
+
+	env := configProfile{
+		BaseURL:         os.Getenv("TSW_BASE_URL"),
+		User:            os.Getenv("TSW_USER"),
+		Password:        os.Getenv("TSW_PASSWORD"),
+		PasswordFile:    os.Getenv("TSW_PASSWORD_FILE"),
+		SubUser:         os.Getenv("TSW_SUB_USER"),
+		Language:        os.Getenv("TSW_LANGUAGE"),
+		CryptoBlock:     os.Getenv("TSW_CRYPTO_BLOCK"),
+		DesiredCurrency: os.Getenv("TSW_DESIRED_CURRENCY"),
+	}
+	if value := os.Getenv("TSW_DEBUG"); value != "" {
+		debug, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("ticketswitch: invalid TSW_DEBUG %q: %w", value, err)
+		}
+		env.DebugMode = &debug
+	}
+	env.apply(config, &passwordFile)
+
+	if passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("ticketswitch: unable to read password file: %w", err)
+		}
+		config.Password = strings.TrimRight(string(data), "\r\n")
+	}
+
+	return config, nil
+}
+
+// LoadConfig builds a Config from a config file and the environment and
+// validates it.
+//
+// The config file is YAML (or JSON) containing named profiles:
+//
+//	default_profile: staging
+//	profiles:
+//	  production:
+//	    user: acme
+//	    password_file: /run/secrets/tsw_password
+//	  staging:
+//	    base_url: https://staging.example.com
+//	    user: acme-test
+//	    password: secret
+//	    sub_user: web
+//	    language: en-GB
+//	    desired_currency: usd
+//	    debug: true
+//
+// When path is empty the TSW_CONFIG environment variable is used, and when it
+// is also empty no file is read. The profile is chosen by the profile
+// argument, then TSW_PROFILE, then the file's default_profile and finally the
+// profile called "default".
+//
+// Settings from the TSW_BASE_URL, TSW_USER, TSW_PASSWORD, TSW_PASSWORD_FILE,
+// TSW_SUB_USER, TSW_LANGUAGE, TSW_CRYPTO_BLOCK, TSW_DESIRED_CURRENCY and
+// TSW_DEBUG environment variables take precedence over the file. A password
+// file has any trailing newline removed. The base URL defaults to
+// DefaultBaseURL.
+func LoadConfig(path, profile string) (*Config, error) {
+	config, err := ReadConfig(path, profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
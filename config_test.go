@@ -0,0 +1,178 @@
+package ticketswitch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clearConfigEnv blanks the config environment variables for the test.
+func clearConfigEnv(t *testing.T) {
+	for _, key := range []string{
+		"TSW_CONFIG", "TSW_PROFILE", "TSW_BASE_URL", "TSW_USER", "TSW_PASSWORD",
+		"TSW_PASSWORD_FILE", "TSW_SUB_USER", "TSW_LANGUAGE", "TSW_CRYPTO_BLOCK",
+		"TSW_DESIRED_CURRENCY", "TSW_DEBUG",
+	} {
+		t.Setenv(key, "")
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testConfigFile = `
+default_profile: staging
+profiles:
+  production:
+    user: acme
+    password_file: %s
+  staging:
+    base_url: https://staging.example.com
+    user: acme-test
+    password: secret
+    sub_user: web
+    language: en-GB
+    desired_currency: eur
+    debug: true
+`
+
+func TestConfig_Validate(t *testing.T) {
+	assert.Nil(t, NewConfig("bill", "hahaha").Validate())
+	assert.Nil(t, (&Config{BaseURL: DefaultBaseURL, User: "bill", CryptoBlock: "abc"}).Validate())
+
+	tests := map[string]Config{
+		"base url":         {User: "bill", Password: "hahaha"},
+		"not absolute":     {BaseURL: "api.ticketswitch.com", User: "bill", Password: "hahaha"},
+		"bad scheme":       {BaseURL: "ftp://api.ticketswitch.com", User: "bill", Password: "hahaha"},
+		"unparseable":      {BaseURL: "http://[::1", User: "bill", Password: "hahaha"},
+		"cryptoblock":      {BaseURL: DefaultBaseURL, CryptoBlock: "abc"},
+		"missing user":     {BaseURL: DefaultBaseURL, Password: "hahaha"},
+		"missing password": {BaseURL: DefaultBaseURL, User: "bill"},
+	}
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.NotNil(t, config.Validate())
+		})
+	}
+}
+
+func TestLoadConfig_env(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("TSW_USER", "bill")
+	t.Setenv("TSW_PASSWORD", "hahaha")
+	t.Setenv("TSW_SUB_USER", "sub")
+	t.Setenv("TSW_DESIRED_CURRENCY", "usd")
+	t.Setenv("TSW_DEBUG", "true")
+
+	config, err := LoadConfig("", "")
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Config{
+			BaseURL:         DefaultBaseURL,
+			User:            "bill",
+			Password:        "hahaha",
+			SubUser:         "sub",
+			DesiredCurrency: "usd",
+			DebugMode:       true,
+		}, config)
+	}
+
+	t.Setenv("TSW_DEBUG", "maybe")
+	_, err = LoadConfig("", "")
+	assert.NotNil(t, err)
+}
+
+func TestLoadConfig_missing_credentials(t *testing.T) {
+	clearConfigEnv(t)
+	_, err := LoadConfig("", "")
+	assert.EqualError(t, err, "ticketswitch: config does not specify a user")
+
+	config, err := ReadConfig("", "")
+	if assert.Nil(t, err) {
+		assert.Equal(t, DefaultBaseURL, config.BaseURL)
+	}
+}
+
+func TestLoadConfig_profiles(t *testing.T) {
+	clearConfigEnv(t)
+	passwordFile := writeFile(t, "password", "s3cret\n")
+	path := writeFile(t, "tsw.yaml", fmt.Sprintf(testConfigFile, passwordFile))
+
+	config, err := LoadConfig(path, "")
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Config{
+			BaseURL:         "https://staging.example.com",
+			User:            "acme-test",
+			Password:        "secret",
+			SubUser:         "web",
+			Language:        "en-GB",
+			DesiredCurrency: "eur",
+			DebugMode:       true,
+		}, config)
+	}
+
+	config, err = LoadConfig(path, "production")
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Config{
+			BaseURL:  DefaultBaseURL,
+			User:     "acme",
+			Password: "s3cret",
+		}, config)
+	}
+
+	t.Setenv("TSW_PROFILE", "production")
+	t.Setenv("TSW_USER", "override")
+	config, err = LoadConfig(path, "")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "override", config.User)
+		assert.Equal(t, "s3cret", config.Password)
+	}
+
+	// a password in the environment beats a password file in the profile
+	t.Setenv("TSW_PASSWORD", "fromenv")
+	config, err = LoadConfig(path, "")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "fromenv", config.Password)
+	}
+
+	_, err = LoadConfig(path, "nope")
+	assert.EqualError(t, err, `ticketswitch: config file `+path+` has no profile "nope"`)
+}
+
+func TestLoadConfig_json(t *testing.T) {
+	clearConfigEnv(t)
+	path := writeFile(t, "tsw.json", `{"profiles": {"default": {"user": "bill", "password": "hahaha", "crypto_block": "abc"}}}`)
+	t.Setenv("TSW_CONFIG", path)
+
+	config, err := LoadConfig("", "")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "bill", config.User)
+		assert.Equal(t, "abc", config.CryptoBlock)
+	}
+}
+
+func TestLoadConfig_errors(t *testing.T) {
+	clearConfigEnv(t)
+
+	_, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"), "")
+	assert.NotNil(t, err)
+
+	path := writeFile(t, "bad.yaml", "profiles: [")
+	_, err = LoadConfig(path, "")
+	assert.NotNil(t, err)
+
+	_, err = LoadConfig("", "staging")
+	assert.EqualError(t, err, `ticketswitch: profile "staging" requested without a config file`)
+
+	t.Setenv("TSW_USER", "bill")
+	t.Setenv("TSW_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+	_, err = LoadConfig("", "")
+	assert.NotNil(t, err)
+}
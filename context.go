@@ -8,6 +8,7 @@ type key int
 
 const (
 	contextTrackingIdKey key = iota
+	contextSubUserKey
 )
 
 // SetSessionTrackingID saves a tracking id into a context
@@ -20,3 +21,15 @@ func GetSessionTrackingID(ctx context.Context) (string, bool) {
 	trackingId, ok := ctx.Value(contextTrackingIdKey).(string)
 	return trackingId, ok
 }
+
+// SetSubUser saves a sub user into a context. Requests made with the context
+// act as the sub user instead of the one in the client's Config.
+func SetSubUser(ctx context.Context, subUser string) context.Context {
+	return context.WithValue(ctx, contextSubUserKey, subUser)
+}
+
+// GetSubUser gets the sub user from the context
+func GetSubUser(ctx context.Context) (string, bool) {
+	subUser, ok := ctx.Value(contextSubUserKey).(string)
+	return subUser, ok
+}
@@ -19,9 +19,17 @@ type CostRange struct {
 	// the maximum surcharge per seat the customer might be expected to pay.
 	MaxSurcharge decimal.Decimal `json:"max_surcharge"`
 
+	// the prices above in the desired currency, when one was requested.
+	MinSeatPriceInDesired decimal.Decimal `json:"min_seatprice_in_desired"`
+	MaxSeatPriceInDesired decimal.Decimal `json:"max_seatprice_in_desired"`
+	MinSurchargeInDesired decimal.Decimal `json:"min_surcharge_in_desired"`
+	MaxSurchargeInDesired decimal.Decimal `json:"max_surcharge_in_desired"`
+
 	// currency the cost range and offer prices are in.
 	CurrencyCode string   `json:"currency_code"`
 	Currency     Currency `json:"currency"`
+	// the currency the customer is expecting, empty when none was requested.
+	DesiredCurrencyCode string `json:"desired_currency_code"`
 
 	// offer with the highest percentage saving.
 	BestValueOffer Offer `json:"best_value_offer"`
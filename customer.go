@@ -1,5 +1,11 @@
 package ticketswitch
 
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
 // Customer contains information about the customer that bought tickets
 type Customer struct {
 	AgentReference             string `json:"agent_ref"`
@@ -23,30 +29,40 @@ type Customer struct {
 	WorldCanUseCustomerData    bool   `json:"world_can_use_customer_data"`
 }
 
-// Params returns the customer data as a map
+// Params returns the customer data as a map. The name, first address line and
+// country code are always included, other fields are only included when they
+// are set.
 func (customer *Customer) Params() map[string]string {
 	values := map[string]string{
-		"agent_ref":                      customer.AgentReference,
 		"first_name":                     customer.FirstName,
 		"last_name":                      customer.LastName,
 		"country_code":                   customer.CountryCode,
-		"title":                          customer.Title,
-		"initials":                       customer.Initials,
-		"suffix":                         customer.Suffix,
-		"postcode":                       customer.Postcode,
-		"town":                           customer.Town,
-		"county":                         customer.County,
-		"email_address":                  customer.EmailAddress,
-		"phone":                          customer.Phone,
-		"work_phone":                     customer.WorkPhone,
-		"home_phone":                     customer.HomePhone,
 		"address_line_one":               customer.AddressLineOne,
-		"address_line_two":               customer.AddressLineTwo,
 		"supplier_can_use_customer_data": "0",
 		"user_can_use_customer_data":     "0",
 		"world_can_use_customer_data":    "0",
 	}
 
+	optional := map[string]string{
+		"agent_ref":        customer.AgentReference,
+		"title":            customer.Title,
+		"initials":         customer.Initials,
+		"suffix":           customer.Suffix,
+		"postcode":         customer.Postcode,
+		"town":             customer.Town,
+		"county":           customer.County,
+		"email_address":    customer.EmailAddress,
+		"phone":            customer.Phone,
+		"work_phone":       customer.WorkPhone,
+		"home_phone":       customer.HomePhone,
+		"address_line_two": customer.AddressLineTwo,
+	}
+	for k, v := range optional {
+		if v != "" {
+			values[k] = v
+		}
+	}
+
 	if customer.SupplierCanUseCustomerData {
 		values["supplier_can_use_customer_data"] = "1"
 	}
@@ -59,3 +75,144 @@ func (customer *Customer) Params() map[string]string {
 
 	return values
 }
+
+// FieldError describes a problem with a single field of a request. Field is
+// the name of the parameter the field is sent to the API as.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (err FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Field, err.Message)
+}
+
+// ValidationErrors is a list of problems found when validating a request,
+// such as customer information or a reservation.
+type ValidationErrors []FieldError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return "ticketswitch: invalid request: " + strings.Join(messages, "; ")
+}
+
+// Field returns the first error for the named field.
+func (errs ValidationErrors) Field(name string) (FieldError, bool) {
+	for _, err := range errs {
+		if err.Field == name {
+			return err, true
+		}
+	}
+	return FieldError{}, false
+}
+
+// addressFields maps the keys of ReservationResult.PrefilledAddress to the
+// customer fields they fill.
+func (customer *Customer) addressFields() map[string]*string {
+	return map[string]*string{
+		"address_line_one": &customer.AddressLineOne,
+		"address_line_two": &customer.AddressLineTwo,
+		"town":             &customer.Town,
+		"county":           &customer.County,
+		"postcode":         &customer.Postcode,
+		"country_code":     &customer.CountryCode,
+	}
+}
+
+// ApplyPrefilledAddress copies the reservation's prefilled address over the
+// customer's address when the reservation doesn't allow the address to be
+// edited. Nothing is changed when the address can be edited.
+func (customer *Customer) ApplyPrefilledAddress(reservation *ReservationResult) {
+	if reservation == nil || reservation.CanEditAddress {
+		return
+	}
+	fields := customer.addressFields()
+	for key, value := range reservation.PrefilledAddress {
+		if field, ok := fields[key]; ok {
+			*field = value
+		}
+	}
+}
+
+// sameCountry checks if two country codes are for the same country,
+// ignoring case. The API uses uk for the United Kingdom where ISO 3166-1
+// uses gb, so the two are treated as the same.
+func sameCountry(a, b string) bool {
+	if strings.EqualFold(a, "gb") {
+		a = "uk"
+	}
+	if strings.EqualFold(b, "gb") {
+		b = "uk"
+	}
+	return strings.EqualFold(a, b)
+}
+
+// Validate checks the customer against the requirements of a reservation,
+// returning ValidationErrors listing every problem found. The reservation may
+// be nil, in which case only the fields the API always requires are checked.
+func (customer *Customer) Validate(reservation *ReservationResult) error {
+	var errs ValidationErrors
+
+	required := []struct {
+		field string
+		value string
+	}{
+		{"first_name", customer.FirstName},
+		{"last_name", customer.LastName},
+		{"address_line_one", customer.AddressLineOne},
+		{"country_code", customer.CountryCode},
+	}
+	for _, r := range required {
+		if strings.TrimSpace(r.value) == "" {
+			errs = append(errs, FieldError{Field: r.field, Message: "is required"})
+		}
+	}
+
+	if customer.EmailAddress != "" {
+		if _, err := mail.ParseAddress(customer.EmailAddress); err != nil {
+			errs = append(errs, FieldError{Field: "email_address", Message: "is not a valid email address"})
+		}
+	}
+
+	if reservation != nil {
+		if reservation.NeedsEmailAddress && customer.EmailAddress == "" {
+			errs = append(errs, FieldError{Field: "email_address", Message: "is required"})
+		}
+		if reservation.NeedsAgentReference && customer.AgentReference == "" {
+			errs = append(errs, FieldError{Field: "agent_ref", Message: "is required"})
+		}
+		if customer.CountryCode != "" && len(reservation.AllowedCountries) > 0 {
+			allowed := false
+			for code := range reservation.AllowedCountries {
+				if sameCountry(code, customer.CountryCode) {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				errs = append(errs, FieldError{Field: "country_code", Message: "is not an allowed country"})
+			}
+		}
+		if !reservation.CanEditAddress {
+			fields := customer.addressFields()
+			for _, key := range []string{"address_line_one", "address_line_two", "town", "county", "postcode", "country_code"} {
+				prefilled, ok := reservation.PrefilledAddress[key]
+				value := *fields[key]
+				if key == "country_code" && sameCountry(value, prefilled) {
+					continue
+				}
+				if ok && value != prefilled {
+					errs = append(errs, FieldError{Field: key, Message: "cannot be changed from the prefilled address"})
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
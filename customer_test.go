@@ -58,3 +58,145 @@ func TestCustomer_Params(t *testing.T) {
 	assert.Equal(t, "0", params["user_can_use_customer_data"])
 	assert.Equal(t, "0", params["world_can_use_customer_data"])
 }
+
+func TestCustomer_Params_omits_empty_optional_fields(t *testing.T) {
+	customer := Customer{
+		FirstName: "Fred",
+		LastName:  "Flintstone",
+	}
+
+	params := customer.Params()
+	assert.Equal(t, map[string]string{
+		"first_name":                     "Fred",
+		"last_name":                      "Flintstone",
+		"country_code":                   "",
+		"address_line_one":               "",
+		"supplier_can_use_customer_data": "0",
+		"user_can_use_customer_data":     "0",
+		"world_can_use_customer_data":    "0",
+	}, params)
+}
+
+func TestCustomer_Validate(t *testing.T) {
+	customer := Customer{
+		FirstName:      "Fred",
+		LastName:       "Flintstone",
+		AddressLineOne: "1313 Boulder Lane",
+		CountryCode:    "uk",
+	}
+	assert.Nil(t, customer.Validate(nil))
+
+	reservation := &ReservationResult{
+		AllowedCountries:    map[string]string{"uk": "United Kingdom", "us": "USA"},
+		CanEditAddress:      true,
+		NeedsEmailAddress:   true,
+		NeedsAgentReference: true,
+	}
+	err := customer.Validate(reservation)
+	if assert.IsType(t, ValidationErrors{}, err) {
+		errs := err.(ValidationErrors)
+		assert.Len(t, errs, 2)
+		_, ok := errs.Field("email_address")
+		assert.True(t, ok)
+		_, ok = errs.Field("agent_ref")
+		assert.True(t, ok)
+		assert.Contains(t, err.Error(), "email_address: is required")
+	}
+
+	customer.EmailAddress = "not an email"
+	customer.AgentReference = "ABC123"
+	customer.CountryCode = "fr"
+	customer.FirstName = " "
+	err = customer.Validate(reservation)
+	if assert.IsType(t, ValidationErrors{}, err) {
+		errs := err.(ValidationErrors)
+		assert.Len(t, errs, 3)
+		fieldErr, ok := errs.Field("email_address")
+		if assert.True(t, ok) {
+			assert.Equal(t, "is not a valid email address", fieldErr.Message)
+		}
+		fieldErr, ok = errs.Field("country_code")
+		if assert.True(t, ok) {
+			assert.Equal(t, "is not an allowed country", fieldErr.Message)
+		}
+		_, ok = errs.Field("first_name")
+		assert.True(t, ok)
+	}
+
+	customer.FirstName = "Fred"
+	customer.EmailAddress = "fred@rockslateandgravel.com"
+	customer.CountryCode = "us"
+	assert.Nil(t, customer.Validate(reservation))
+
+	// ISO codes in any case match the API's lower case codes.
+	customer.CountryCode = "GB"
+	assert.Nil(t, customer.Validate(reservation))
+	customer.CountryCode = "US"
+	assert.Nil(t, customer.Validate(reservation))
+	customer.CountryCode = "gb"
+
+	reservation.CanEditAddress = false
+	reservation.PrefilledAddress = map[string]string{"country_code": "uk", "postcode": "EC1R 4TN"}
+	err = customer.Validate(reservation)
+	if assert.IsType(t, ValidationErrors{}, err) {
+		errs := err.(ValidationErrors)
+		assert.Len(t, errs, 1)
+		fieldErr, ok := errs.Field("postcode")
+		if assert.True(t, ok) {
+			assert.Equal(t, "cannot be changed from the prefilled address", fieldErr.Message)
+		}
+	}
+	customer.CountryCode = "us"
+	err = customer.Validate(reservation)
+	if assert.IsType(t, ValidationErrors{}, err) {
+		_, ok := err.(ValidationErrors).Field("country_code")
+		assert.True(t, ok)
+	}
+
+	customer.ApplyPrefilledAddress(reservation)
+	assert.Equal(t, "uk", customer.CountryCode)
+	assert.Equal(t, "EC1R 4TN", customer.Postcode)
+	assert.Equal(t, "1313 Boulder Lane", customer.AddressLineOne)
+	assert.Nil(t, customer.Validate(reservation))
+}
+
+func TestCustomer_ApplyPrefilledAddress_editable(t *testing.T) {
+	customer := Customer{CountryCode: "us", Town: "Bedrock"}
+	customer.ApplyPrefilledAddress(&ReservationResult{
+		CanEditAddress:   true,
+		PrefilledAddress: map[string]string{"country_code": "uk", "town": "London"},
+	})
+	assert.Equal(t, "us", customer.CountryCode)
+	assert.Equal(t, "Bedrock", customer.Town)
+
+	customer.ApplyPrefilledAddress(nil)
+	assert.Equal(t, "us", customer.CountryCode)
+}
+
+func TestMakePurchaseParams_Validate(t *testing.T) {
+	reservation := &ReservationResult{CanEditAddress: true, NeedsAgentReference: true}
+	params := MakePurchaseParams{
+		Customer: Customer{
+			FirstName:      "Barney",
+			LastName:       "Rubble",
+			AddressLineOne: "1315 Boulder Lane",
+			CountryCode:    "us",
+		},
+		SendConfirmationEmail: true,
+	}
+
+	err := params.Validate(reservation)
+	if assert.IsType(t, ValidationErrors{}, err) {
+		errs := err.(ValidationErrors)
+		assert.Len(t, errs, 2)
+		_, ok := errs.Field("agent_ref")
+		assert.True(t, ok)
+		_, ok = errs.Field("email_address")
+		assert.True(t, ok)
+	}
+
+	params.AgentReference = "ABC123"
+	params.Customer.EmailAddress = "barney@rockslateandgravel.com"
+	assert.Nil(t, params.Validate(reservation))
+	assert.Equal(t, "", params.Customer.AgentReference)
+}
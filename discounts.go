@@ -1,6 +1,11 @@
 package ticketswitch
 
-import "github.com/shopspring/decimal"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
 
 // Discount contains all the information about the discount from the API
 type Discount struct {
@@ -34,3 +39,249 @@ type DiscountsResult struct {
 	CurrencyCode    string              `json:"currency_code"`
 	CurrencyDetails map[string]Currency `json:"currency_details"`
 }
+
+// AdultOfUnknownAge can be used in a Party for an adult whose age hasn't been
+// asked for.
+const AdultOfUnknownAge = -1
+
+// childAgeLimit is the age below which a member can receive a child discount
+// that doesn't state its own maximum age.
+const childAgeLimit = 18
+
+// Party describes the ages of the people seats are being reserved for, one
+// entry per seat.
+type Party []int
+
+// NewParty returns a party of adults of unknown age followed by children of
+// the given ages.
+func NewParty(adults int, childAges ...int) Party {
+	party := make(Party, 0, adults+len(childAges))
+	for i := 0; i < adults; i++ {
+		party = append(party, AdultOfUnknownAge)
+	}
+	return append(party, childAges...)
+}
+
+// EligibleForAge checks if someone of the given age can receive the discount.
+//
+// The discount's minimum and maximum eligible ages are always respected, and
+// an adult of unknown age is only eligible when the discount has no maximum
+// age and no minimum above adulthood. Child discounts without a maximum age
+// are limited to under 18s. Other concessions, such as student discounts,
+// depend on more than age and are only eligible when they state an age range.
+func (discount *Discount) EligibleForAge(age int) bool {
+	known := age != AdultOfUnknownAge
+	if discount.MinimumEligibleAge > 0 {
+		if known && age < discount.MinimumEligibleAge {
+			return false
+		}
+		if !known && discount.MinimumEligibleAge > childAgeLimit {
+			return false
+		}
+	}
+	if discount.MaximumEligibleAge > 0 && (!known || age > discount.MaximumEligibleAge) {
+		return false
+	}
+
+	switch discount.SemanticType {
+	case "", "standard", "adult":
+		return true
+	case "child":
+		if discount.MaximumEligibleAge > 0 {
+			return true
+		}
+		return known && age < childAgeLimit
+	default:
+		return discount.MinimumEligibleAge > 0 || discount.MaximumEligibleAge > 0
+	}
+}
+
+// CombinedPrice returns the price of a single seat with the discount
+// including any surcharge.
+func (discount *Discount) CombinedPrice() decimal.Decimal {
+	return discount.Seatprice.Add(discount.Surcharge)
+}
+
+// NoEligibleDiscountError is returned by AssignDiscounts when none of the
+// discounts can be given to a member of the party.
+type NoEligibleDiscountError struct {
+	// the position of the member within the party.
+	Index int
+	// the age of the member.
+	Age int
+}
+
+func (err NoEligibleDiscountError) Error() string {
+	if err.Age == AdultOfUnknownAge {
+		return fmt.Sprintf("ticketswitch: no discount available for party member %d (adult of unknown age)", err.Index)
+	}
+	return fmt.Sprintf("ticketswitch: no discount available for party member %d aged %d", err.Index, err.Age)
+}
+
+// DiscountsUsedUpError is returned by AssignDiscounts when a member of the
+// party is eligible for some of the discounts but there aren't enough of them
+// available for the whole party.
+type DiscountsUsedUpError struct {
+	// the position of the member within the party.
+	Index int
+	// the age of the member.
+	Age int
+}
+
+func (err DiscountsUsedUpError) Error() string {
+	if err.Age == AdultOfUnknownAge {
+		return fmt.Sprintf("ticketswitch: the discounts for party member %d (adult of unknown age) are used up", err.Index)
+	}
+	return fmt.Sprintf("ticketswitch: the discounts for party member %d aged %d are used up", err.Index, err.Age)
+}
+
+// DiscountAssignment is the result of assigning discounts to a party.
+type DiscountAssignment struct {
+	// discount codes in the same order as the party, suitable for
+	// MakeReservationParams.Discounts.
+	Discounts []string
+	// the discount given to each member of the party.
+	Assigned []Discount
+	// the combined price of all the seats.
+	Total decimal.Decimal
+}
+
+// Apply sets the discounts and number of seats on reservation params.
+func (assignment *DiscountAssignment) Apply(params *MakeReservationParams) {
+	params.Discounts = assignment.Discounts
+	params.NumberOfSeats = len(assignment.Discounts)
+}
+
+// AssignDiscounts works out the eligible discount for each member of the
+// party that gives the cheapest total, see Discount.EligibleForAge.
+//
+// Discounts with a positive NumberAvailable are never given to more members
+// than are available, so a member may be given a dearer discount to leave a
+// scarce one for someone who needs it. A NoEligibleDiscountError is returned
+// when a member isn't eligible for any of the discounts and a
+// DiscountsUsedUpError when there aren't enough of them for the whole party.
+func AssignDiscounts(party Party, discounts []Discount) (*DiscountAssignment, error) {
+	if len(party) == 0 {
+		return nil, errors.New("ticketswitch: party is empty")
+	}
+
+	eligible := make([][]int, len(party))
+	for i, age := range party {
+		for j := range discounts {
+			if discounts[j].EligibleForAge(age) {
+				eligible[i] = append(eligible[i], j)
+			}
+		}
+		if len(eligible[i]) == 0 {
+			return nil, NoEligibleDiscountError{Index: i, Age: age}
+		}
+	}
+
+	// members are added one at a time, each along the cheapest path of
+	// moves that frees a discount for them, which keeps the total of the
+	// members added so far as low as it can be.
+	assigned := make([]int, len(party))
+	used := make([]int, len(discounts))
+	for i := range party {
+		path, ok := cheapestDiscountPath(i, eligible, discounts, assigned[:i], used)
+		if !ok {
+			return nil, DiscountsUsedUpError{Index: i, Age: party[i]}
+		}
+		for _, move := range path {
+			if move.member < i {
+				used[assigned[move.member]]--
+			}
+			assigned[move.member] = move.discount
+			used[move.discount]++
+		}
+	}
+
+	assignment := &DiscountAssignment{
+		Discounts: make([]string, len(party)),
+		Assigned:  make([]Discount, len(party)),
+	}
+	for i, j := range assigned {
+		assignment.Discounts[i] = discounts[j].Code
+		assignment.Assigned[i] = discounts[j]
+		assignment.Total = assignment.Total.Add(discounts[j].CombinedPrice())
+	}
+	return assignment, nil
+}
+
+// discountMove gives a member of the party a different discount.
+type discountMove struct {
+	member   int
+	discount int
+}
+
+// cheapestDiscountPath finds the cheapest way to give the member a discount,
+// moving members already assigned one to other discounts they are eligible
+// for when the ones the member could have are used up. It returns false when
+// there is no way.
+func cheapestDiscountPath(member int, eligible [][]int, discounts []Discount, assigned []int, used []int) ([]discountMove, bool) {
+	// the cost of reaching each discount and the move that reached it.
+	cost := make([]*decimal.Decimal, len(discounts))
+	moves := make([]discountMove, len(discounts))
+	from := make([]int, len(discounts))
+	relax := func(m, previous, j int, c decimal.Decimal) bool {
+		if cost[j] != nil && !c.LessThan(*cost[j]) {
+			return false
+		}
+		cost[j] = &c
+		moves[j] = discountMove{member: m, discount: j}
+		from[j] = previous
+		return true
+	}
+	for _, j := range eligible[member] {
+		relax(member, -1, j, discounts[j].CombinedPrice())
+	}
+
+	// the costs can go down as members move to cheaper discounts, so keep
+	// relaxing until nothing changes. There are no negative cycles because
+	// the assignment so far is already the cheapest.
+	for changed := true; changed; {
+		changed = false
+		for m, j := range assigned {
+			if cost[j] == nil {
+				continue
+			}
+			for _, k := range eligible[m] {
+				if k == j {
+					continue
+				}
+				c := cost[j].Sub(discounts[j].CombinedPrice()).Add(discounts[k].CombinedPrice())
+				if relax(m, j, k, c) {
+					changed = true
+				}
+			}
+		}
+	}
+
+	end := -1
+	for j := range discounts {
+		if cost[j] == nil {
+			continue
+		}
+		if discounts[j].NumberAvailable > 0 && used[j] >= discounts[j].NumberAvailable {
+			continue
+		}
+		if end < 0 || cost[j].LessThan(*cost[end]) {
+			end = j
+		}
+	}
+	if end < 0 {
+		return nil, false
+	}
+
+	var path []discountMove
+	for j := end; j >= 0; j = from[j] {
+		path = append(path, moves[j])
+	}
+	return path, true
+}
+
+// AssignDiscounts works out the cheapest eligible discount for each member of
+// the party from the discounts in the result.
+func (result *DiscountsResult) AssignDiscounts(party Party) (*DiscountAssignment, error) {
+	return AssignDiscounts(party, result.DiscountsHolder.Discounts)
+}
@@ -0,0 +1,150 @@
+package ticketswitch
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadDiscounts(t *testing.T, path string) *DiscountsResult {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result DiscountsResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return &result
+}
+
+func TestNewParty(t *testing.T) {
+	assert.Equal(t, Party{AdultOfUnknownAge, AdultOfUnknownAge, 7, 12}, NewParty(2, 7, 12))
+	assert.Equal(t, Party{}, NewParty(0))
+}
+
+func TestDiscount_EligibleForAge(t *testing.T) {
+	discounts := loadDiscounts(t, "testdata/discounts_by_age.json").DiscountsHolder.Discounts
+	adult, child, under10, student, oap := discounts[0], discounts[1], discounts[2], discounts[3], discounts[4]
+
+	assert.True(t, adult.EligibleForAge(AdultOfUnknownAge))
+	assert.True(t, adult.EligibleForAge(16))
+	assert.False(t, adult.EligibleForAge(15))
+
+	assert.False(t, child.EligibleForAge(AdultOfUnknownAge))
+	assert.False(t, child.EligibleForAge(4))
+	assert.True(t, child.EligibleForAge(5))
+	assert.True(t, child.EligibleForAge(15))
+	assert.False(t, child.EligibleForAge(16))
+
+	assert.True(t, under10.EligibleForAge(0))
+	assert.False(t, under10.EligibleForAge(10))
+
+	assert.False(t, student.EligibleForAge(AdultOfUnknownAge))
+	assert.False(t, student.EligibleForAge(20))
+
+	assert.False(t, oap.EligibleForAge(AdultOfUnknownAge))
+	assert.False(t, oap.EligibleForAge(64))
+	assert.True(t, oap.EligibleForAge(65))
+
+	untyped := Discount{Code: "CHILD"}
+	assert.True(t, untyped.EligibleForAge(AdultOfUnknownAge))
+	untyped.SemanticType = "child"
+	assert.False(t, untyped.EligibleForAge(AdultOfUnknownAge))
+	assert.True(t, untyped.EligibleForAge(17))
+	assert.False(t, untyped.EligibleForAge(18))
+}
+
+func TestAssignDiscounts(t *testing.T) {
+	result := loadDiscounts(t, "testdata/discounts_by_age.json")
+
+	assignment, err := result.AssignDiscounts(NewParty(2, 7, 12))
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"ADULT", "ADULT", "UNDER10", "CHILD"}, assignment.Discounts)
+		assert.Equal(t, "Kids go half price", assignment.Assigned[2].Description)
+		assert.True(t, decimal.NewFromInt(113).Equal(assignment.Total), assignment.Total.String())
+
+		params := MakeReservationParams{}
+		assignment.Apply(&params)
+		assert.Equal(t, 4, params.NumberOfSeats)
+		assert.Equal(t, assignment.Discounts, params.Discounts)
+	}
+
+	// only one UNDER10 is available so it goes to the youngest child that
+	// can't get a CHILD discount
+	assignment, err = result.AssignDiscounts(Party{7, 3, 70})
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"CHILD", "UNDER10", "OAP"}, assignment.Discounts)
+	}
+
+	// both young children can only have the single UNDER10
+	_, err = result.AssignDiscounts(Party{AdultOfUnknownAge, 3, 2})
+	if assert.IsType(t, DiscountsUsedUpError{}, err) {
+		assert.Equal(t, DiscountsUsedUpError{Index: 2, Age: 2}, err)
+		assert.Equal(t, "ticketswitch: the discounts for party member 2 aged 2 are used up", err.Error())
+	}
+
+	_, err = AssignDiscounts(Party{2}, result.DiscountsHolder.Discounts[:2])
+	if assert.IsType(t, NoEligibleDiscountError{}, err) {
+		assert.Equal(t, NoEligibleDiscountError{Index: 0, Age: 2}, err)
+		assert.Equal(t, "ticketswitch: no discount available for party member 0 aged 2", err.Error())
+	}
+
+	_, err = AssignDiscounts(NewParty(1), []Discount{{Code: "CHILD", SemanticType: "child"}})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "ticketswitch: no discount available for party member 0 (adult of unknown age)", err.Error())
+	}
+
+	_, err = result.AssignDiscounts(Party{})
+	assert.NotNil(t, err)
+}
+
+func TestAssignDiscounts_moves_members(t *testing.T) {
+	// one of each discount is available, A is only for 5 to 10 year olds and
+	// C only for 8 to 12 year olds. Giving the first child the cheapest
+	// discount would leave nothing for the third.
+	discounts := []Discount{
+		{Code: "A", Seatprice: decimal.NewFromInt(10), MinimumEligibleAge: 5, MaximumEligibleAge: 10, NumberAvailable: 1},
+		{Code: "B", Seatprice: decimal.NewFromInt(20), MinimumEligibleAge: 5, MaximumEligibleAge: 12, NumberAvailable: 1},
+		{Code: "C", Seatprice: decimal.NewFromInt(30), MinimumEligibleAge: 8, MaximumEligibleAge: 12, NumberAvailable: 1},
+	}
+	assignment, err := AssignDiscounts(Party{7, 11, 6}, discounts)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"A", "C", "B"}, assignment.Discounts)
+		assert.True(t, decimal.NewFromInt(60).Equal(assignment.Total), assignment.Total.String())
+	}
+
+	// with two of B the total is cheaper if the first child gives up A
+	discounts[1].NumberAvailable = 2
+	assignment, err = AssignDiscounts(Party{7, 11, 6}, discounts)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"A", "B", "B"}, assignment.Discounts)
+		assert.True(t, decimal.NewFromInt(50).Equal(assignment.Total), assignment.Total.String())
+	}
+
+	_, err = AssignDiscounts(Party{7, 11, 6, 9}, discounts[:2])
+	assert.Equal(t, DiscountsUsedUpError{Index: 3, Age: 9}, err)
+}
+
+func TestPriceBand_AssignDiscounts(t *testing.T) {
+	data, err := os.ReadFile("testdata/availability.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result AvailabilityResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	band := result.Availability.TicketTypes[0].PriceBands[0]
+	assignment, err := band.AssignDiscounts(NewParty(1, 8))
+	if assert.Nil(t, err) {
+		// the fixture has no age restrictions so every seat gets the
+		// cheapest discount
+		assert.Equal(t, []string{"CHILD", "CHILD"}, assignment.Discounts)
+		assert.True(t, decimal.NewFromInt(42).Equal(assignment.Total))
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // F13AuthErrorCode is the error code that gets returned for Authentication errors
@@ -15,6 +17,10 @@ type Error struct {
 	Description         string `json:"error_desc"`
 	AuthenticationError bool
 	CallbackGoneError   bool
+	// the API is rate limiting the user.
+	RateLimited bool
+	// how long the API asked us to wait before retrying when rate limited.
+	RetryAfter time.Duration
 }
 
 func (err Error) Error() string {
@@ -26,16 +32,26 @@ func checkForError(resp *http.Response) error {
 	if resp.StatusCode == http.StatusGone {
 		ret.CallbackGoneError = true
 	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		ret.RateLimited = true
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			ret.RetryAfter = time.Duration(seconds) * time.Second
+		}
+	}
 	decoder := json.NewDecoder(resp.Body)
 	err := decoder.Decode(&ret)
 	if err != nil {
+		if ret.RateLimited || ret.CallbackGoneError {
+			ret.Description = http.StatusText(resp.StatusCode)
+			return ret
+		}
 		return err
 	}
 
 	if ret.Code == F13AuthErrorCode {
 		ret.AuthenticationError = true
 	}
-	if ret.Code > 0 || ret.Description != "" || ret.AuthenticationError || ret.CallbackGoneError {
+	if ret.Code > 0 || ret.Description != "" || ret.AuthenticationError || ret.CallbackGoneError || ret.RateLimited {
 		return ret
 	}
 	return nil
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -93,3 +94,42 @@ func TestCheckForError_CallbackGoneError(t *testing.T) {
 	}
 	assert.True(t, ticketswitchErr.CallbackGoneError)
 }
+
+func TestCheckForError_CallbackGoneError_without_body(t *testing.T) {
+	responseWriter := httptest.NewRecorder()
+	responseWriter.WriteHeader(http.StatusGone)
+	response := responseWriter.Result()
+
+	defer response.Body.Close()
+
+	err := checkForError(response)
+
+	assert.NotNil(t, err)
+	ticketswitchErr, ok := err.(Error)
+	if !ok {
+		t.Fatal("Should be able to convert error into Error type")
+	}
+	assert.True(t, ticketswitchErr.CallbackGoneError)
+	assert.Equal(t, "Gone", ticketswitchErr.Description)
+}
+
+func TestCheckForError_RateLimited(t *testing.T) {
+	responseWriter := httptest.NewRecorder()
+	responseWriter.Header().Set("Retry-After", "30")
+	responseWriter.WriteHeader(http.StatusTooManyRequests)
+	_, _ = responseWriter.Write([]byte("slow down"))
+	response := responseWriter.Result()
+
+	defer response.Body.Close()
+
+	err := checkForError(response)
+
+	assert.NotNil(t, err)
+	ticketswitchErr, ok := err.(Error)
+	if !ok {
+		t.Fatal("Should be able to convert error into Error type")
+	}
+	assert.True(t, ticketswitchErr.RateLimited)
+	assert.Equal(t, 30*time.Second, ticketswitchErr.RetryAfter)
+	assert.Equal(t, "Too Many Requests", ticketswitchErr.Description)
+}
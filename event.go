@@ -1,6 +1,6 @@
 package ticketswitch
 
-import "github.com/shopspring/decimal"
+import "time"
 
 // GeoData contains the longitude and latitude of the Venue
 type GeoData struct {
@@ -60,12 +60,12 @@ type Event struct {
 	IsSeated bool `json:"is_seated"`
 	// indicates that ticket purchases for this event will require a departure
 	// date.
-	NeedsDepartureDate bool `json:"needs_departure_date"`
+	NeedsDepartureDate bool `json:"need_departure_date"`
 	// indicates that ticket purchases for this event will require a duration.
-	NeedsDuration bool `json:"needs_duration"`
+	NeedsDuration bool `json:"need_duration"`
 	// indicates that ticket purchases for this event will require a
 	// performance id.
-	NeedsPerformance bool `json:"needs_performance"`
+	NeedsPerformance bool `json:"need_performance"`
 	// list of related event id's for upselling.
 	UpsellList UpsellList `json:"event_upsell_list"`
 	// pricing summary from cached availability. Only present when requested.
@@ -100,9 +100,7 @@ type Event struct {
 	CriticReviewPercent float64 `json:"critic_review_percent"`
 	// summary of availability details from cached data. Only
 	// present when requested.
-	AvailabilityDetails AvailabilityDetails `json:"availability_details"`
-	// avail details
-	AvailDetails AvailDetails `json:"avail_details"`
+	AvailabilityDetails AvailabilityDetails `json:"avail_details"`
 	// list of Event objects that comprise a meta event
 	ComponentEvents []Event `json:"component_events"`
 	// list of valid qualities available for purchase. from cached data, only
@@ -118,19 +116,17 @@ type Event struct {
 	VenueCode string `json:"venue_code"`
 }
 
-type AvailDetailsTicketType struct {
-	Code       string           `json:"ticket_type_code"`
-	Desc       string           `json:"ticket_type_desc"`
-	PriceBands []EventPriceBand `json:"price_band"`
+// PriceBandsAvailableOn returns the price bands the event's cached
+// availability details say are available on the given date, cheapest first.
+// The event must have been requested with availability details.
+func (event *Event) PriceBandsAvailableOn(date time.Time) []CachedPriceBand {
+	return event.AvailabilityDetails.PriceBandsAvailableOn(date)
 }
 
-type AvailDetails struct {
-	AvailDetailsTicketTypes []AvailDetailsTicketType `json:"ticket_type"`
-}
-
-type AvailableDates struct {
-	First string `json:"first_yyyymmdd"`
-	Last  string `json:"last_yyyymmdd"`
+// CheapestPriceBandOn returns the cheapest price band the event's cached
+// availability details say is available on the given date.
+func (event *Event) CheapestPriceBandOn(date time.Time) (CachedPriceBand, bool) {
+	return event.AvailabilityDetails.CheapestPriceBandOn(date)
 }
 
 // ListEventsResults represents a set of events returned by the API
@@ -149,28 +145,3 @@ type ListEventsResults struct {
 	// performances returned by the call
 	Events []Event `json:"event"`
 }
-
-type EventPriceBand struct {
-	Code               string               `json:"price_band_code"`
-	Desc               string               `json:"price_band_desc"`
-	AvailabilityDetail []AvailabilityDetail `json:"avail_detail"`
-}
-
-type AvailabilityDetail struct {
-	AvailabilityCurrencyCode string          `json:"avail_currency_code"`
-	AvailableDates           AvailableDates  `json:"available_dates"`
-	AvailableWeekdays        int             `json:"available_weekdays_bitmask"`
-	CombinedTaxComponent     decimal.Decimal `json:"combined_tax_component"`
-	// CombinedTaxComponentInDesired     decimal.Decimal `json:"combined_tax_component_in_desired"`
-	// DesiredCurrencyCode      string          `json:"desired_currency_code"`
-	DiscountSemanticType string          `json:"discount_semantic_type"`
-	Seatprice            decimal.Decimal `json:"seatprice"`
-	// SeatpriceInDesired       decimal.Decimal `json:"seatprice_in_desired"`
-	SuffixedPriceBandCode string          `json:"suffixed_price_band_code"`
-	Surcharge             decimal.Decimal `json:"surcharge"`
-	// SurchargeInDesired       decimal.Decimal `json:"surcharge_in_desired"`
-	SurchargeTaxSubComponent decimal.Decimal `json:"surcharge_tax_sub_component"`
-	// SurchargeTaxSubComponentInDesired decimal.Decimal `json:"surcharge_tax_sub_component_in_desired"`
-	ValidQuantities       []int `json:"valid_quantities"`
-	CachedNumberAvailable int   `json:"cached_number_available"`
-}
@@ -0,0 +1,339 @@
+package ticketswitch
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// EventPredicate reports whether an event should be kept by an EventQuery.
+type EventPredicate func(event *Event) bool
+
+// EventQuery filters and sorts events on the client, for listing pages that
+// need more than the filters and sort orders the API offers. Build one by
+// chaining the filter and SortBy methods, then Apply it to the events:
+//
+//	query := NewEventQuery().
+//		InCity("london").
+//		InClass("theatre").
+//		Seated(true).
+//		PriceBetween(decimal.Zero, decimal.NewFromInt(50)).
+//		SortBy(ByCriticRating, ByMinPrice)
+//	events := query.Apply(results.Events)
+//
+// ListEventsParams builds the params for the parts of the query the API can
+// do itself, so fewer events need to be fetched.
+type EventQuery struct {
+	predicates []EventPredicate
+	orders     []EventOrder
+
+	// the parts of the query the API supports.
+	cityCode    string
+	countryCode string
+	circle      *Circle
+	// set when a filter or sort order needs the events' cost ranges.
+	costRange bool
+}
+
+// NewEventQuery returns a query that keeps every event in its original
+// order.
+func NewEventQuery() *EventQuery {
+	return &EventQuery{}
+}
+
+// Where keeps the events the predicate is true for.
+func (query *EventQuery) Where(predicate EventPredicate) *EventQuery {
+	query.predicates = append(query.predicates, predicate)
+	return query
+}
+
+// InClass keeps the events that belong to any of the classes, by class
+// identifier.
+func (query *EventQuery) InClass(classes ...string) *EventQuery {
+	return query.Where(func(event *Event) bool {
+		for _, class := range classes {
+			if _, ok := event.Classes[class]; ok {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// WithFilter keeps the events that have any of the custom filters.
+func (query *EventQuery) WithFilter(filters ...string) *EventQuery {
+	return query.Where(func(event *Event) bool {
+		for _, filter := range filters {
+			for _, eventFilter := range event.Filters {
+				if eventFilter == filter {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+// AtVenue keeps the events at any of the venues, matched by venue code or,
+// ignoring case, by venue description.
+func (query *EventQuery) AtVenue(venues ...string) *EventQuery {
+	return query.Where(func(event *Event) bool {
+		for _, venue := range venues {
+			if (event.VenueCode != "" && event.VenueCode == venue) || strings.EqualFold(event.Venue, venue) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// InCity keeps the events in any of the cities, by city code. The API can
+// filter by a single city.
+func (query *EventQuery) InCity(codes ...string) *EventQuery {
+	if len(codes) == 1 && query.cityCode == "" {
+		query.cityCode = codes[0]
+	}
+	return query.Where(func(event *Event) bool {
+		for _, code := range codes {
+			if event.CityCode == code {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// InCountry keeps the events in the country, by ISO 3166-1 country code. The
+// API can filter by country.
+func (query *EventQuery) InCountry(code string) *EventQuery {
+	if query.countryCode == "" {
+		query.countryCode = code
+	}
+	return query.Where(func(event *Event) bool {
+		return strings.EqualFold(event.CountryCode, code)
+	})
+}
+
+// Within keeps the events whose venue is within the circle's radius, in
+// kilometres. Events without geo data are left out. The API can filter by a
+// circle.
+func (query *EventQuery) Within(circle *Circle) *EventQuery {
+	if query.circle == nil {
+		query.circle = circle
+	}
+	return query.Where(func(event *Event) bool {
+		distance, ok := event.DistanceFrom(circle.Point)
+		return ok && distance <= circle.Radius
+	})
+}
+
+// Seated keeps the seated events, or the unseated ones when seated is false.
+func (query *EventQuery) Seated(seated bool) *EventQuery {
+	return query.Where(func(event *Event) bool {
+		return event.IsSeated == seated
+	})
+}
+
+// MinCriticReview keeps the events with a critic review percentage of at
+// least percent. Events without reviews are left out.
+func (query *EventQuery) MinCriticReview(percent float64) *EventQuery {
+	return query.Where(func(event *Event) bool {
+		return event.CriticReviewPercent > 0 && event.CriticReviewPercent >= percent
+	})
+}
+
+// PriceBetween keeps the events with tickets priced between min and max,
+// including surcharges, according to their cached cost range. A zero max
+// means no upper limit. Prices are compared in each event's own currency and
+// events without a cost range are left out.
+func (query *EventQuery) PriceBetween(min, max decimal.Decimal) *EventQuery {
+	query.costRange = true
+	return query.Where(func(event *Event) bool {
+		low, high, ok := eventPriceRange(event)
+		if !ok {
+			return false
+		}
+		return high.GreaterThanOrEqual(min) && (max.IsZero() || low.LessThanOrEqual(max))
+	})
+}
+
+// SortBy sorts the events by each order in turn, so later orders break ties
+// in earlier ones. Events that are equal in every order keep their original
+// order.
+func (query *EventQuery) SortBy(orders ...EventOrder) *EventQuery {
+	for _, order := range orders {
+		if order.costRange {
+			query.costRange = true
+		}
+	}
+	query.orders = append(query.orders, orders...)
+	return query
+}
+
+// Match checks if the event is kept by every filter of the query.
+func (query *EventQuery) Match(event *Event) bool {
+	for _, predicate := range query.predicates {
+		if !predicate(event) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the events kept by the query in its sort order. The events
+// passed in are left as they are.
+func (query *EventQuery) Apply(events []Event) []Event {
+	matched := make([]Event, 0, len(events))
+	for i := range events {
+		if query.Match(&events[i]) {
+			matched = append(matched, events[i])
+		}
+	}
+	if len(query.orders) == 0 {
+		return matched
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := &matched[i], &matched[j]
+		for _, order := range query.orders {
+			if c := order.compare(a, b); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	return matched
+}
+
+// ListEventsParams returns the params for listing the events with the parts
+// of the query the API supports: a single city, a country, a circle and a
+// first sort order the API has. Cost ranges are requested when the query
+// filters or sorts by price. The results still need to be passed to Apply for
+// the rest of the query.
+func (query *EventQuery) ListEventsParams() *ListEventsParams {
+	params := &ListEventsParams{
+		CityCode:    query.cityCode,
+		CountryCode: query.countryCode,
+		Circle:      query.circle,
+	}
+	params.CostRange = query.costRange
+	if len(query.orders) > 0 {
+		params.SortOrder = query.orders[0].sortOrder()
+	}
+	return params
+}
+
+// EventOrder is a key events can be sorted by with EventQuery.SortBy.
+type EventOrder struct {
+	name string
+	// returns the value of the key and false when the event has none.
+	key        func(event *Event) (interface{}, bool)
+	less       func(a, b interface{}) bool
+	descending bool
+	// set when the key comes from the event's cost range.
+	costRange bool
+}
+
+// The keys events can be sorted by. Events without a value for the key, such
+// as events without a cost range or reviews, are always sorted last.
+var (
+	// ByDescription sorts by event description, ignoring case.
+	ByDescription = EventOrder{name: "description", key: func(event *Event) (interface{}, bool) {
+		return strings.ToLower(event.Description), event.Description != ""
+	}, less: lessString}
+	// ByVenue sorts by venue description, ignoring case.
+	ByVenue = EventOrder{name: "venue", key: func(event *Event) (interface{}, bool) {
+		return strings.ToLower(event.Venue), event.Venue != ""
+	}, less: lessString}
+	// ByCity sorts by city description, ignoring case.
+	ByCity = EventOrder{name: "city", key: func(event *Event) (interface{}, bool) {
+		return strings.ToLower(event.City), event.City != ""
+	}, less: lessString}
+	// ByCriticRating sorts by critic review percentage, highest first.
+	ByCriticRating = EventOrder{name: "rating", key: func(event *Event) (interface{}, bool) {
+		return event.CriticReviewPercent, event.CriticReviewPercent > 0
+	}, less: func(a, b interface{}) bool {
+		return a.(float64) < b.(float64)
+	}, descending: true}
+	// ByMinPrice sorts by the cheapest ticket price including surcharge,
+	// cheapest first.
+	ByMinPrice = EventOrder{name: "min_price", key: func(event *Event) (interface{}, bool) {
+		low, _, ok := eventPriceRange(event)
+		return low, ok
+	}, less: lessDecimal, costRange: true}
+	// ByMaxPrice sorts by the most expensive ticket price including
+	// surcharge, cheapest first.
+	ByMaxPrice = EventOrder{name: "max_price", key: func(event *Event) (interface{}, bool) {
+		_, high, ok := eventPriceRange(event)
+		return high, ok
+	}, less: lessDecimal, costRange: true}
+)
+
+// Reverse returns the order the other way round. Events without a value for
+// the key are still sorted last.
+func (order EventOrder) Reverse() EventOrder {
+	order.descending = !order.descending
+	return order
+}
+
+func (order EventOrder) compare(a, b *Event) int {
+	aKey, aOK := order.key(a)
+	bKey, bOK := order.key(b)
+	switch {
+	case !aOK && !bOK:
+		return 0
+	case !aOK:
+		return 1
+	case !bOK:
+		return -1
+	}
+	c := 0
+	if order.less(aKey, bKey) {
+		c = -1
+	} else if order.less(bKey, aKey) {
+		c = 1
+	}
+	if order.descending {
+		c = -c
+	}
+	return c
+}
+
+// sortOrder returns the API sort order matching the order, or an empty
+// string when the API doesn't have one.
+func (order EventOrder) sortOrder() string {
+	switch {
+	case order.name == "description" && !order.descending:
+		return SortAlphabetic
+	case order.name == "rating" && order.descending:
+		return SortCriticRating
+	case order.name == "min_price" && !order.descending:
+		return SortCostAscending
+	case order.name == "max_price" && order.descending:
+		return SortCostDescending
+	}
+	return ""
+}
+
+func lessString(a, b interface{}) bool {
+	return a.(string) < b.(string)
+}
+
+func lessDecimal(a, b interface{}) bool {
+	return a.(decimal.Decimal).LessThan(b.(decimal.Decimal))
+}
+
+// eventPriceRange returns the cheapest and most expensive ticket prices of
+// the event including surcharges, or false when it has no cost range.
+func eventPriceRange(event *Event) (decimal.Decimal, decimal.Decimal, bool) {
+	costRange := event.CostRange
+	if costRange.MinSeatPrice.IsZero() && costRange.MaxSeatPrice.IsZero() {
+		return decimal.Zero, decimal.Zero, false
+	}
+	low := costRange.MinSeatPrice.Add(costRange.MinSurcharge)
+	high := costRange.MaxSeatPrice.Add(costRange.MaxSurcharge)
+	if high.LessThan(low) {
+		high = low
+	}
+	return low, high, true
+}
@@ -0,0 +1,105 @@
+package ticketswitch
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func testQueryEvents() []Event {
+	price := func(min, max string) CostRange {
+		return CostRange{
+			MinSeatPrice: decimal.RequireFromString(min),
+			MaxSeatPrice: decimal.RequireFromString(max),
+			MinSurcharge: decimal.RequireFromString("2.50"),
+			MaxSurcharge: decimal.RequireFromString("5"),
+		}
+	}
+	return []Event{
+		{ID: "ballet", Description: "The Unremarkable Ballet", Venue: "Lyric Theatre", VenueCode: "LY", CityCode: "london", CountryCode: "uk",
+			Classes: map[string]string{"dance": "Dance"}, IsSeated: true, CriticReviewPercent: 80, CostRange: price("20", "60")},
+		{ID: "musical", Description: "a Musical", Venue: "Apollo Theatre", VenueCode: "AP", CityCode: "london", CountryCode: "uk",
+			Classes: map[string]string{"theatre": "Theatre"}, Filters: []string{"family"}, IsSeated: true, CriticReviewPercent: 80, CostRange: price("15", "45")},
+		{ID: "tour", Description: "Bus Tour", CityCode: "london", CountryCode: "uk",
+			Classes: map[string]string{"attraction": "Attraction"}, Filters: []string{"family"}, CostRange: price("30", "30")},
+		{ID: "play", Description: "Play", Venue: "Palace Theatre", CityCode: "manchester", CountryCode: "uk",
+			Classes: map[string]string{"theatre": "Theatre"}, IsSeated: true, CriticReviewPercent: 60},
+	}
+}
+
+func queryIDs(query *EventQuery, events []Event) []string {
+	ids := []string{}
+	for _, event := range query.Apply(events) {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestEventQuery_Filters(t *testing.T) {
+	events := testQueryEvents()
+
+	assert.Equal(t, []string{"ballet", "musical", "tour", "play"}, queryIDs(NewEventQuery(), events))
+	assert.Equal(t, []string{"musical", "play"}, queryIDs(NewEventQuery().InClass("theatre"), events))
+	assert.Equal(t, []string{"musical", "tour"}, queryIDs(NewEventQuery().WithFilter("family"), events))
+	assert.Equal(t, []string{"ballet", "play"}, queryIDs(NewEventQuery().AtVenue("LY", "palace theatre"), events))
+	assert.Equal(t, []string{"play"}, queryIDs(NewEventQuery().InCity("manchester"), events))
+	assert.Equal(t, []string{"tour"}, queryIDs(NewEventQuery().Seated(false), events))
+	assert.Equal(t, []string{"ballet", "musical"}, queryIDs(NewEventQuery().MinCriticReview(70), events))
+	assert.Empty(t, queryIDs(NewEventQuery().InCountry("us"), events))
+
+	// tickets from 17.50 to 50.00 include the surcharge.
+	assert.Equal(t, []string{"musical"}, queryIDs(NewEventQuery().PriceBetween(decimal.Zero, decimal.RequireFromString("20")), events))
+	assert.Equal(t, []string{"ballet"}, queryIDs(NewEventQuery().PriceBetween(decimal.RequireFromString("51"), decimal.Zero), events))
+
+	query := NewEventQuery().
+		InClass("theatre", "dance").
+		Seated(true).
+		Where(func(event *Event) bool { return event.ID != "ballet" })
+	assert.Equal(t, []string{"musical", "play"}, queryIDs(query, events))
+	assert.True(t, query.Match(&events[3]))
+	assert.False(t, query.Match(&events[0]))
+
+	// the events passed in are left alone.
+	assert.Equal(t, "ballet", events[0].ID)
+}
+
+func TestEventQuery_SortBy(t *testing.T) {
+	events := testQueryEvents()
+
+	assert.Equal(t, []string{"musical", "ballet", "play", "tour"}, queryIDs(NewEventQuery().SortBy(ByCriticRating, ByMinPrice), events))
+	assert.Equal(t, []string{"musical", "ballet", "tour", "play"}, queryIDs(NewEventQuery().SortBy(ByMinPrice), events))
+	assert.Equal(t, []string{"tour", "ballet", "musical", "play"}, queryIDs(NewEventQuery().SortBy(ByMinPrice.Reverse()), events))
+	assert.Equal(t, []string{"musical", "tour", "play", "ballet"}, queryIDs(NewEventQuery().SortBy(ByDescription), events))
+	assert.Equal(t, []string{"musical", "ballet", "play", "tour"}, queryIDs(NewEventQuery().SortBy(ByVenue), events))
+}
+
+func TestEventQuery_ListEventsParams(t *testing.T) {
+	circle := NewCircle(51.5080, -0.1281, 5)
+	query := NewEventQuery().
+		InCity("london").
+		InCountry("uk").
+		Within(circle).
+		InClass("theatre").
+		SortBy(ByCriticRating, ByMinPrice)
+	params := query.ListEventsParams()
+	assert.Equal(t, "london", params.CityCode)
+	assert.Equal(t, "uk", params.CountryCode)
+	assert.Equal(t, circle, params.Circle)
+	assert.Equal(t, SortCriticRating, params.SortOrder)
+	assert.True(t, params.CostRange)
+
+	params = NewEventQuery().InCity("london").SortBy(ByCriticRating).ListEventsParams()
+	assert.False(t, params.CostRange)
+	assert.True(t, NewEventQuery().PriceBetween(decimal.Zero, decimal.NewFromInt(50)).ListEventsParams().CostRange)
+	assert.True(t, NewEventQuery().SortBy(ByMaxPrice.Reverse()).ListEventsParams().CostRange)
+
+	params = NewEventQuery().InCity("london", "manchester").SortBy(ByVenue).ListEventsParams()
+	assert.Equal(t, "", params.CityCode)
+	assert.Equal(t, "", params.SortOrder)
+
+	assert.Equal(t, SortCostAscending, NewEventQuery().SortBy(ByMinPrice).ListEventsParams().SortOrder)
+	assert.Equal(t, SortCostDescending, NewEventQuery().SortBy(ByMaxPrice.Reverse()).ListEventsParams().SortOrder)
+	assert.Equal(t, SortAlphabetic, NewEventQuery().SortBy(ByDescription).ListEventsParams().SortOrder)
+	assert.Equal(t, "", NewEventQuery().SortBy(ByDescription.Reverse()).ListEventsParams().SortOrder)
+}
@@ -0,0 +1,433 @@
+package ticketswitch
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultExportConcurrency is the number of events whose performances are
+// fetched at once by an Exporter.
+const DefaultExportConcurrency = 4
+
+// DefaultExportPageLength is the number of events requested per page by an
+// Exporter.
+const DefaultExportPageLength = 100
+
+// ExportColumns is the schema of the rows written by an Exporter. There is one
+// row for each performance, with the event columns repeated. Events without
+// performances, or whose performances couldn't be fetched, have a single row
+// with the performance columns empty.
+//
+//	event_id            identifier for the event
+//	event_desc          human-readable name for the event
+//	event_status        status of the event
+//	event_type          the type of the event
+//	source_code         the internal code for the backend system
+//	venue_desc          human-readable description of the venue
+//	postcode            venue post code
+//	city_code           venue city code
+//	city_desc           human-readable venue city
+//	country_code        ISO 3166-1 country code of the venue
+//	latitude            venue latitude, empty when unknown
+//	longitude           venue longitude, empty when unknown
+//	classes             class identifiers and descriptions of the event as
+//	                    id:description pairs separated by ";" ordered by id
+//	currency_code       currency of the event cost range
+//	min_seatprice       event cost range, empty without a cost range
+//	max_seatprice
+//	min_surcharge
+//	max_surcharge
+//	perf_id             identifier for the performance
+//	perf_name           the name of the performance
+//	perf_datetime       localized date and time of the performance, RFC 3339
+//	perf_currency_code  currency of the performance cost range
+//	perf_min_seatprice  performance cost range, empty without a cost range
+//	perf_max_seatprice
+//	perf_min_surcharge
+//	perf_max_surcharge
+//	error               why the performances of the event are missing
+//
+// Columns will only ever be added to the end of the schema.
+var ExportColumns = []string{
+	"event_id",
+	"event_desc",
+	"event_status",
+	"event_type",
+	"source_code",
+	"venue_desc",
+	"postcode",
+	"city_code",
+	"city_desc",
+	"country_code",
+	"latitude",
+	"longitude",
+	"classes",
+	"currency_code",
+	"min_seatprice",
+	"max_seatprice",
+	"min_surcharge",
+	"max_surcharge",
+	"perf_id",
+	"perf_name",
+	"perf_datetime",
+	"perf_currency_code",
+	"perf_min_seatprice",
+	"perf_max_seatprice",
+	"perf_min_surcharge",
+	"perf_max_surcharge",
+	"error",
+}
+
+// ExportRow is a row of a catalogue export, see ExportColumns.
+type ExportRow struct {
+	EventID          string `json:"event_id"`
+	EventDesc        string `json:"event_desc"`
+	EventStatus      string `json:"event_status"`
+	EventType        string `json:"event_type"`
+	SourceCode       string `json:"source_code"`
+	VenueDesc        string `json:"venue_desc"`
+	Postcode         string `json:"postcode"`
+	CityCode         string `json:"city_code"`
+	CityDesc         string `json:"city_desc"`
+	CountryCode      string `json:"country_code"`
+	Latitude         string `json:"latitude"`
+	Longitude        string `json:"longitude"`
+	Classes          string `json:"classes"`
+	CurrencyCode     string `json:"currency_code"`
+	MinSeatprice     string `json:"min_seatprice"`
+	MaxSeatprice     string `json:"max_seatprice"`
+	MinSurcharge     string `json:"min_surcharge"`
+	MaxSurcharge     string `json:"max_surcharge"`
+	PerfID           string `json:"perf_id"`
+	PerfName         string `json:"perf_name"`
+	PerfDatetime     string `json:"perf_datetime"`
+	PerfCurrencyCode string `json:"perf_currency_code"`
+	PerfMinSeatprice string `json:"perf_min_seatprice"`
+	PerfMaxSeatprice string `json:"perf_max_seatprice"`
+	PerfMinSurcharge string `json:"perf_min_surcharge"`
+	PerfMaxSurcharge string `json:"perf_max_surcharge"`
+	Error            string `json:"error"`
+}
+
+// Values returns the row's values in the order of ExportColumns.
+func (row *ExportRow) Values() []string {
+	return []string{
+		row.EventID,
+		row.EventDesc,
+		row.EventStatus,
+		row.EventType,
+		row.SourceCode,
+		row.VenueDesc,
+		row.Postcode,
+		row.CityCode,
+		row.CityDesc,
+		row.CountryCode,
+		row.Latitude,
+		row.Longitude,
+		row.Classes,
+		row.CurrencyCode,
+		row.MinSeatprice,
+		row.MaxSeatprice,
+		row.MinSurcharge,
+		row.MaxSurcharge,
+		row.PerfID,
+		row.PerfName,
+		row.PerfDatetime,
+		row.PerfCurrencyCode,
+		row.PerfMinSeatprice,
+		row.PerfMaxSeatprice,
+		row.PerfMinSurcharge,
+		row.PerfMaxSurcharge,
+		row.Error,
+	}
+}
+
+// costRangeValues formats a cost range as its currency and min and max seat
+// prices and surcharges. Everything is empty when there is no cost range.
+func costRangeValues(costRange *CostRange) (currency, minSeatprice, maxSeatprice, minSurcharge, maxSurcharge string) {
+	if costRange.CurrencyCode == "" {
+		return
+	}
+	format := func(d decimal.Decimal) string { return d.String() }
+	return costRange.CurrencyCode, format(costRange.MinSeatPrice), format(costRange.MaxSeatPrice),
+		format(costRange.MinSurcharge), format(costRange.MaxSurcharge)
+}
+
+// newExportRow returns a row with the event columns filled in.
+func newExportRow(event *Event) ExportRow {
+	row := ExportRow{
+		EventID:     event.ID,
+		EventDesc:   event.Description,
+		EventStatus: event.Status,
+		EventType:   event.EventType,
+		SourceCode:  event.SourceCode,
+		VenueDesc:   event.Venue,
+		Postcode:    event.Postcode,
+		CityCode:    event.CityCode,
+		CityDesc:    event.City,
+		CountryCode: event.CountryCode,
+	}
+	if event.GeoData.Latitude != 0 || event.GeoData.Longitude != 0 {
+		row.Latitude = strconv.FormatFloat(event.GeoData.Latitude, 'f', -1, 64)
+		row.Longitude = strconv.FormatFloat(event.GeoData.Longitude, 'f', -1, 64)
+	}
+	ids := make([]string, 0, len(event.Classes))
+	for id := range event.Classes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	classes := make([]string, len(ids))
+	for i, id := range ids {
+		classes[i] = id + ":" + event.Classes[id]
+	}
+	row.Classes = strings.Join(classes, ";")
+	row.CurrencyCode, row.MinSeatprice, row.MaxSeatprice, row.MinSurcharge, row.MaxSurcharge =
+		costRangeValues(&event.CostRange)
+	return row
+}
+
+// withPerformance returns a copy of the row with the performance columns
+// filled in.
+func (row ExportRow) withPerformance(perf *Performance) ExportRow {
+	row.PerfID = perf.ID
+	row.PerfName = perf.Name
+	if !perf.Datetime.IsZero() {
+		row.PerfDatetime = perf.Datetime.Format(time.RFC3339)
+	}
+	row.PerfCurrencyCode, row.PerfMinSeatprice, row.PerfMaxSeatprice, row.PerfMinSurcharge, row.PerfMaxSurcharge =
+		costRangeValues(&perf.CostRange)
+	return row
+}
+
+// RowWriter writes the rows of a catalogue export.
+type RowWriter interface {
+	WriteRow(row *ExportRow) error
+	// Flush writes any buffered rows. A CSV writer writes its header even
+	// when there were no rows.
+	Flush() error
+}
+
+type csvRowWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+// NewCSVRowWriter returns a RowWriter that writes CSV with a header row of
+// ExportColumns.
+func NewCSVRowWriter(w io.Writer) RowWriter {
+	return &csvRowWriter{w: csv.NewWriter(w)}
+}
+
+func (writer *csvRowWriter) writeHeader() error {
+	if writer.headerWritten {
+		return nil
+	}
+	writer.headerWritten = true
+	return writer.w.Write(ExportColumns)
+}
+
+func (writer *csvRowWriter) WriteRow(row *ExportRow) error {
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+	return writer.w.Write(row.Values())
+}
+
+func (writer *csvRowWriter) Flush() error {
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+	writer.w.Flush()
+	return writer.w.Error()
+}
+
+type jsonlRowWriter struct {
+	encoder *json.Encoder
+}
+
+// NewJSONLRowWriter returns a RowWriter that writes one JSON object per line,
+// keyed by ExportColumns.
+func NewJSONLRowWriter(w io.Writer) RowWriter {
+	return &jsonlRowWriter{encoder: json.NewEncoder(w)}
+}
+
+func (writer *jsonlRowWriter) WriteRow(row *ExportRow) error {
+	return writer.encoder.Encode(row)
+}
+
+func (writer *jsonlRowWriter) Flush() error {
+	return nil
+}
+
+// ExportError is reported when the performances of an event couldn't be
+// exported.
+type ExportError struct {
+	EventID string
+	Err     error
+}
+
+func (err *ExportError) Error() string {
+	return fmt.Sprintf("ticketswitch: exporting %s: %s", err.EventID, err.Err)
+}
+
+func (err *ExportError) Unwrap() error {
+	return err.Err
+}
+
+// ExportSummary describes a finished export.
+type ExportSummary struct {
+	Events       int
+	Performances int
+	Rows         int
+	// the events whose performances couldn't be fetched, in the order they
+	// were written.
+	Errors []*ExportError
+}
+
+// Exporter writes a snapshot of the catalogue, every event and its
+// performances, to a RowWriter.
+type Exporter struct {
+	// parameters for listing events. Cost ranges are always requested and
+	// the pagination is managed by the exporter.
+	Params ListEventsParams
+	// the number of events whose performances are fetched at once.
+	Concurrency int
+	// the number of events requested per page.
+	PageLength int
+	// called with each event whose performances couldn't be fetched.
+	OnError func(err *ExportError)
+
+	client API
+}
+
+// NewExporter returns an Exporter with the default settings.
+func NewExporter(client API) *Exporter {
+	return &Exporter{
+		Concurrency: DefaultExportConcurrency,
+		PageLength:  DefaultExportPageLength,
+		client:      client,
+	}
+}
+
+// eventPerformances fetches every page of an event's performances.
+func (exporter *Exporter) eventPerformances(ctx context.Context, eventID string) ([]Performance, error) {
+	params := &ListPerformancesParams{
+		UniversalParams:  exporter.Params.UniversalParams,
+		EventID:          eventID,
+		RequireCostRange: true,
+	}
+	perfs := make([]Performance, 0)
+	for {
+		results, err := exporter.client.ListPerformances(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		perfs = append(perfs, results.Performances...)
+		next, more := results.PagingStatus.NextPage()
+		if !more {
+			return perfs, nil
+		}
+		params.PageNumber = next
+	}
+}
+
+// exportedEvent is the result of fetching the performances of an event.
+type exportedEvent struct {
+	perfs []Performance
+	err   error
+}
+
+// Export walks every page of events and writes a row for each performance.
+// Performances are fetched for several events at once, but rows are written
+// in the order the API returned the events. An error fetching the
+// performances of an event is reported in the event's row, passed to OnError
+// and recorded in the summary. Errors listing events or writing rows stop the
+// export.
+func (exporter *Exporter) Export(ctx context.Context, w RowWriter) (*ExportSummary, error) {
+	summary := &ExportSummary{Errors: make([]*ExportError, 0)}
+	params := exporter.Params
+	params.CostRange = true
+	params.PageNumber = 0
+	if exporter.PageLength > 0 {
+		params.PageLength = exporter.PageLength
+	}
+	concurrency := exporter.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	for {
+		results, err := exporter.client.ListEvents(ctx, &params)
+		if err != nil {
+			return summary, err
+		}
+
+		fetched := make([]exportedEvent, len(results.Events))
+		semaphore := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for i := range results.Events {
+			if results.Events[i].HasNoPerformances {
+				continue
+			}
+			wg.Add(1)
+			semaphore <- struct{}{}
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-semaphore }()
+				perfs, err := exporter.eventPerformances(ctx, results.Events[i].ID)
+				fetched[i] = exportedEvent{perfs: perfs, err: err}
+			}(i)
+		}
+		wg.Wait()
+		if ctx.Err() != nil {
+			return summary, ctx.Err()
+		}
+
+		for i := range results.Events {
+			event := &results.Events[i]
+			row := newExportRow(event)
+			summary.Events++
+			if fetched[i].err != nil {
+				exportErr := &ExportError{EventID: event.ID, Err: fetched[i].err}
+				summary.Errors = append(summary.Errors, exportErr)
+				if exporter.OnError != nil {
+					exporter.OnError(exportErr)
+				}
+				row.Error = fetched[i].err.Error()
+			}
+			if len(fetched[i].perfs) == 0 {
+				if err := w.WriteRow(&row); err != nil {
+					return summary, err
+				}
+				summary.Rows++
+				continue
+			}
+			for j := range fetched[i].perfs {
+				perfRow := row.withPerformance(&fetched[i].perfs[j])
+				if err := w.WriteRow(&perfRow); err != nil {
+					return summary, err
+				}
+				summary.Rows++
+				summary.Performances++
+			}
+		}
+
+		next, more := results.PagingStatus.NextPage()
+		if !more {
+			break
+		}
+		params.PageNumber = next
+	}
+
+	return summary, w.Flush()
+}
@@ -0,0 +1,149 @@
+package ticketswitch
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newExportServer(t *testing.T) *httptest.Server {
+	perfs, err := os.ReadFile("testdata/performances_avail_details.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := map[string]string{
+		"": `{"results": {"event": [
+			{"event_id": "6IF", "event_desc": "Matthew Bourne's Nutcracker TEST", "event_status": "live",
+			 "venue_desc": "Sadler's Wells", "city_code": "london-uk", "city_desc": "London", "country_code": "uk",
+			 "geo_data": {"latitude": 51.52961137, "longitude": -0.10601562},
+			 "classes": {"dance": "Dance", "ballet": "Ballet"},
+			 "cost_range": {"currency_code": "gbp", "min_seatprice": 15, "max_seatprice": 55, "min_surcharge": 0, "max_surcharge": 2.5}},
+			{"event_id": "BAD", "event_desc": "Broken"}
+		], "paging_status": {"page_number": 1, "pages_remaining": 1}}}`,
+		"2": `{"results": {"event": [
+			{"event_id": "6KU", "event_desc": "Attraction", "has_no_perfs": true}
+		], "paging_status": {"page_number": 2, "pages_remaining": 0}}}`,
+	}
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/f13/events.v1":
+				assert.Equal(t, "1", r.URL.Query().Get("req_cost_range"))
+				assert.Equal(t, "10", r.URL.Query().Get("page_len"))
+				w.Write([]byte(pages[r.URL.Query().Get("page_no")]))
+			case "/f13/performances.v1":
+				assert.Equal(t, "1", r.URL.Query().Get("req_cost_range"))
+				if r.URL.Query().Get("event_id") == "6IF" {
+					w.Write(perfs)
+					return
+				}
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error_code": 8, "error_desc": "bad event"}`))
+			default:
+				t.Errorf("unexpected request to %s", r.URL.Path)
+			}
+		}))
+}
+
+func TestExporter_Export_csv(t *testing.T) {
+	server := newExportServer(t)
+	defer server.Close()
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+
+	var reported []string
+	exporter := NewExporter(client)
+	exporter.PageLength = 10
+	exporter.OnError = func(err *ExportError) { reported = append(reported, err.EventID) }
+
+	var buf bytes.Buffer
+	summary, err := exporter.Export(context.Background(), NewCSVRowWriter(&buf))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 3, summary.Events)
+	assert.Equal(t, 2, summary.Performances)
+	assert.Equal(t, 4, summary.Rows)
+	if assert.Len(t, summary.Errors, 1) {
+		assert.Equal(t, "BAD", summary.Errors[0].EventID)
+	}
+	assert.Equal(t, []string{"BAD"}, reported)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if !assert.Nil(t, err) || !assert.Len(t, records, 5) {
+		return
+	}
+	assert.Equal(t, ExportColumns, records[0])
+	row := make(map[string]string)
+	for i, column := range ExportColumns {
+		row[column] = records[1][i]
+	}
+	assert.Equal(t, "6IF", row["event_id"])
+	assert.Equal(t, "51.52961137", row["latitude"])
+	assert.Equal(t, "ballet:Ballet;dance:Dance", row["classes"])
+	assert.Equal(t, "15", row["min_seatprice"])
+	assert.Equal(t, "2.5", row["max_surcharge"])
+	assert.Equal(t, "6IF-C5O", row["perf_id"])
+	assert.Equal(t, "2026-12-12T19:30:00Z", row["perf_datetime"])
+	assert.Equal(t, "", row["error"])
+
+	assert.Equal(t, "6IF-C5P", records[2][18])
+	assert.Equal(t, "BAD", records[3][0])
+	assert.Equal(t, "", records[3][18])
+	assert.Contains(t, records[3][len(ExportColumns)-1], "bad event")
+	assert.Equal(t, "6KU", records[4][0])
+	assert.Equal(t, "", records[4][13])
+}
+
+func TestExporter_Export_jsonl(t *testing.T) {
+	server := newExportServer(t)
+	defer server.Close()
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+
+	exporter := NewExporter(client)
+	exporter.PageLength = 10
+	exporter.Concurrency = 1
+
+	var buf bytes.Buffer
+	_, err := exporter.Export(context.Background(), NewJSONLRowWriter(&buf))
+	if !assert.Nil(t, err) {
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 4) {
+		var row map[string]string
+		assert.Nil(t, json.Unmarshal([]byte(lines[0]), &row))
+		assert.Len(t, row, len(ExportColumns))
+		for _, column := range ExportColumns {
+			assert.Contains(t, row, column)
+		}
+		assert.Equal(t, "6IF-C5O", row["perf_id"])
+	}
+}
+
+func TestExporter_Export_list_error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error_code": 1, "error_desc": "oops"}`))
+		}))
+	defer server.Close()
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+
+	var buf bytes.Buffer
+	_, err := NewExporter(client).Export(context.Background(), NewCSVRowWriter(&buf))
+	assert.NotNil(t, err)
+}
+
+func TestCSVRowWriter_empty(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, NewCSVRowWriter(&buf).Flush())
+	assert.Equal(t, strings.Join(ExportColumns, ",")+"\n", buf.String())
+}
@@ -0,0 +1,205 @@
+// Package gateway serves a credential-free JSON API over the ticketswitch API
+// for browser and mobile clients.
+//
+// A Gateway is an http.Handler that holds the API credentials itself and
+// exposes a small, stable set of routes for searching events, checking
+// availability and making and releasing reservations. Responses only contain
+// the fields declared by the types in this package. Purchases and
+// cancellations are deliberately not exposed: take payment and purchase from
+// a trusted backend.
+//
+//	handler := gateway.New(client)
+//	handler.Tenant = func(r *http.Request) (string, error) { ... }
+//	http.Handle("/tickets/", http.StripPrefix("/tickets", handler))
+//
+// The routes are described by the OpenAPI document served at /openapi.json.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+)
+
+// DefaultTrackingHeader is the header session tracking ids are read from.
+const DefaultTrackingHeader = "X-Request-Id"
+
+// DefaultMaxPageLength is the largest page of results a client can ask for.
+const DefaultMaxPageLength = 100
+
+// maxBodySize is the largest request body accepted.
+const maxBodySize = 64 << 10
+
+// Gateway is an http.Handler serving the gateway's JSON API.
+type Gateway struct {
+	// returns the sub user requests are made as, for example from the
+	// authenticated user or the host name. An empty sub user uses the
+	// client's. Requests are refused with 403 Forbidden when it returns an
+	// error. When nil every request uses the client's sub user.
+	Tenant func(r *http.Request) (string, error)
+	// the header a session tracking id is read from and echoed in.
+	TrackingHeader string
+	// the largest page of results a client can ask for.
+	MaxPageLength int
+	// called with errors that are hidden from clients, such as failures to
+	// reach the API.
+	OnError func(r *http.Request, err error)
+
+	api    ticketswitch.API
+	routes []*route
+}
+
+// New returns a Gateway that makes its calls with the API.
+func New(api ticketswitch.API) *Gateway {
+	gateway := &Gateway{
+		TrackingHeader: DefaultTrackingHeader,
+		MaxPageLength:  DefaultMaxPageLength,
+		api:            api,
+	}
+	gateway.routes = gateway.newRoutes()
+	return gateway
+}
+
+// requestError is an error caused by the request, reported to the client as
+// is.
+type requestError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (err *requestError) Error() string {
+	return err.message
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &requestError{status: http.StatusBadRequest, code: "bad_request", message: fmt.Sprintf(format, args...)}
+}
+
+// request is a request matched to a route.
+type request struct {
+	*http.Request
+	// the values of the path parameters.
+	path map[string]string
+}
+
+// queryInt returns an integer query parameter, or zero when it's missing.
+func (r *request) queryInt(name string) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, badRequest("%s must be a positive integer", name)
+	}
+	return n, nil
+}
+
+// ServeHTTP serves a request.
+func (gateway *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if gateway.TrackingHeader != "" {
+		if id := r.Header.Get(gateway.TrackingHeader); id != "" {
+			ctx = ticketswitch.SetSessionTrackingID(ctx, id)
+			w.Header().Set(gateway.TrackingHeader, id)
+		}
+	}
+
+	route, path, allowed := gateway.match(r)
+	if route == nil {
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			gateway.writeError(w, r, &requestError{
+				status: http.StatusMethodNotAllowed, code: "method_not_allowed", message: "method not allowed",
+			})
+			return
+		}
+		gateway.writeError(w, r, &requestError{status: http.StatusNotFound, code: "not_found", message: "no such route"})
+		return
+	}
+
+	if gateway.Tenant != nil {
+		subUser, err := gateway.Tenant(r)
+		if err != nil {
+			gateway.writeError(w, r, &requestError{status: http.StatusForbidden, code: "forbidden", message: err.Error()})
+			return
+		}
+		if subUser != "" {
+			ctx = ticketswitch.SetSubUser(ctx, subUser)
+		}
+	}
+
+	result, err := route.handle(ctx, &request{Request: r.WithContext(ctx), path: path})
+	if err != nil {
+		gateway.writeError(w, r, err)
+		return
+	}
+	status := route.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, result)
+}
+
+// match finds the route for a request. When the path matches routes for
+// other methods only, those methods are returned.
+func (gateway *Gateway) match(r *http.Request) (*route, map[string]string, []string) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	var allowed []string
+	for _, route := range gateway.routes {
+		path, ok := route.match(segments)
+		if !ok {
+			continue
+		}
+		if route.method == r.Method || (route.method == http.MethodGet && r.Method == http.MethodHead) {
+			return route, path, nil
+		}
+		allowed = append(allowed, route.method)
+	}
+	return nil, nil, allowed
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError maps an error to a status and writes it.
+func (gateway *Gateway) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var reqErr *requestError
+	var apiErr ticketswitch.Error
+	hidden := false
+	switch {
+	case errors.As(err, &reqErr):
+	case errors.Is(err, ticketswitch.ErrEventNotFound):
+		reqErr = &requestError{status: http.StatusNotFound, code: "not_found", message: "event not found"}
+	case errors.As(err, &apiErr) && apiErr.RateLimited:
+		if apiErr.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(apiErr.RetryAfter.Seconds())))
+		}
+		reqErr = &requestError{status: http.StatusTooManyRequests, code: "rate_limited", message: "too many requests"}
+	case errors.As(err, &apiErr) && (apiErr.AuthenticationError || apiErr.CallbackGoneError):
+		hidden = true
+		reqErr = &requestError{status: http.StatusBadGateway, code: "upstream_error", message: "the ticketing API failed"}
+	case errors.As(err, &apiErr):
+		reqErr = &requestError{status: http.StatusUnprocessableEntity, code: "rejected", message: apiErr.Description}
+	case errors.Is(err, context.DeadlineExceeded):
+		hidden = true
+		reqErr = &requestError{status: http.StatusGatewayTimeout, code: "timeout", message: "the ticketing API timed out"}
+	default:
+		hidden = true
+		reqErr = &requestError{status: http.StatusBadGateway, code: "upstream_error", message: "the ticketing API failed"}
+	}
+	if hidden && gateway.OnError != nil {
+		gateway.OnError(r, err)
+	}
+	writeJSON(w, reqErr.status, ErrorResponse{Error: ErrorDetail{Code: reqErr.code, Message: reqErr.message}})
+}
@@ -0,0 +1,299 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/ingresso-group/goticketswitch.v2/mock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// serve sends a request to the gateway and decodes the JSON response.
+func serve(gateway *Gateway, method, target, body string, headers ...string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	var r *http.Request
+	if body != "" {
+		r = httptest.NewRequest(method, target, strings.NewReader(body))
+	} else {
+		r = httptest.NewRequest(method, target, nil)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, r)
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &decoded)
+	return w, decoded
+}
+
+func errorCode(body map[string]interface{}) interface{} {
+	if detail, ok := body["error"].(map[string]interface{}); ok {
+		return detail["code"]
+	}
+	return nil
+}
+
+func TestGateway_listEvents(t *testing.T) {
+	api := &mock.API{
+		ListEventsFunc: func(ctx context.Context, params *ticketswitch.ListEventsParams) (*ticketswitch.ListEventsResults, error) {
+			return &ticketswitch.ListEventsResults{
+				PagingStatus: ticketswitch.PagingStatus{PageNumber: 1, PageLength: 50, PagesRemaining: 2, TotalResults: 150},
+				Events: []ticketswitch.Event{{
+					ID: "6IF", Description: "Nutcracker", SourceCode: "ext_test0",
+					GeoData:   ticketswitch.GeoData{Latitude: 51.5, Longitude: -0.12},
+					CostRange: ticketswitch.CostRange{CurrencyCode: "gbp", MinSeatPrice: decimal.RequireFromString("15")},
+				}},
+			}, nil
+		},
+	}
+	gateway := New(api)
+	gateway.MaxPageLength = 50
+
+	w, body := serve(gateway, http.MethodGet, "/events?keywords=nut,+cracker&country=uk&sort=alphabetic&page=1&page_length=500", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	if calls := api.CallsTo("ListEvents"); assert.Len(t, calls, 1) {
+		params := calls[0].Args[0].(*ticketswitch.ListEventsParams)
+		assert.Equal(t, []string{"nut", "cracker"}, params.Keywords)
+		assert.Equal(t, "uk", params.CountryCode)
+		assert.Equal(t, ticketswitch.SortAlphabetic, params.SortOrder)
+		assert.Equal(t, 1, params.PageNumber)
+		assert.Equal(t, 50, params.PageLength)
+		assert.True(t, params.CostRange)
+	}
+
+	events := body["events"].([]interface{})
+	if assert.Len(t, events, 1) {
+		event := events[0].(map[string]interface{})
+		assert.Equal(t, "6IF", event["event_id"])
+		assert.Equal(t, 51.5, event["latitude"])
+		assert.Equal(t, "15", event["cost_range"].(map[string]interface{})["min_seatprice"])
+		assert.NotContains(t, event, "source_code")
+	}
+	assert.Equal(t, float64(150), body["paging"].(map[string]interface{})["total_results"])
+
+	w, body = serve(gateway, http.MethodGet, "/events?sort=sideways", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "bad_request", errorCode(body))
+}
+
+func TestGateway_tenant_and_tracking(t *testing.T) {
+	var ctx context.Context
+	api := &mock.API{
+		GetEventFunc: func(c context.Context, eventID string, params *ticketswitch.UniversalParams) (*ticketswitch.Event, error) {
+			ctx = c
+			return &ticketswitch.Event{ID: eventID}, nil
+		},
+	}
+	gateway := New(api)
+	gateway.Tenant = func(r *http.Request) (string, error) {
+		switch r.Host {
+		case "web.example.com":
+			return "web", nil
+		case "example.com":
+			return "", nil
+		}
+		return "", errors.New("unknown tenant")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/events/6IF", nil)
+	r.Host = "web.example.com"
+	r.Header.Set("X-Request-Id", "abc123")
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "abc123", w.Header().Get("X-Request-Id"))
+	subUser, _ := ticketswitch.GetSubUser(ctx)
+	assert.Equal(t, "web", subUser)
+	trackingID, _ := ticketswitch.GetSessionTrackingID(ctx)
+	assert.Equal(t, "abc123", trackingID)
+
+	r = httptest.NewRequest(http.MethodGet, "/events/6IF", nil)
+	r.Host = "example.com"
+	gateway.ServeHTTP(httptest.NewRecorder(), r)
+	_, ok := ticketswitch.GetSubUser(ctx)
+	assert.False(t, ok)
+
+	r = httptest.NewRequest(http.MethodGet, "/events/6IF", nil)
+	r.Host = "evil.example.com"
+	w = httptest.NewRecorder()
+	gateway.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Len(t, api.Calls(), 2)
+}
+
+func TestGateway_errors(t *testing.T) {
+	var hidden []error
+	api := &mock.API{
+		GetEventFunc: func(ctx context.Context, eventID string, params *ticketswitch.UniversalParams) (*ticketswitch.Event, error) {
+			switch eventID {
+			case "missing":
+				return nil, ticketswitch.ErrEventNotFound
+			case "limited":
+				return nil, ticketswitch.Error{RateLimited: true, RetryAfter: 30 * time.Second}
+			case "auth":
+				return nil, ticketswitch.Error{Code: 3, Description: "bad credentials", AuthenticationError: true}
+			case "slow":
+				return nil, context.DeadlineExceeded
+			case "rejected":
+				return nil, ticketswitch.Error{Code: 8, Description: "event is dead"}
+			}
+			return nil, errors.New("connection refused")
+		},
+		GetAvailabilityFunc: func(ctx context.Context, perf string, params *ticketswitch.GetAvailabilityParams) (*ticketswitch.AvailabilityResult, error) {
+			return &ticketswitch.AvailabilityResult{BackendIsDown: true}, nil
+		},
+	}
+	gateway := New(api)
+	gateway.OnError = func(r *http.Request, err error) { hidden = append(hidden, err) }
+
+	for _, test := range []struct {
+		target string
+		status int
+		code   string
+	}{
+		{"/events/missing", http.StatusNotFound, "not_found"},
+		{"/events/limited", http.StatusTooManyRequests, "rate_limited"},
+		{"/events/auth", http.StatusBadGateway, "upstream_error"},
+		{"/events/slow", http.StatusGatewayTimeout, "timeout"},
+		{"/events/rejected", http.StatusUnprocessableEntity, "rejected"},
+		{"/events/down", http.StatusBadGateway, "upstream_error"},
+		{"/performances/6IF-A1/availability", http.StatusServiceUnavailable, "backend_unavailable"},
+		{"/performances/6IF-A1/availability?seats=two", http.StatusBadRequest, "bad_request"},
+		{"/nowhere", http.StatusNotFound, "not_found"},
+	} {
+		w, body := serve(gateway, http.MethodGet, test.target, "")
+		assert.Equal(t, test.status, w.Code, test.target)
+		assert.Equal(t, test.code, errorCode(body), test.target)
+		if test.code == "rate_limited" {
+			assert.Equal(t, "30", w.Header().Get("Retry-After"))
+		}
+		if test.code == "upstream_error" {
+			assert.Equal(t, "the ticketing API failed", body["error"].(map[string]interface{})["message"])
+		}
+	}
+	assert.Len(t, hidden, 3)
+
+	w, body := serve(gateway, http.MethodPut, "/events/6IF", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET", w.Header().Get("Allow"))
+	assert.Equal(t, "method_not_allowed", errorCode(body))
+}
+
+func TestGateway_makeReservation(t *testing.T) {
+	api := &mock.API{
+		MakeReservationFunc: func(ctx context.Context, params *ticketswitch.MakeReservationParams) (*ticketswitch.ReservationResult, error) {
+			return &ticketswitch.ReservationResult{
+				Status:               "reserved",
+				MinutesLeftOnReserve: 15,
+				Trolley: ticketswitch.Trolley{
+					TransactionUUID: "abc",
+					Bundles: []ticketswitch.Bundle{{
+						CurrencyCode: "gbp",
+						TotalCost:    decimal.RequireFromString("76.5"),
+						Orders: []ticketswitch.Order{{
+							ItemNumber: 1, TicketTypeCode: "STALLS", TotalNumberOfSeats: 2,
+							UserCommission: ticketswitch.UserCommission{IncVat: decimal.RequireFromString("5")},
+						}},
+					}},
+				},
+			}, nil
+		},
+	}
+	gateway := New(api)
+
+	w, body := serve(gateway, http.MethodPost, "/reservations",
+		`{"performance_id": "7AB-5", "ticket_type_code": "STALLS", "price_band_code": "A/pool", "seats": 2, "discounts": ["ADULT", "CHILD"]}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "abc", body["transaction_uuid"])
+	assert.Equal(t, "76.5", body["total"])
+	assert.Equal(t, "gbp", body["currency_code"])
+	if orders, ok := body["orders"].([]interface{}); assert.True(t, ok) && assert.Len(t, orders, 1) {
+		assert.NotContains(t, orders[0], "user_commission")
+	}
+	if calls := api.CallsTo("MakeReservation"); assert.Len(t, calls, 1) {
+		params := calls[0].Args[0].(*ticketswitch.MakeReservationParams)
+		assert.Equal(t, 2, params.NumberOfSeats)
+		assert.Equal(t, []string{"ADULT", "CHILD"}, params.Discounts)
+	}
+
+	for _, request := range []string{
+		`{"performance_id": "7AB-5"}`,
+		`{"performance_id": "7AB-5", "ticket_type_code": "STALLS", "price_band_code": "A/pool"}`,
+		`{"performance_id": "7AB-5", "ticket_type_code": "STALLS", "price_band_code": "A/pool", "seats": 2, "discounts": ["ADULT"]}`,
+		`{"performance_id": "7AB-5", "ticket_type_code": "STALLS", "price_band_code": "A/pool", "seats": 1, "send_method": "POST"}`,
+		`{"performance_id": "7AB-5", "ticket_type_code": "STALLS", "price_band_code": "A/pool", "seats": 1, "user_commission": true}`,
+		`not json`,
+	} {
+		w, body = serve(gateway, http.MethodPost, "/reservations", request)
+		assert.Equal(t, http.StatusBadRequest, w.Code, request)
+		assert.Equal(t, "bad_request", errorCode(body), request)
+	}
+	assert.Len(t, api.Calls(), 1)
+}
+
+func TestGateway_transactions(t *testing.T) {
+	api := &mock.API{
+		GetStatusFunc: func(ctx context.Context, params *ticketswitch.TransactionParams) (*ticketswitch.StatusResult, error) {
+			return &ticketswitch.StatusResult{
+				Status:           "purchased",
+				PurchaseDatetime: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+				Customer:         ticketswitch.Customer{FirstName: "Fred"},
+				Trolley:          ticketswitch.Trolley{TransactionUUID: params.TransactionUUID},
+			}, nil
+		},
+		ReleaseReservationFunc: func(ctx context.Context, params *ticketswitch.TransactionParams) (bool, error) {
+			return true, nil
+		},
+	}
+	gateway := New(api)
+
+	w, body := serve(gateway, http.MethodGet, "/transactions/abc", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "abc", body["transaction_uuid"])
+	assert.Equal(t, "2026-10-18T12:00:00Z", body["purchased_at"])
+	assert.NotContains(t, body, "reserved_at")
+	assert.NotContains(t, w.Body.String(), "Fred")
+
+	w, body = serve(gateway, http.MethodDelete, "/transactions/abc", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, body["released"])
+}
+
+func TestGateway_OpenAPI(t *testing.T) {
+	gateway := New(&mock.API{})
+	w, body := serve(gateway, http.MethodGet, "/openapi.json", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3.0.3", body["openapi"])
+
+	paths := body["paths"].(map[string]interface{})
+	for _, route := range gateway.routes {
+		path, ok := paths[route.path].(map[string]interface{})
+		if assert.True(t, ok, route.path) {
+			assert.Contains(t, path, strings.ToLower(route.method), route.path)
+		}
+	}
+
+	reserve := paths["/reservations"].(map[string]interface{})["post"].(map[string]interface{})
+	assert.Equal(t, "makeReservation", reserve["operationId"])
+	assert.Contains(t, reserve["responses"], "201")
+
+	schemas := body["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	for _, name := range []string{"EventList", "Event", "CostRange", "Transaction", "Order", "ReservationRequest", "ErrorResponse"} {
+		assert.Contains(t, schemas, name)
+	}
+	event := schemas["Event"].(map[string]interface{})
+	properties := event["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"$ref": "#/components/schemas/CostRange"}, properties["cost_range"])
+	assert.NotContains(t, event["required"], "cost_range")
+	assert.Equal(t, "decimal", schemas["CostRange"].(map[string]interface{})["properties"].(map[string]interface{})["min_seatprice"].(map[string]interface{})["format"])
+}
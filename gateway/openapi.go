@@ -0,0 +1,165 @@
+package gateway
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Version is the version of the gateway's API in its OpenAPI document.
+const Version = "1.0.0"
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+)
+
+// OpenAPI returns an OpenAPI 3 document describing the gateway's routes,
+// ready to be encoded as JSON.
+func (gateway *Gateway) OpenAPI() map[string]interface{} {
+	schemas := make(map[string]interface{})
+	schemas["ErrorResponse"] = schemaOf(reflect.TypeOf(ErrorResponse{}), schemas)
+
+	paths := make(map[string]interface{})
+	for _, route := range gateway.routes {
+		operation := map[string]interface{}{
+			"operationId": route.id,
+			"summary":     route.summary,
+		}
+
+		parameters := make([]interface{}, 0)
+		for _, name := range route.pathParameters() {
+			parameters = append(parameters, map[string]interface{}{
+				"name": name, "in": "path", "required": true,
+				"schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, param := range route.query {
+			parameters = append(parameters, map[string]interface{}{
+				"name": param.name, "in": "query", "required": param.required,
+				"description": param.description,
+				"schema":      map[string]interface{}{"type": param.kind},
+			})
+		}
+		parameters = append(parameters, map[string]interface{}{
+			"name": gateway.TrackingHeader, "in": "header", "required": false,
+			"description": "session tracking id passed on to the ticketing API",
+			"schema":      map[string]interface{}{"type": "string"},
+		})
+		operation["parameters"] = parameters
+
+		if route.request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(schemaOf(reflect.TypeOf(route.request), schemas)),
+			}
+		}
+
+		status := route.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		errorResponse := map[string]interface{}{
+			"description": "error",
+			"content":     jsonContent(map[string]interface{}{"$ref": "#/components/schemas/ErrorResponse"}),
+		}
+		operation["responses"] = map[string]interface{}{
+			strconv.Itoa(status): map[string]interface{}{
+				"description": http.StatusText(status),
+				"content":     jsonContent(schemaOf(reflect.TypeOf(route.response), schemas)),
+			},
+			"default": errorResponse,
+		}
+
+		path, ok := paths[route.path].(map[string]interface{})
+		if !ok {
+			path = make(map[string]interface{})
+			paths[route.path] = path
+		}
+		path[strings.ToLower(route.method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "ticketswitch gateway",
+			"version": Version,
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+// schemaOf returns the schema of a type. Named structs are added to schemas
+// and referenced.
+func schemaOf(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case decimalType:
+		return map[string]interface{}{"type": "string", "format": "decimal"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return map[string]interface{}{"type": "object"}
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+	default:
+		return map[string]interface{}{}
+	}
+
+	ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	if _, ok := schemas[t.Name()]; ok {
+		return ref
+	}
+	// reserve the name before recursing so recursive types terminate.
+	schemas[t.Name()] = nil
+
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schemaOf(field.Type, schemas)
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	schemas[t.Name()] = schema
+	return ref
+}
@@ -0,0 +1,357 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+)
+
+// parameter is a query parameter of a route.
+type parameter struct {
+	name string
+	// the OpenAPI type, string or integer.
+	kind        string
+	description string
+	required    bool
+}
+
+// route is an operation of the gateway. The OpenAPI document is generated
+// from the routes.
+type route struct {
+	method string
+	// the path, with parameters in braces, e.g. /events/{event_id}.
+	path    string
+	id      string
+	summary string
+	query   []parameter
+	// the type of the request body, if any.
+	request interface{}
+	// the type of the response body.
+	response interface{}
+	// the status of a successful response, defaults to 200.
+	status int
+	handle func(ctx context.Context, r *request) (interface{}, error)
+}
+
+// match checks if the path segments match the route, returning the path
+// parameters.
+func (route *route) match(segments []string) (map[string]string, bool) {
+	pattern := strings.Split(strings.Trim(route.path, "/"), "/")
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	values := make(map[string]string)
+	for i, part := range pattern {
+		if strings.HasPrefix(part, "{") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			values[strings.Trim(part, "{}")] = segments[i]
+			continue
+		}
+		if part != segments[i] {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// pathParameters returns the names of the route's path parameters.
+func (route *route) pathParameters() []string {
+	names := make([]string, 0)
+	for _, part := range strings.Split(route.path, "/") {
+		if strings.HasPrefix(part, "{") {
+			names = append(names, strings.Trim(part, "{}"))
+		}
+	}
+	return names
+}
+
+var pageParameters = []parameter{
+	{name: "page", kind: "integer", description: "the page to return, starting from 0"},
+	{name: "page_length", kind: "integer", description: "the number of results per page"},
+}
+
+// sortOrders are the event sort orders clients may ask for.
+var sortOrders = []string{
+	ticketswitch.SortMostPopular,
+	ticketswitch.SortAlphabetic,
+	ticketswitch.SortCostAscending,
+	ticketswitch.SortCostDescending,
+	ticketswitch.SortCriticRating,
+	ticketswitch.SortRecent,
+	ticketswitch.SortLastSale,
+}
+
+func (gateway *Gateway) newRoutes() []*route {
+	return []*route{
+		{
+			method: http.MethodGet, path: "/events", id: "listEvents",
+			summary: "Search for events",
+			query: append([]parameter{
+				{name: "keywords", kind: "string", description: "comma separated keywords"},
+				{name: "country", kind: "string", description: "ISO 3166-1 country code"},
+				{name: "city", kind: "string", description: "city code"},
+				{name: "sort", kind: "string", description: "one of " + strings.Join(sortOrders, ", ")},
+			}, pageParameters...),
+			response: EventList{},
+			handle:   gateway.listEvents,
+		},
+		{
+			method: http.MethodGet, path: "/events/{event_id}", id: "getEvent",
+			summary:  "Get an event",
+			response: Event{},
+			handle:   gateway.getEvent,
+		},
+		{
+			method: http.MethodGet, path: "/events/{event_id}/performances", id: "listPerformances",
+			summary:  "List the performances of an event",
+			query:    pageParameters,
+			response: PerformanceList{},
+			handle:   gateway.listPerformances,
+		},
+		{
+			method: http.MethodGet, path: "/performances/{performance_id}/availability", id: "getAvailability",
+			summary: "Get the live availability of a performance",
+			query: []parameter{
+				{name: "seats", kind: "integer", description: "only show price bands with this many seats together"},
+			},
+			response: Availability{},
+			handle:   gateway.getAvailability,
+		},
+		{
+			method: http.MethodGet, path: "/performances/{performance_id}/discounts", id: "getDiscounts",
+			summary: "List the discounts of a price band",
+			query: []parameter{
+				{name: "ticket_type", kind: "string", description: "ticket type code", required: true},
+				{name: "price_band", kind: "string", description: "price band code", required: true},
+			},
+			response: DiscountList{},
+			handle:   gateway.getDiscounts,
+		},
+		{
+			method: http.MethodGet, path: "/performances/{performance_id}/send-methods", id: "getSendMethods",
+			summary:  "List the send methods of a performance",
+			response: SendMethodList{},
+			handle:   gateway.getSendMethods,
+		},
+		{
+			method: http.MethodPost, path: "/reservations", id: "makeReservation",
+			summary:  "Reserve seats",
+			request:  ReservationRequest{},
+			response: Transaction{},
+			status:   http.StatusCreated,
+			handle:   gateway.makeReservation,
+		},
+		{
+			method: http.MethodGet, path: "/transactions/{transaction_uuid}", id: "getTransaction",
+			summary:  "Get the status of a transaction",
+			response: Transaction{},
+			handle:   gateway.getTransaction,
+		},
+		{
+			method: http.MethodDelete, path: "/transactions/{transaction_uuid}", id: "releaseReservation",
+			summary:  "Release a reservation",
+			response: Release{},
+			handle:   gateway.releaseReservation,
+		},
+		{
+			method: http.MethodGet, path: "/openapi.json", id: "getOpenAPI",
+			summary:  "Get this document",
+			response: map[string]interface{}{},
+			handle: func(ctx context.Context, r *request) (interface{}, error) {
+				return gateway.OpenAPI(), nil
+			},
+		},
+	}
+}
+
+// pagination reads the page parameters.
+func (gateway *Gateway) pagination(r *request) (ticketswitch.PaginationParams, error) {
+	var params ticketswitch.PaginationParams
+	var err error
+	if params.PageNumber, err = r.queryInt("page"); err != nil {
+		return params, err
+	}
+	if params.PageLength, err = r.queryInt("page_length"); err != nil {
+		return params, err
+	}
+	if gateway.MaxPageLength > 0 && (params.PageLength == 0 || params.PageLength > gateway.MaxPageLength) {
+		params.PageLength = gateway.MaxPageLength
+	}
+	return params, nil
+}
+
+func (gateway *Gateway) listEvents(ctx context.Context, r *request) (interface{}, error) {
+	query := r.URL.Query()
+	params := &ticketswitch.ListEventsParams{
+		UniversalParams: ticketswitch.UniversalParams{CostRange: true},
+		CountryCode:     query.Get("country"),
+		CityCode:        query.Get("city"),
+	}
+	for _, keyword := range strings.Split(query.Get("keywords"), ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			params.Keywords = append(params.Keywords, keyword)
+		}
+	}
+	if sort := query.Get("sort"); sort != "" {
+		found := false
+		for _, order := range sortOrders {
+			found = found || order == sort
+		}
+		if !found {
+			return nil, badRequest("unknown sort order %q", sort)
+		}
+		params.SortOrder = sort
+	}
+	var err error
+	if params.PaginationParams, err = gateway.pagination(r); err != nil {
+		return nil, err
+	}
+
+	results, err := gateway.api.ListEvents(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	list := EventList{Events: make([]Event, 0, len(results.Events)), Paging: newPaging(&results.PagingStatus)}
+	for i := range results.Events {
+		list.Events = append(list.Events, newEvent(&results.Events[i]))
+	}
+	return list, nil
+}
+
+func (gateway *Gateway) getEvent(ctx context.Context, r *request) (interface{}, error) {
+	event, err := gateway.api.GetEvent(ctx, r.path["event_id"], &ticketswitch.UniversalParams{CostRange: true})
+	if err != nil {
+		return nil, err
+	}
+	return newEvent(event), nil
+}
+
+func (gateway *Gateway) listPerformances(ctx context.Context, r *request) (interface{}, error) {
+	params := &ticketswitch.ListPerformancesParams{
+		UniversalParams: ticketswitch.UniversalParams{CostRange: true},
+		EventID:         r.path["event_id"],
+	}
+	var err error
+	if params.PaginationParams, err = gateway.pagination(r); err != nil {
+		return nil, err
+	}
+	results, err := gateway.api.ListPerformances(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	list := PerformanceList{
+		Performances: make([]Performance, 0, len(results.Performances)),
+		Paging:       newPaging(&results.PagingStatus),
+	}
+	for i := range results.Performances {
+		list.Performances = append(list.Performances, newPerformance(&results.Performances[i]))
+	}
+	return list, nil
+}
+
+func (gateway *Gateway) getAvailability(ctx context.Context, r *request) (interface{}, error) {
+	seats, err := r.queryInt("seats")
+	if err != nil {
+		return nil, err
+	}
+	perfID := r.path["performance_id"]
+	result, err := gateway.api.GetAvailability(ctx, perfID, &ticketswitch.GetAvailabilityParams{NumberOfSeats: seats})
+	if err != nil {
+		return nil, err
+	}
+	if result.BackendIsDown || result.BackendIsBroken || result.BackendThrottleFailed {
+		return nil, &requestError{
+			status:  http.StatusServiceUnavailable,
+			code:    "backend_unavailable",
+			message: "availability can't be checked right now",
+		}
+	}
+	return newAvailability(perfID, result), nil
+}
+
+func (gateway *Gateway) getDiscounts(ctx context.Context, r *request) (interface{}, error) {
+	query := r.URL.Query()
+	ticketType, priceBand := query.Get("ticket_type"), query.Get("price_band")
+	if ticketType == "" || priceBand == "" {
+		return nil, badRequest("ticket_type and price_band are required")
+	}
+	result, err := gateway.api.GetDiscounts(ctx, r.path["performance_id"], ticketType, priceBand, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newDiscountList(result), nil
+}
+
+func (gateway *Gateway) getSendMethods(ctx context.Context, r *request) (interface{}, error) {
+	result, err := gateway.api.GetSendMethods(ctx, r.path["performance_id"], nil)
+	if err != nil {
+		return nil, err
+	}
+	return newSendMethodList(result), nil
+}
+
+func (gateway *Gateway) makeReservation(ctx context.Context, r *request) (interface{}, error) {
+	var body ReservationRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		return nil, badRequest("invalid request body: %s", err)
+	}
+	if body.PerformanceID == "" || body.TicketTypeCode == "" || body.PriceBandCode == "" {
+		return nil, badRequest("performance_id, ticket_type_code and price_band_code are required")
+	}
+	if body.Seats == 0 {
+		body.Seats = len(body.SeatIDs)
+	}
+	if body.Seats < 1 {
+		return nil, badRequest("seats must be at least 1")
+	}
+	if len(body.SeatIDs) > 0 && len(body.SeatIDs) != body.Seats {
+		return nil, badRequest("seat_ids must list every seat")
+	}
+	if len(body.Discounts) > 0 && len(body.Discounts) != body.Seats {
+		return nil, badRequest("discounts must have one code per seat")
+	}
+	if body.SendMethod != "" && body.SourceCode == "" {
+		return nil, badRequest("source_code is required with send_method")
+	}
+
+	result, err := gateway.api.MakeReservation(ctx, &ticketswitch.MakeReservationParams{
+		PerformanceID:  body.PerformanceID,
+		TicketTypeCode: body.TicketTypeCode,
+		PriceBandCode:  body.PriceBandCode,
+		NumberOfSeats:  body.Seats,
+		Seats:          body.SeatIDs,
+		Discounts:      body.Discounts,
+		SendMethod:     body.SendMethod,
+		SourceCode:     body.SourceCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+	transaction := newTransaction(&result.Trolley, result.Status, result.MinutesLeftOnReserve, result.ReserveTime, time.Time{})
+	transaction.UnreservedOrders = newOrders(result.UnreservedOrders)
+	return transaction, nil
+}
+
+func (gateway *Gateway) getTransaction(ctx context.Context, r *request) (interface{}, error) {
+	result, err := gateway.api.GetStatus(ctx, &ticketswitch.TransactionParams{TransactionUUID: r.path["transaction_uuid"]})
+	if err != nil {
+		return nil, err
+	}
+	return newTransaction(&result.Trolley, result.Status, result.MinutesLeftOnReserve, result.ReserveDatetime, result.PurchaseDatetime), nil
+}
+
+func (gateway *Gateway) releaseReservation(ctx context.Context, r *request) (interface{}, error) {
+	released, err := gateway.api.ReleaseReservation(ctx, &ticketswitch.TransactionParams{TransactionUUID: r.path["transaction_uuid"]})
+	if err != nil {
+		return nil, err
+	}
+	return Release{Released: released}, nil
+}
@@ -0,0 +1,399 @@
+package gateway
+
+import (
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/shopspring/decimal"
+)
+
+// The types below are the gateway's JSON API. Only the fields they declare are
+// ever sent to clients, so fields added to the ticketswitch types, internal
+// codes, commissions and customer details are never exposed by accident.
+// Prices are decimal strings.
+
+// CostRange summarises cached prices per seat.
+type CostRange struct {
+	CurrencyCode string          `json:"currency_code"`
+	MinSeatprice decimal.Decimal `json:"min_seatprice"`
+	MaxSeatprice decimal.Decimal `json:"max_seatprice"`
+	MinSurcharge decimal.Decimal `json:"min_surcharge"`
+	MaxSurcharge decimal.Decimal `json:"max_surcharge"`
+}
+
+func newCostRange(costRange *ticketswitch.CostRange) *CostRange {
+	if costRange.CurrencyCode == "" {
+		return nil
+	}
+	return &CostRange{
+		CurrencyCode: costRange.CurrencyCode,
+		MinSeatprice: costRange.MinSeatPrice,
+		MaxSeatprice: costRange.MaxSeatPrice,
+		MinSurcharge: costRange.MinSurcharge,
+		MaxSurcharge: costRange.MaxSurcharge,
+	}
+}
+
+// Paging describes a page of results.
+type Paging struct {
+	Page           int `json:"page"`
+	PageLength     int `json:"page_length"`
+	PagesRemaining int `json:"pages_remaining"`
+	TotalResults   int `json:"total_results"`
+}
+
+func newPaging(status *ticketswitch.PagingStatus) Paging {
+	return Paging{
+		Page:           status.PageNumber,
+		PageLength:     status.PageLength,
+		PagesRemaining: status.PagesRemaining,
+		TotalResults:   status.TotalResults,
+	}
+}
+
+// Event is an event, such as a show or an attraction.
+type Event struct {
+	ID                  string            `json:"event_id"`
+	Description         string            `json:"description"`
+	Status              string            `json:"status"`
+	Type                string            `json:"type"`
+	Venue               string            `json:"venue"`
+	Postcode            string            `json:"postcode"`
+	City                string            `json:"city"`
+	CityCode            string            `json:"city_code"`
+	Country             string            `json:"country"`
+	CountryCode         string            `json:"country_code"`
+	Latitude            float64           `json:"latitude"`
+	Longitude           float64           `json:"longitude"`
+	Classes             map[string]string `json:"classes"`
+	IsSeated            bool              `json:"is_seated"`
+	HasNoPerformances   bool              `json:"has_no_performances"`
+	ShowPerformanceTime bool              `json:"show_performance_time"`
+	MinRunningTime      int               `json:"min_running_time"`
+	MaxRunningTime      int               `json:"max_running_time"`
+	CostRange           *CostRange        `json:"cost_range,omitempty"`
+}
+
+func newEvent(event *ticketswitch.Event) Event {
+	classes := event.Classes
+	if classes == nil {
+		classes = make(map[string]string)
+	}
+	return Event{
+		ID:                  event.ID,
+		Description:         event.Description,
+		Status:              event.Status,
+		Type:                event.EventType,
+		Venue:               event.Venue,
+		Postcode:            event.Postcode,
+		City:                event.City,
+		CityCode:            event.CityCode,
+		Country:             event.Country,
+		CountryCode:         event.CountryCode,
+		Latitude:            event.GeoData.Latitude,
+		Longitude:           event.GeoData.Longitude,
+		Classes:             classes,
+		IsSeated:            event.IsSeated,
+		HasNoPerformances:   event.HasNoPerformances,
+		ShowPerformanceTime: event.ShowPerformanceTime,
+		MinRunningTime:      event.MinRunningTime,
+		MaxRunningTime:      event.MaxRunningTime,
+		CostRange:           newCostRange(&event.CostRange),
+	}
+}
+
+// EventList is a page of events.
+type EventList struct {
+	Events []Event `json:"events"`
+	Paging Paging  `json:"paging"`
+}
+
+// Performance is a single date and time of an event.
+type Performance struct {
+	ID          string     `json:"performance_id"`
+	EventID     string     `json:"event_id"`
+	Name        string     `json:"name"`
+	Datetime    time.Time  `json:"datetime"`
+	DateDesc    string     `json:"date_desc"`
+	TimeDesc    string     `json:"time_desc"`
+	RunningTime int        `json:"running_time"`
+	IsLimited   bool       `json:"is_limited"`
+	CostRange   *CostRange `json:"cost_range,omitempty"`
+}
+
+func newPerformance(perf *ticketswitch.Performance) Performance {
+	return Performance{
+		ID:          perf.ID,
+		EventID:     perf.EventID,
+		Name:        perf.Name,
+		Datetime:    perf.Datetime,
+		DateDesc:    perf.DateDesc,
+		TimeDesc:    perf.TimeDesc,
+		RunningTime: perf.RunningTime,
+		IsLimited:   perf.IsLimited,
+		CostRange:   newCostRange(&perf.CostRange),
+	}
+}
+
+// PerformanceList is a page of performances.
+type PerformanceList struct {
+	Performances []Performance `json:"performances"`
+	Paging       Paging        `json:"paging"`
+}
+
+// PriceBand is a group of seats within a ticket type at one price.
+type PriceBand struct {
+	Code                     string          `json:"price_band_code"`
+	Description              string          `json:"description"`
+	Available                int             `json:"available"`
+	Seatprice                decimal.Decimal `json:"seatprice"`
+	Surcharge                decimal.Decimal `json:"surcharge"`
+	IsOffer                  bool            `json:"is_offer"`
+	NonOfferSeatprice        decimal.Decimal `json:"non_offer_seatprice"`
+	NonOfferSurcharge        decimal.Decimal `json:"non_offer_surcharge"`
+	AllowsLeavingSingleSeats string          `json:"allows_leaving_single_seats"`
+}
+
+// TicketType is an area of a venue.
+type TicketType struct {
+	Code        string      `json:"ticket_type_code"`
+	Description string      `json:"description"`
+	PriceBands  []PriceBand `json:"price_bands"`
+}
+
+// Availability is the live availability of a performance.
+type Availability struct {
+	PerformanceID               string       `json:"performance_id"`
+	CurrencyCode                string       `json:"currency_code"`
+	ValidQuantities             []int        `json:"valid_quantities"`
+	ContiguousSeatSelectionOnly bool         `json:"contiguous_seat_selection_only"`
+	TicketTypes                 []TicketType `json:"ticket_types"`
+}
+
+func newAvailability(perfID string, result *ticketswitch.AvailabilityResult) Availability {
+	availability := Availability{
+		PerformanceID:               perfID,
+		CurrencyCode:                result.CurrencyCode,
+		ValidQuantities:             result.ValidQuantities,
+		ContiguousSeatSelectionOnly: result.ContiguousSeatSelectionOnly,
+		TicketTypes:                 make([]TicketType, 0, len(result.Availability.TicketTypes)),
+	}
+	if availability.ValidQuantities == nil {
+		availability.ValidQuantities = make([]int, 0)
+	}
+	for _, ticketType := range result.Availability.TicketTypes {
+		bands := make([]PriceBand, 0, len(ticketType.PriceBands))
+		for _, band := range ticketType.PriceBands {
+			bands = append(bands, PriceBand{
+				Code:                     band.Code,
+				Description:              band.Desc,
+				Available:                band.NumberAvailable,
+				Seatprice:                band.Seatprice,
+				Surcharge:                band.Surcharge,
+				IsOffer:                  band.IsOffer,
+				NonOfferSeatprice:        band.NonOfferSeatprice,
+				NonOfferSurcharge:        band.NonOfferSurcharge,
+				AllowsLeavingSingleSeats: band.AllowsLeavingSingleSeats,
+			})
+		}
+		availability.TicketTypes = append(availability.TicketTypes, TicketType{
+			Code:        ticketType.Code,
+			Description: ticketType.Desc,
+			PriceBands:  bands,
+		})
+	}
+	return availability
+}
+
+// Discount is a price available within a price band, such as a child price.
+type Discount struct {
+	Code               string          `json:"discount_code"`
+	Description        string          `json:"description"`
+	SemanticType       string          `json:"semantic_type"`
+	Available          int             `json:"available"`
+	Seatprice          decimal.Decimal `json:"seatprice"`
+	Surcharge          decimal.Decimal `json:"surcharge"`
+	MinimumEligibleAge int             `json:"minimum_eligible_age"`
+	MaximumEligibleAge int             `json:"maximum_eligible_age"`
+}
+
+// DiscountList is the discounts of a price band.
+type DiscountList struct {
+	CurrencyCode string     `json:"currency_code"`
+	Discounts    []Discount `json:"discounts"`
+}
+
+func newDiscountList(result *ticketswitch.DiscountsResult) DiscountList {
+	list := DiscountList{CurrencyCode: result.CurrencyCode, Discounts: make([]Discount, 0)}
+	for _, discount := range result.DiscountsHolder.Discounts {
+		list.Discounts = append(list.Discounts, Discount{
+			Code:               discount.Code,
+			Description:        discount.Description,
+			SemanticType:       discount.SemanticType,
+			Available:          discount.NumberAvailable,
+			Seatprice:          discount.Seatprice,
+			Surcharge:          discount.Surcharge,
+			MinimumEligibleAge: discount.MinimumEligibleAge,
+			MaximumEligibleAge: discount.MaximumEligibleAge,
+		})
+	}
+	return list
+}
+
+// SendMethod is a way of getting tickets to the customer.
+type SendMethod struct {
+	Code               string          `json:"send_code"`
+	Description        string          `json:"description"`
+	Type               string          `json:"type"`
+	Cost               decimal.Decimal `json:"cost"`
+	PermittedCountries []string        `json:"permitted_countries"`
+}
+
+// SendMethodList is the send methods of a performance. The source code must
+// be sent with a send method when reserving.
+type SendMethodList struct {
+	SourceCode   string       `json:"source_code"`
+	CurrencyCode string       `json:"currency_code"`
+	SendMethods  []SendMethod `json:"send_methods"`
+}
+
+func newSendMethodList(result *ticketswitch.SendMethodsResults) SendMethodList {
+	list := SendMethodList{
+		SourceCode:   result.SourceCode,
+		CurrencyCode: result.CurrencyCode,
+		SendMethods:  make([]SendMethod, 0),
+	}
+	for _, method := range result.SendMethodsHolder.SendMethods {
+		countries := make([]string, 0, len(method.PermittedCountries.Countries))
+		for _, country := range method.PermittedCountries.Countries {
+			countries = append(countries, country.Code)
+		}
+		list.SendMethods = append(list.SendMethods, SendMethod{
+			Code:               method.Code,
+			Description:        method.Desc,
+			Type:               method.Type,
+			Cost:               method.Cost,
+			PermittedCountries: countries,
+		})
+	}
+	return list
+}
+
+// ReservationRequest asks for seats to be reserved.
+type ReservationRequest struct {
+	PerformanceID  string `json:"performance_id"`
+	TicketTypeCode string `json:"ticket_type_code"`
+	PriceBandCode  string `json:"price_band_code"`
+	Seats          int    `json:"seats"`
+	// specific seats, when the performance allows seat selection.
+	SeatIDs []string `json:"seat_ids,omitempty"`
+	// a discount code for each seat.
+	Discounts []string `json:"discounts,omitempty"`
+	// the send method and the source code listed with it.
+	SendMethod string `json:"send_method,omitempty"`
+	SourceCode string `json:"source_code,omitempty"`
+}
+
+// Order is a group of seats within a transaction.
+type Order struct {
+	ItemNumber          int             `json:"item_number"`
+	EventID             string          `json:"event_id"`
+	EventDescription    string          `json:"event_description"`
+	PerformanceID       string          `json:"performance_id"`
+	PerformanceDatetime time.Time       `json:"performance_datetime"`
+	TicketTypeCode      string          `json:"ticket_type_code"`
+	TicketTypeDesc      string          `json:"ticket_type_description"`
+	PriceBandCode       string          `json:"price_band_code"`
+	Seats               int             `json:"seats"`
+	SeatIDs             []string        `json:"seat_ids"`
+	TotalSeatprice      decimal.Decimal `json:"total_seatprice"`
+	TotalSurcharge      decimal.Decimal `json:"total_surcharge"`
+	SendMethod          string          `json:"send_method"`
+	CancellationStatus  string          `json:"cancellation_status,omitempty"`
+}
+
+func newOrders(orders []ticketswitch.Order) []Order {
+	converted := make([]Order, 0, len(orders))
+	for _, order := range orders {
+		seatIDs := make([]string, 0)
+		for _, ticketOrder := range order.TicketOrdersHolder.TicketOrders {
+			for _, seat := range ticketOrder.Seats {
+				seatIDs = append(seatIDs, seat.FullID)
+			}
+		}
+		converted = append(converted, Order{
+			ItemNumber:          order.ItemNumber,
+			EventID:             order.Event.ID,
+			EventDescription:    order.Event.Description,
+			PerformanceID:       order.Performance.ID,
+			PerformanceDatetime: order.Performance.Datetime,
+			TicketTypeCode:      order.TicketTypeCode,
+			TicketTypeDesc:      order.TicketTypeDesc,
+			PriceBandCode:       order.PriceBandCode,
+			Seats:               order.TotalNumberOfSeats,
+			SeatIDs:             seatIDs,
+			TotalSeatprice:      order.TotalSaleSeatprice,
+			TotalSurcharge:      order.TotalSaleSurcharge,
+			SendMethod:          order.SendMethod.Code,
+			CancellationStatus:  order.CancellationStatus,
+		})
+	}
+	return converted
+}
+
+// Transaction is a reservation or purchase.
+type Transaction struct {
+	TransactionUUID string          `json:"transaction_uuid"`
+	Status          string          `json:"status"`
+	MinutesLeft     float64         `json:"minutes_left"`
+	ReservedAt      *time.Time      `json:"reserved_at,omitempty"`
+	PurchasedAt     *time.Time      `json:"purchased_at,omitempty"`
+	CurrencyCode    string          `json:"currency_code"`
+	Total           decimal.Decimal `json:"total"`
+	Orders          []Order         `json:"orders"`
+	// orders of a reservation that couldn't be reserved.
+	UnreservedOrders []Order `json:"unreserved_orders,omitempty"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func newTransaction(trolley *ticketswitch.Trolley, status string, minutesLeft float64, reservedAt, purchasedAt time.Time) Transaction {
+	transaction := Transaction{
+		TransactionUUID: trolley.TransactionUUID,
+		Status:          status,
+		MinutesLeft:     minutesLeft,
+		ReservedAt:      optionalTime(reservedAt),
+		PurchasedAt:     optionalTime(purchasedAt),
+		Orders:          make([]Order, 0),
+	}
+	for _, bundle := range trolley.Bundles {
+		transaction.Total = transaction.Total.Add(bundle.TotalCost)
+		if transaction.CurrencyCode == "" {
+			transaction.CurrencyCode = bundle.CurrencyCode
+		}
+		transaction.Orders = append(transaction.Orders, newOrders(bundle.Orders)...)
+	}
+	return transaction
+}
+
+// Release is the outcome of releasing a reservation.
+type Release struct {
+	Released bool `json:"released"`
+}
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes an error. The code is stable and meant for programs;
+// the message is meant for people.
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
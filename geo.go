@@ -2,6 +2,7 @@ package ticketswitch
 
 import (
 	"fmt"
+	"sort"
 
 	geo "github.com/kellydunn/golang-geo"
 )
@@ -54,3 +55,131 @@ func (area *Circle) Valid() bool {
 func (area *Circle) Param() string {
 	return fmt.Sprintf("%.6f:%.6f:%.6f", area.Lat(), area.Lng(), area.Radius)
 }
+
+// Valid checks if the geo data holds usable coordinates. Venues the API has no
+// location for are returned at 0, 0, which is treated as missing.
+func (geoData GeoData) Valid() bool {
+	if geoData.Latitude == 0 && geoData.Longitude == 0 {
+		return false
+	}
+	return geoData.Latitude >= -90.0 && geoData.Latitude <= 90.0 &&
+		geoData.Longitude >= -180.0 && geoData.Longitude <= 180.0
+}
+
+// Point returns the location of the event's venue, or false when the event
+// has no geo data.
+func (event *Event) Point() (*geo.Point, bool) {
+	if !event.GeoData.Valid() {
+		return nil, false
+	}
+	return geo.NewPoint(event.GeoData.Latitude, event.GeoData.Longitude), true
+}
+
+// DistanceFrom returns the great circle distance in kilometres from the point
+// to the event's venue, or false when the event has no geo data.
+func (event *Event) DistanceFrom(point geo.Point) (float64, bool) {
+	venue, ok := event.Point()
+	if !ok {
+		return 0, false
+	}
+	return point.GreatCircleDistance(venue), true
+}
+
+// SortByDistance sorts the events nearest first from the point. Events
+// without geo data are moved to the end in their original order.
+func (results *ListEventsResults) SortByDistance(point geo.Point) {
+	type ranked struct {
+		event    Event
+		distance float64
+		ok       bool
+	}
+	ranks := make([]ranked, len(results.Events))
+	for i := range results.Events {
+		distance, ok := results.Events[i].DistanceFrom(point)
+		ranks[i] = ranked{results.Events[i], distance, ok}
+	}
+	sort.SliceStable(ranks, func(i, j int) bool {
+		if !ranks[i].ok || !ranks[j].ok {
+			return ranks[i].ok && !ranks[j].ok
+		}
+		return ranks[i].distance < ranks[j].distance
+	})
+	for i := range ranks {
+		results.Events[i] = ranks[i].event
+	}
+}
+
+// BoundingBox returns a polygon covering the area between two latitudes and
+// two longitudes, for use with WithinPolygon. Boxes that cross the
+// antimeridian aren't supported.
+func BoundingBox(south, west, north, east float64) *geo.Polygon {
+	return geo.NewPolygon([]*geo.Point{
+		geo.NewPoint(south, west),
+		geo.NewPoint(north, west),
+		geo.NewPoint(north, east),
+		geo.NewPoint(south, east),
+	})
+}
+
+// WithinPolygon returns the events whose venue is inside the polygon, such as
+// a city boundary or a BoundingBox. Events without geo data are left out.
+func (results *ListEventsResults) WithinPolygon(polygon *geo.Polygon) []Event {
+	var events []Event
+	for i := range results.Events {
+		point, ok := results.Events[i].Point()
+		if ok && polygon.Contains(point) {
+			events = append(events, results.Events[i])
+		}
+	}
+	return events
+}
+
+// VenueCluster is a group of events at the same venue.
+type VenueCluster struct {
+	// the venue code shared by the events, empty when they don't have one.
+	VenueCode string
+	// human-readable description of the venue.
+	Venue string
+	// location of the venue, zero when none of the events have geo data.
+	GeoData GeoData
+	// events at the venue.
+	Events []Event
+}
+
+// ClusterByVenue groups the events by venue, in the order each venue first
+// appears. Events are grouped by VenueCode, or by their geo data when they
+// don't have one, or by their venue description when they have neither.
+// Events with none of these are left out.
+func (results *ListEventsResults) ClusterByVenue() []VenueCluster {
+	var clusters []VenueCluster
+	index := make(map[string]int)
+	for _, event := range results.Events {
+		var key string
+		switch {
+		case event.VenueCode != "":
+			key = "code:" + event.VenueCode
+		case event.GeoData.Valid():
+			key = fmt.Sprintf("geo:%.6f:%.6f", event.GeoData.Latitude, event.GeoData.Longitude)
+		case event.Venue != "":
+			key = "desc:" + event.Venue
+		default:
+			continue
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(clusters)
+			index[key] = i
+			clusters = append(clusters, VenueCluster{VenueCode: event.VenueCode})
+		}
+		cluster := &clusters[i]
+		if cluster.Venue == "" {
+			cluster.Venue = event.Venue
+		}
+		if !cluster.GeoData.Valid() && event.GeoData.Valid() {
+			cluster.GeoData = event.GeoData
+		}
+		cluster.Events = append(cluster.Events, event)
+	}
+	return clusters
+}
@@ -50,3 +50,94 @@ func TestCircle_Param(t *testing.T) {
 	area = NewCircle(45.67890, 98.76543, 123.4567)
 	assert.Equal(t, "45.678900:98.765430:123.456700", area.Param())
 }
+
+func testGeoResults() *ListEventsResults {
+	return &ListEventsResults{
+		Events: []Event{
+			{ID: "edinburgh", Venue: "Festival Theatre", VenueCode: "FT", GeoData: GeoData{Latitude: 55.9469, Longitude: -3.1857}},
+			{ID: "nowhere", Venue: "Touring"},
+			{ID: "lyric", Venue: "Lyric Theatre", VenueCode: "LY", GeoData: GeoData{Latitude: 51.5113, Longitude: -0.1327}},
+			{ID: "manchester", Venue: "Palace Theatre", GeoData: GeoData{Latitude: 53.4741, Longitude: -2.2428}},
+			{ID: "lyric-matinee", Venue: "Lyric Theatre", VenueCode: "LY"},
+			{ID: "apollo", Venue: "Apollo Theatre", VenueCode: "AP", GeoData: GeoData{Latitude: 51.5116, Longitude: -0.1332}},
+			{ID: "unknown"},
+		},
+	}
+}
+
+func eventIDs(events []Event) []string {
+	var ids []string
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestGeoData_Valid(t *testing.T) {
+	assert.True(t, GeoData{Latitude: 51.5113, Longitude: -0.1327}.Valid())
+	assert.True(t, GeoData{Latitude: 0, Longitude: 32.5}.Valid())
+	assert.False(t, GeoData{}.Valid())
+	assert.False(t, GeoData{Latitude: 91, Longitude: 0.1}.Valid())
+	assert.False(t, GeoData{Latitude: 51.5, Longitude: -181}.Valid())
+}
+
+func TestEvent_DistanceFrom(t *testing.T) {
+	results := testGeoResults()
+	trafalgar := geo.NewPoint(51.5080, -0.1281)
+
+	distance, ok := results.Events[2].DistanceFrom(*trafalgar)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.49, distance, 0.01)
+
+	distance, ok = results.Events[0].DistanceFrom(*trafalgar)
+	assert.True(t, ok)
+	assert.InDelta(t, 533, distance, 1)
+
+	_, ok = results.Events[1].DistanceFrom(*trafalgar)
+	assert.False(t, ok)
+}
+
+func TestListEventsResults_SortByDistance(t *testing.T) {
+	results := testGeoResults()
+	results.SortByDistance(*geo.NewPoint(51.5080, -0.1281))
+	assert.Equal(t, []string{"lyric", "apollo", "manchester", "edinburgh", "nowhere", "lyric-matinee", "unknown"}, eventIDs(results.Events))
+}
+
+func TestListEventsResults_WithinPolygon(t *testing.T) {
+	results := testGeoResults()
+
+	// roughly the West End.
+	westEnd := geo.NewPolygon([]*geo.Point{
+		geo.NewPoint(51.5165, -0.1440),
+		geo.NewPoint(51.5165, -0.1180),
+		geo.NewPoint(51.5060, -0.1180),
+		geo.NewPoint(51.5060, -0.1440),
+	})
+	assert.Equal(t, []string{"lyric", "apollo"}, eventIDs(results.WithinPolygon(westEnd)))
+
+	england := BoundingBox(49.9, -5.7, 55.8, 1.8)
+	assert.Equal(t, []string{"lyric", "manchester", "apollo"}, eventIDs(results.WithinPolygon(england)))
+
+	assert.Empty(t, results.WithinPolygon(BoundingBox(-10, -10, -5, -5)))
+}
+
+func TestListEventsResults_ClusterByVenue(t *testing.T) {
+	clusters := testGeoResults().ClusterByVenue()
+	if !assert.Len(t, clusters, 5) {
+		return
+	}
+
+	assert.Equal(t, "FT", clusters[0].VenueCode)
+	assert.Equal(t, "", clusters[1].VenueCode)
+	assert.Equal(t, "Touring", clusters[1].Venue)
+	assert.False(t, clusters[1].GeoData.Valid())
+
+	assert.Equal(t, "LY", clusters[2].VenueCode)
+	assert.Equal(t, "Lyric Theatre", clusters[2].Venue)
+	assert.Equal(t, GeoData{Latitude: 51.5113, Longitude: -0.1327}, clusters[2].GeoData)
+	assert.Equal(t, []string{"lyric", "lyric-matinee"}, eventIDs(clusters[2].Events))
+
+	assert.Equal(t, "Palace Theatre", clusters[3].Venue)
+	assert.Equal(t, []string{"manchester"}, eventIDs(clusters[3].Events))
+	assert.Equal(t, "AP", clusters[4].VenueCode)
+}
@@ -0,0 +1,295 @@
+package ticketswitch
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JournalPhase says whether a journal entry was written before or after a
+// call.
+type JournalPhase string
+
+// The phases of a journalled call.
+const (
+	// written before the request is sent.
+	JournalStarted JournalPhase = "started"
+	// written once the outcome of the request is known.
+	JournalFinished JournalPhase = "finished"
+)
+
+// JournalOutcome describes how a journalled call ended.
+type JournalOutcome string
+
+// The outcomes of a journalled call.
+const (
+	// the API responded successfully. Status holds the transaction status.
+	OutcomeSucceeded JournalOutcome = "succeeded"
+	// the API responded with an error, so the call had no effect.
+	OutcomeFailed JournalOutcome = "failed"
+	// no usable response was received, for example because the request
+	// timed out. The call may or may not have taken effect and the
+	// transaction should be checked with GetStatus.
+	OutcomeUnknown JournalOutcome = "unknown"
+)
+
+// JournalEntry records a transactional call. Each call writes a started entry
+// before the request is sent and a finished entry with the same ID once the
+// outcome is known, so a started entry without a finished one is a call whose
+// outcome was never seen.
+type JournalEntry struct {
+	// identifies the call, shared by its started and finished entries.
+	ID string `json:"id"`
+	// the API endpoint, e.g. purchase.v1.
+	Call  string       `json:"call"`
+	Phase JournalPhase `json:"phase"`
+	// the transaction, only known once a reservation has been made.
+	TransactionUUID string `json:"transaction_uuid,omitempty"`
+	AgentReference  string `json:"agent_reference,omitempty"`
+	// the call parameters with payment details removed.
+	Params  map[string]string `json:"params,omitempty"`
+	Outcome JournalOutcome    `json:"outcome,omitempty"`
+	// the transaction status reported by the API. Release calls record
+	// released or not_released and cancel calls record cancelled,
+	// partially_cancelled or not_cancelled.
+	Status string `json:"status,omitempty"`
+	// the error returned by the call.
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Journal durably records the transactional calls made by a Client:
+// MakeReservation, MakePurchase, Callback, ReleaseReservation, Cancel and
+// CancelOrders.
+//
+// When the started entry can't be recorded the call is not made and the error
+// is returned. Errors recording the finished entry are not returned, so the
+// result of a call that took effect is never lost; implementations should
+// report them some other way.
+type Journal interface {
+	Record(entry JournalEntry) error
+}
+
+// redactedValue replaces sensitive parameter values in journal entries.
+const redactedValue = "[redacted]"
+
+// sensitiveParams are parameters that are never journalled, in addition to
+// the parameters supplied by a PaymentMethod.
+var sensitiveParams = []string{"card", "cv_two", "cvv", "expiry", "issue_number", "password", "passwd", "token", "crypto_block"}
+
+// sanitizeParams returns a copy of the parameters with the values of payment
+// and other sensitive parameters redacted.
+func sanitizeParams(values map[string]string, payment map[string]string) map[string]string {
+	sanitized := make(map[string]string, len(values))
+	for key, value := range values {
+		if _, ok := payment[key]; ok {
+			value = redactedValue
+		}
+		for _, sensitive := range sensitiveParams {
+			if strings.Contains(key, sensitive) {
+				value = redactedValue
+			}
+		}
+		sanitized[key] = value
+	}
+	return sanitized
+}
+
+func newJournalID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
+
+// startJournal records the start of a call. It returns nil when the client
+// has no journal.
+func (client *Client) startJournal(entry *JournalEntry) (*JournalEntry, error) {
+	if client.Journal == nil {
+		return nil, nil
+	}
+	entry.ID = newJournalID()
+	entry.Phase = JournalStarted
+	entry.StartedAt = time.Now().UTC()
+	if err := client.Journal.Record(*entry); err != nil {
+		return nil, fmt.Errorf("ticketswitch: unable to journal %s: %w", entry.Call, err)
+	}
+	return entry, nil
+}
+
+// finishJournal records the outcome of a call started with startJournal.
+func (client *Client) finishJournal(entry *JournalEntry, err error) {
+	if entry == nil {
+		return
+	}
+	finished := time.Now().UTC()
+	entry.Phase = JournalFinished
+	entry.FinishedAt = &finished
+	entry.Outcome = OutcomeSucceeded
+	if err != nil {
+		entry.Error = err.Error()
+		var apiErr Error
+		if errors.As(err, &apiErr) {
+			entry.Outcome = OutcomeFailed
+		} else {
+			entry.Outcome = OutcomeUnknown
+		}
+	}
+	_ = client.Journal.Record(*entry)
+}
+
+// FileJournal is a Journal that appends entries to a file as JSON lines,
+// syncing the file after every entry. When MaxSize is set the file is rotated
+// before it would grow past it; rotated files are kept alongside the journal
+// with the time of rotation appended to their name and are never deleted.
+type FileJournal struct {
+	// the size in bytes the file is rotated at, zero for no rotation.
+	MaxSize int64
+	// called with errors recording entries, which a Client doesn't return
+	// for finished entries.
+	OnError func(err error)
+
+	path string
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	now  func() time.Time
+}
+
+// OpenFileJournal opens the journal at path for appending, creating it if it
+// doesn't exist.
+func OpenFileJournal(path string, maxSize int64) (*FileJournal, error) {
+	journal := &FileJournal{MaxSize: maxSize, path: path, now: time.Now}
+	if err := journal.open(); err != nil {
+		return nil, err
+	}
+	return journal, nil
+}
+
+func (journal *FileJournal) open() error {
+	file, err := os.OpenFile(journal.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("ticketswitch: unable to open journal: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("ticketswitch: unable to open journal: %w", err)
+	}
+	journal.file = file
+	journal.size = info.Size()
+	return nil
+}
+
+// Path returns the path of the current journal file.
+func (journal *FileJournal) Path() string {
+	return journal.path
+}
+
+// Record appends the entry to the journal and syncs it to disk.
+func (journal *FileJournal) Record(entry JournalEntry) error {
+	err := journal.record(entry)
+	if err != nil && journal.OnError != nil {
+		journal.OnError(err)
+	}
+	return err
+}
+
+func (journal *FileJournal) record(entry JournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	if journal.file == nil {
+		return errors.New("ticketswitch: journal is closed")
+	}
+	if journal.MaxSize > 0 && journal.size > 0 && journal.size+int64(len(data)) > journal.MaxSize {
+		if err := journal.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := journal.file.Write(data)
+	journal.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("ticketswitch: unable to write journal: %w", err)
+	}
+	if err := journal.file.Sync(); err != nil {
+		return fmt.Errorf("ticketswitch: unable to sync journal: %w", err)
+	}
+	return nil
+}
+
+// Rotate moves the current journal file aside and starts a new one.
+func (journal *FileJournal) Rotate() error {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	if journal.file == nil {
+		return errors.New("ticketswitch: journal is closed")
+	}
+	return journal.rotate()
+}
+
+func (journal *FileJournal) rotate() error {
+	if err := journal.file.Close(); err != nil {
+		return fmt.Errorf("ticketswitch: unable to rotate journal: %w", err)
+	}
+	journal.file = nil
+	rotated := journal.path + "." + journal.now().UTC().Format("20060102T150405.000000000Z")
+	if err := os.Rename(journal.path, rotated); err != nil {
+		return fmt.Errorf("ticketswitch: unable to rotate journal: %w", err)
+	}
+	// make the rename durable before writing to the new file
+	if dir, err := os.Open(filepath.Dir(journal.path)); err == nil {
+		_ = dir.Sync()
+		dir.Close()
+	}
+	return journal.open()
+}
+
+// Close closes the journal file.
+func (journal *FileJournal) Close() error {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	if journal.file == nil {
+		return nil
+	}
+	err := journal.file.Close()
+	journal.file = nil
+	return err
+}
+
+// ReadJournal reads the entries written by a FileJournal.
+func ReadJournal(r io.Reader) ([]JournalEntry, error) {
+	entries := make([]JournalEntry, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return entries, fmt.Errorf("ticketswitch: journal line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,249 @@
+package ticketswitch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryJournal keeps entries in memory.
+type memoryJournal struct {
+	entries []JournalEntry
+	err     error
+}
+
+func (journal *memoryJournal) Record(entry JournalEntry) error {
+	if journal.err != nil {
+		return journal.err
+	}
+	journal.entries = append(journal.entries, entry)
+	return nil
+}
+
+func newJournalTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *memoryJournal) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	journal := &memoryJournal{}
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+	client.Journal = journal
+	return client, journal
+}
+
+func serveFile(t *testing.T, path string) http.HandlerFunc {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}
+}
+
+func TestSanitizeParams(t *testing.T) {
+	values := map[string]string{
+		"transaction_uuid": "abc",
+		"first_name":       "Fred",
+		"card_number":      "4111111111111111",
+		"cv_two":           "123",
+		"stripe_token":     "tok_123",
+		"provider_secret":  "s3cret",
+	}
+	sanitized := sanitizeParams(values, map[string]string{"provider_secret": ""})
+	assert.Equal(t, map[string]string{
+		"transaction_uuid": "abc",
+		"first_name":       "Fred",
+		"card_number":      redactedValue,
+		"cv_two":           redactedValue,
+		"stripe_token":     redactedValue,
+		"provider_secret":  redactedValue,
+	}, sanitized)
+	assert.Equal(t, "4111111111111111", values["card_number"])
+}
+
+func TestClient_Journal_purchase(t *testing.T) {
+	client, journal := newJournalTestClient(t, serveFile(t, "testdata/purchase-credit-success.json"))
+
+	params := &MakePurchaseParams{
+		TransactionUUID: "4df498e9-2daa-4393-a6bb-cc3dfefa7cc1",
+		AgentReference:  "INV-1",
+		Customer:        Customer{FirstName: "Fred", LastName: "Flintstone"},
+		PaymentMethod:   FakePaymentMethod{"provider_secret": "s3cret"},
+	}
+	_, err := client.MakePurchase(context.Background(), params)
+	if !assert.Nil(t, err) || !assert.Len(t, journal.entries, 2) {
+		return
+	}
+
+	started, finished := journal.entries[0], journal.entries[1]
+	assert.Equal(t, started.ID, finished.ID)
+	assert.Equal(t, "purchase.v1", started.Call)
+	assert.Equal(t, JournalStarted, started.Phase)
+	assert.Equal(t, "4df498e9-2daa-4393-a6bb-cc3dfefa7cc1", started.TransactionUUID)
+	assert.Equal(t, "INV-1", started.AgentReference)
+	assert.Equal(t, redactedValue, started.Params["provider_secret"])
+	assert.Equal(t, "Fred", started.Params["first_name"])
+	assert.Nil(t, started.FinishedAt)
+
+	assert.Equal(t, JournalFinished, finished.Phase)
+	assert.Equal(t, OutcomeSucceeded, finished.Outcome)
+	assert.Equal(t, "purchased", finished.Status)
+	assert.NotNil(t, finished.FinishedAt)
+}
+
+func TestClient_Journal_reserve(t *testing.T) {
+	client, journal := newJournalTestClient(t, serveFile(t, "testdata/reservation.json"))
+
+	_, err := client.MakeReservation(context.Background(), &MakeReservationParams{
+		PerformanceID: "7AB-5", NumberOfSeats: 2, TicketTypeCode: "STALLS", PriceBandCode: "A/pool",
+	})
+	if assert.Nil(t, err) && assert.Len(t, journal.entries, 2) {
+		assert.Equal(t, "", journal.entries[0].TransactionUUID)
+		assert.Equal(t, "e18c20fc-042e-11e7-975c-002590326962", journal.entries[1].TransactionUUID)
+		assert.Equal(t, "reserved", journal.entries[1].Status)
+	}
+}
+
+func TestClient_Journal_release_and_cancel(t *testing.T) {
+	client, journal := newJournalTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/f13/release.v1" {
+			w.Write([]byte(`{"released_ok": true}`))
+			return
+		}
+		data, _ := os.ReadFile("testdata/must_also_cancel.json")
+		w.Write(data)
+	})
+
+	_, err := client.ReleaseReservation(context.Background(), &TransactionParams{TransactionUUID: "abc"})
+	assert.Nil(t, err)
+	_, err = client.Cancel(context.Background(), &CancellationParams{TransactionUUID: "def", CancelItemsList: CancelItemsList{1}})
+	assert.Nil(t, err)
+
+	if assert.Len(t, journal.entries, 4) {
+		assert.Equal(t, "released", journal.entries[1].Status)
+		assert.Equal(t, "abc", journal.entries[1].TransactionUUID)
+		assert.Equal(t, "cancel.v1", journal.entries[3].Call)
+		assert.Equal(t, "def", journal.entries[3].TransactionUUID)
+		assert.Equal(t, "1", journal.entries[3].Params["cancel_items_list"])
+		assert.Equal(t, "not_cancelled", journal.entries[3].Status)
+	}
+}
+
+func TestClient_Journal_outcomes(t *testing.T) {
+	requests := 0
+	client, journal := newJournalTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error_code": 1100, "error_desc": "transaction already purchased"}`))
+	})
+
+	_, err := client.MakePurchase(context.Background(), &MakePurchaseParams{TransactionUUID: "done"})
+	assert.NotNil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.MakePurchase(ctx, &MakePurchaseParams{TransactionUUID: "slow"})
+	assert.NotNil(t, err)
+
+	if assert.Len(t, journal.entries, 4) {
+		assert.Equal(t, OutcomeFailed, journal.entries[1].Outcome)
+		assert.Contains(t, journal.entries[1].Error, "already purchased")
+		assert.Equal(t, OutcomeUnknown, journal.entries[3].Outcome)
+	}
+}
+
+func TestClient_Journal_start_error(t *testing.T) {
+	called := false
+	client, journal := newJournalTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	journal.err = errors.New("disk full")
+
+	_, err := client.MakePurchase(context.Background(), &MakePurchaseParams{TransactionUUID: "abc"})
+	assert.EqualError(t, err, "ticketswitch: unable to journal purchase.v1: disk full")
+	assert.False(t, called)
+}
+
+func TestFileJournal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "journal.jsonl")
+
+	journal, err := OpenFileJournal(path, 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, journal.Record(JournalEntry{ID: "1", Call: "reserve.v1", Phase: JournalStarted}))
+	assert.Nil(t, journal.Close())
+
+	// reopening appends
+	journal, err = OpenFileJournal(path, 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, journal.Record(JournalEntry{ID: "1", Call: "reserve.v1", Phase: JournalFinished, Outcome: OutcomeSucceeded}))
+	assert.Nil(t, journal.Close())
+	assert.NotNil(t, journal.Record(JournalEntry{ID: "2"}))
+
+	f, err := os.Open(path)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer f.Close()
+	entries, err := ReadJournal(f)
+	if assert.Nil(t, err) && assert.Len(t, entries, 2) {
+		assert.Equal(t, JournalStarted, entries[0].Phase)
+		assert.Equal(t, OutcomeSucceeded, entries[1].Outcome)
+	}
+}
+
+func TestFileJournal_rotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "journal.jsonl")
+
+	journal, err := OpenFileJournal(path, 150)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer journal.Close()
+	tick := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	journal.now = func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	}
+
+	for _, id := range []string{"1", "2", "3"} {
+		assert.Nil(t, journal.Record(JournalEntry{ID: id, Call: "purchase.v1", Phase: JournalStarted}))
+	}
+
+	files, err := filepath.Glob(path + "*")
+	if !assert.Nil(t, err) || !assert.Len(t, files, 3) {
+		return
+	}
+	assert.Equal(t, path+".20261018T120001.000000000Z", files[1])
+	var ids []string
+	for _, file := range []string{files[1], files[2], files[0]} {
+		data, err := os.ReadFile(file)
+		assert.Nil(t, err)
+		entries, err := ReadJournal(strings.NewReader(string(data)))
+		assert.Nil(t, err)
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+		}
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+}
+
+func TestReadJournal_error(t *testing.T) {
+	_, err := ReadJournal(strings.NewReader("{\"id\": \"1\"}\nnope\n"))
+	assert.EqualError(t, err, "ticketswitch: journal line 2: invalid character 'o' in literal null (expecting 'u')")
+}
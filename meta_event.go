@@ -0,0 +1,231 @@
+package ticketswitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ComponentOrder chooses the tickets for one component event of a meta
+// event.
+type ComponentOrder struct {
+	// the component event, one of the meta event's ComponentEvents.
+	EventID        string
+	PerformanceID  string
+	TicketTypeCode string
+	PriceBandCode  string
+	// defaults to MetaReservationParams.NumberOfSeats.
+	NumberOfSeats int
+	Seats         []string
+	Discounts     []string
+	DepartureDate time.Time
+	Duration      int
+}
+
+// MetaReservationParams are the parameters that can be passed to the
+// MakeMetaReservation call.
+type MetaReservationParams struct {
+	UniversalParams
+	// the meta event being booked, with its ComponentEvents.
+	Event *Event
+	// the number of seats of every component that doesn't choose its own.
+	NumberOfSeats int
+	// one order per chosen component.
+	Components     []ComponentOrder
+	SendMethod     string
+	SourceCode     string // Required if specifying the send method
+	UserCommission bool
+}
+
+// ComponentError describes a problem with the order for a component event.
+type ComponentError struct {
+	EventID string
+	Message string
+}
+
+func (err ComponentError) Error() string {
+	return fmt.Sprintf("%s: %s", err.EventID, err.Message)
+}
+
+// ComponentErrors is a list of problems found when validating the orders for
+// a meta event.
+type ComponentErrors []ComponentError
+
+func (errs ComponentErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return "ticketswitch: invalid meta event reservation: " + strings.Join(messages, "; ")
+}
+
+// numberOfSeats returns the number of seats for a component order.
+func (params *MetaReservationParams) numberOfSeats(order *ComponentOrder) int {
+	if order.NumberOfSeats > 0 {
+		return order.NumberOfSeats
+	}
+	return params.NumberOfSeats
+}
+
+// Validate checks that every required component of the meta event has been
+// chosen exactly once with a performance, ticket type, price band and number
+// of seats, returning ComponentErrors listing every problem found. Add-on
+// components are optional.
+func (params *MetaReservationParams) Validate() error {
+	if params.Event == nil || len(params.Event.ComponentEvents) == 0 {
+		id := ""
+		if params.Event != nil {
+			id = params.Event.ID
+		}
+		return ComponentErrors{{EventID: id, Message: "is not a meta event"}}
+	}
+
+	var errs ComponentErrors
+	components := make(map[string]bool, len(params.Event.ComponentEvents))
+	for _, component := range params.Event.ComponentEvents {
+		components[component.ID] = true
+	}
+
+	chosen := make(map[string]bool)
+	for i := range params.Components {
+		order := &params.Components[i]
+		if !components[order.EventID] {
+			errs = append(errs, ComponentError{EventID: order.EventID, Message: "is not a component of " + params.Event.ID})
+			continue
+		}
+		if chosen[order.EventID] {
+			errs = append(errs, ComponentError{EventID: order.EventID, Message: "is chosen more than once"})
+			continue
+		}
+		chosen[order.EventID] = true
+
+		if order.PerformanceID == "" || order.TicketTypeCode == "" || order.PriceBandCode == "" {
+			errs = append(errs, ComponentError{EventID: order.EventID, Message: "needs a performance, ticket type and price band"})
+		}
+		seats := params.numberOfSeats(order)
+		if seats < 1 {
+			errs = append(errs, ComponentError{EventID: order.EventID, Message: "needs at least one seat"})
+			continue
+		}
+		if len(order.Seats) > 0 && len(order.Seats) != seats {
+			errs = append(errs, ComponentError{EventID: order.EventID, Message: fmt.Sprintf("has %d seat ids for %d seats", len(order.Seats), seats)})
+		}
+		if len(order.Discounts) > 0 && len(order.Discounts) != seats {
+			errs = append(errs, ComponentError{EventID: order.EventID, Message: fmt.Sprintf("has %d discounts for %d seats", len(order.Discounts), seats)})
+		}
+	}
+
+	for _, component := range params.Event.ComponentEvents {
+		if !component.IsAddon && !chosen[component.ID] {
+			errs = append(errs, ComponentError{EventID: component.ID, Message: "must be chosen"})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// MetaReservationResult is the result from the MakeMetaReservation call.
+type MetaReservationResult struct {
+	Reservation *ReservationResult
+	// the reserved orders by component event id.
+	Orders map[string][]Order
+	// the chosen components that have no reserved order.
+	Missing []string
+}
+
+// Complete checks if every chosen component was reserved. An incomplete meta
+// event reservation should normally be released.
+func (result *MetaReservationResult) Complete() bool {
+	return len(result.Missing) == 0
+}
+
+// MakeMetaReservation reserves the chosen components of a meta event
+// together. The component orders are added to a trolley one by one and the
+// trolley is then reserved as a single transaction. Nothing is reserved when
+// the params don't validate or a component can't be added to the trolley.
+func (client *Client) MakeMetaReservation(ctx context.Context, params *MetaReservationParams) (*MetaReservationResult, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	token := ""
+	for i := range params.Components {
+		order := &params.Components[i]
+		trolley, err := client.GetTrolley(ctx, &TrolleyParams{
+			UniversalParams: params.UniversalParams,
+			Token:           token,
+			DepartureDate:   order.DepartureDate,
+			Duration:        order.Duration,
+			Discounts:       order.Discounts,
+			NumberOfSeats:   params.numberOfSeats(order),
+			PerformanceID:   order.PerformanceID,
+			PriceBandCode:   order.PriceBandCode,
+			Seats:           order.Seats,
+			TicketTypeCode:  order.TicketTypeCode,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(trolley.DiscardedOrders) > 0 || trolley.InputContainedUnavailableOrder {
+			return nil, fmt.Errorf("ticketswitch: component %s of %s could not be added to the trolley", order.EventID, params.Event.ID)
+		}
+		token = trolley.Token
+	}
+
+	reservation, err := client.MakeReservation(ctx, &MakeReservationParams{
+		UniversalParams: params.UniversalParams,
+		SendMethod:      params.SendMethod,
+		SourceCode:      params.SourceCode,
+		TrolleyToken:    token,
+		UserCommission:  params.UserCommission,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MetaReservationResult{
+		Reservation: reservation,
+		Orders:      make(map[string][]Order),
+	}
+	for _, bundle := range reservation.Trolley.Bundles {
+		for _, order := range bundle.Orders {
+			result.Orders[order.Event.ID] = append(result.Orders[order.Event.ID], order)
+		}
+	}
+	for _, order := range params.Components {
+		if len(result.Orders[order.EventID]) == 0 {
+			result.Missing = append(result.Missing, order.EventID)
+		}
+	}
+	return result, nil
+}
+
+// ListComponentPerformances lists the performances of each component event
+// of a meta event, keyed by component event id, so that one can be chosen
+// for each ComponentOrder. The params apply to every component and their
+// EventID is ignored.
+func (client *Client) ListComponentPerformances(ctx context.Context, event *Event, params *ListPerformancesParams) (map[string]*ListPerformancesResults, error) {
+	if event == nil || len(event.ComponentEvents) == 0 {
+		return nil, errors.New("ticketswitch: event is not a meta event")
+	}
+	var componentParams ListPerformancesParams
+	if params != nil {
+		componentParams = *params
+	}
+
+	performances := make(map[string]*ListPerformancesResults, len(event.ComponentEvents))
+	for _, component := range event.ComponentEvents {
+		componentParams.EventID = component.ID
+		results, err := client.ListPerformances(ctx, &componentParams)
+		if err != nil {
+			return nil, fmt.Errorf("ticketswitch: listing performances of component %s: %w", component.ID, err)
+		}
+		performances[component.ID] = results
+	}
+	return performances, nil
+}
@@ -0,0 +1,215 @@
+package ticketswitch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrolleyParams_Params(t *testing.T) {
+	params := TrolleyParams{
+		Token:          "abc",
+		NumberOfSeats:  2,
+		PerformanceID:  "6IF-C30",
+		PriceBandCode:  "C/pool",
+		TicketTypeCode: "CIRCLE",
+		Discounts:      []string{"ADULT", "CHILD"},
+		SendMethod:     "POST",
+		SourceCode:     "ext_test0",
+	}
+
+	values := params.Params()
+	assert.Equal(t, "abc", values["trolley_token"])
+	assert.Equal(t, "2", values["no_of_seats"])
+	assert.Equal(t, "6IF-C30", values["perf_id"])
+	assert.Equal(t, "C/pool", values["price_band_code"])
+	assert.Equal(t, "CIRCLE", values["ticket_type_code"])
+	assert.Equal(t, "CHILD", values["disc1"])
+	assert.Equal(t, "POST", values["ext_test0_send_code"])
+
+	params = TrolleyParams{Token: "abc", ItemNumbersToRemove: []int{1, 3}}
+	assert.Equal(t, map[string]string{
+		"trolley_token":     "abc",
+		"remove_items_list": "1,3",
+	}, params.Params())
+}
+
+func TestMakeReservationParams_trolley_token(t *testing.T) {
+	params := MakeReservationParams{TrolleyToken: "abc"}
+
+	values := params.Params()
+	assert.Equal(t, "abc", values["trolley_token"])
+	assert.NotContains(t, values, "perf_id")
+	assert.NotContains(t, values, "no_of_seats")
+}
+
+func TestGetTrolley(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/f13/trolley.v1", r.URL.Path)
+			assert.Equal(t, "6IF-C30", r.URL.Query().Get("perf_id"))
+			w.Write([]byte(`{
+				"trolley_token": "tok1",
+				"trolley_contents": {"trolley_order_count": 1},
+				"discarded_orders": []
+			}`))
+		}))
+	defer server.Close()
+
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+	result, err := client.GetTrolley(context.Background(), &TrolleyParams{PerformanceID: "6IF-C30", NumberOfSeats: 2})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "tok1", result.Token)
+		assert.Equal(t, 1, result.Trolley.OrderCount)
+		assert.Empty(t, result.DiscardedOrders)
+	}
+}
+
+func testMetaEvent() *Event {
+	return &Event{
+		ID: "META",
+		ComponentEvents: []Event{
+			{ID: "SHOW"},
+			{ID: "DINNER"},
+			{ID: "PARKING", IsAddon: true},
+		},
+	}
+}
+
+func TestListComponentPerformances(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/f13/performances.v1", r.URL.Path)
+			eventID := r.URL.Query().Get("event_id")
+			fmt.Fprintf(w, `{"results": {"performance": [{"perf_id": "%s-1"}]}}`, eventID)
+		}))
+	defer server.Close()
+
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+	performances, err := client.ListComponentPerformances(context.Background(), testMetaEvent(), &ListPerformancesParams{EventID: "ignored"})
+	if assert.Nil(t, err) {
+		assert.Len(t, performances, 3)
+		assert.Equal(t, "DINNER-1", performances["DINNER"].Performances[0].ID)
+	}
+
+	_, err = client.ListComponentPerformances(context.Background(), &Event{ID: "6IF"}, nil)
+	assert.EqualError(t, err, "ticketswitch: event is not a meta event")
+}
+
+func TestMetaReservationParams_Validate(t *testing.T) {
+	params := MetaReservationParams{
+		Event:         testMetaEvent(),
+		NumberOfSeats: 2,
+		Components: []ComponentOrder{
+			{EventID: "SHOW", PerformanceID: "SHOW-1", TicketTypeCode: "STALLS", PriceBandCode: "A"},
+			{EventID: "DINNER", PerformanceID: "DINNER-1", TicketTypeCode: "TABLE", PriceBandCode: "A"},
+		},
+	}
+	assert.Nil(t, params.Validate())
+
+	params.Components = []ComponentOrder{
+		{EventID: "SHOW", PerformanceID: "SHOW-1", TicketTypeCode: "STALLS", PriceBandCode: "A", Seats: []string{"A1"}},
+		{EventID: "SHOW", PerformanceID: "SHOW-2", TicketTypeCode: "STALLS", PriceBandCode: "A"},
+		{EventID: "PARKING", PerformanceID: "PARKING-1"},
+		{EventID: "OTHER", PerformanceID: "OTHER-1", TicketTypeCode: "X", PriceBandCode: "A"},
+	}
+	err := params.Validate()
+	errs, ok := err.(ComponentErrors)
+	if assert.True(t, ok) {
+		assert.Equal(t, ComponentErrors{
+			{EventID: "SHOW", Message: "has 1 seat ids for 2 seats"},
+			{EventID: "SHOW", Message: "is chosen more than once"},
+			{EventID: "PARKING", Message: "needs a performance, ticket type and price band"},
+			{EventID: "OTHER", Message: "is not a component of META"},
+			{EventID: "DINNER", Message: "must be chosen"},
+		}, errs)
+	}
+
+	params = MetaReservationParams{Event: &Event{ID: "6IF"}}
+	assert.EqualError(t, params.Validate(), "ticketswitch: invalid meta event reservation: 6IF: is not a meta event")
+}
+
+func TestMakeMetaReservation(t *testing.T) {
+	trolleyCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/f13/trolley.v1":
+				query := r.URL.Query()
+				if trolleyCalls == 0 {
+					assert.Equal(t, "", query.Get("trolley_token"))
+					assert.Equal(t, "SHOW-1", query.Get("perf_id"))
+				} else {
+					assert.Equal(t, "tok1", query.Get("trolley_token"))
+					assert.Equal(t, "DINNER-1", query.Get("perf_id"))
+					assert.Equal(t, "4", query.Get("no_of_seats"))
+				}
+				trolleyCalls++
+				fmt.Fprintf(w, `{"trolley_token": "tok%d"}`, trolleyCalls)
+			case "/f13/reserve.v1":
+				var inputs map[string]interface{}
+				if err := json.NewDecoder(r.Body).Decode(&inputs); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, "tok2", inputs["trolley_token"])
+				assert.NotContains(t, inputs, "perf_id")
+				w.Write([]byte(`{
+					"transaction_status": "reserved",
+					"trolley_contents": {
+						"transaction_uuid": "abc-123",
+						"bundle": [
+							{"order": [{"item_number": 1, "event": {"event_id": "SHOW"}}]},
+							{"order": [{"item_number": 2, "event": {"event_id": "DINNER"}}]}
+						]
+					}
+				}`))
+			default:
+				t.Errorf("unexpected request to %s", r.URL.Path)
+			}
+		}))
+	defer server.Close()
+
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+	result, err := client.MakeMetaReservation(context.Background(), &MetaReservationParams{
+		Event:         testMetaEvent(),
+		NumberOfSeats: 2,
+		Components: []ComponentOrder{
+			{EventID: "SHOW", PerformanceID: "SHOW-1", TicketTypeCode: "STALLS", PriceBandCode: "A"},
+			{EventID: "DINNER", PerformanceID: "DINNER-1", TicketTypeCode: "TABLE", PriceBandCode: "A", NumberOfSeats: 4},
+		},
+	})
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, 2, trolleyCalls)
+		assert.True(t, result.Complete())
+		assert.Equal(t, "abc-123", result.Reservation.Trolley.TransactionUUID)
+		assert.Equal(t, 1, result.Orders["SHOW"][0].ItemNumber)
+		assert.Equal(t, 2, result.Orders["DINNER"][0].ItemNumber)
+	}
+}
+
+func TestMakeMetaReservation_discarded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/f13/trolley.v1", r.URL.Path)
+			w.Write([]byte(`{"trolley_token": "tok1", "discarded_orders": [{"item_number": 1}]}`))
+		}))
+	defer server.Close()
+
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+	_, err := client.MakeMetaReservation(context.Background(), &MetaReservationParams{
+		Event:         testMetaEvent(),
+		NumberOfSeats: 2,
+		Components: []ComponentOrder{
+			{EventID: "SHOW", PerformanceID: "SHOW-1", TicketTypeCode: "STALLS", PriceBandCode: "A"},
+			{EventID: "DINNER", PerformanceID: "DINNER-1", TicketTypeCode: "TABLE", PriceBandCode: "A"},
+		},
+	})
+
+	assert.EqualError(t, err, "ticketswitch: component SHOW of META could not be added to the trolley")
+}
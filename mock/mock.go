@@ -0,0 +1,247 @@
+// Code generated by generate.go; DO NOT EDIT.
+
+package mock
+
+import (
+	"context"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+)
+
+// API implements ticketswitch.API by calling the function for each
+// method. Methods without a function return ErrNotMocked. Every call is
+// recorded, whether or not it has a function.
+type API struct {
+	TestFunc                      func(ctx context.Context) (*ticketswitch.User, error)
+	ListEventsFunc                func(ctx context.Context, params *ticketswitch.ListEventsParams) (*ticketswitch.ListEventsResults, error)
+	GetEventsFunc                 func(ctx context.Context, eventIDs []string, params *ticketswitch.UniversalParams) (map[string]*ticketswitch.Event, error)
+	GetEventFunc                  func(ctx context.Context, eventID string, params *ticketswitch.UniversalParams) (*ticketswitch.Event, error)
+	ListPerformancesFunc          func(ctx context.Context, params *ticketswitch.ListPerformancesParams) (*ticketswitch.ListPerformancesResults, error)
+	ListComponentPerformancesFunc func(ctx context.Context, event *ticketswitch.Event, params *ticketswitch.ListPerformancesParams) (map[string]*ticketswitch.ListPerformancesResults, error)
+	ListPerformanceTimesFunc      func(ctx context.Context, params *ticketswitch.ListPerformancesParams) (*ticketswitch.ListPerformanceTimesResults, error)
+	GetAvailabilityFunc           func(ctx context.Context, perf string, params *ticketswitch.GetAvailabilityParams) (*ticketswitch.AvailabilityResult, error)
+	GetProductAvailabilityFunc    func(ctx context.Context, event *ticketswitch.Event, params *ticketswitch.GetAvailabilityParams) (*ticketswitch.ProductAvailability, error)
+	GetDiscountsFunc              func(ctx context.Context, perf string, ticketTypeCode string, priceBandCode string, params *ticketswitch.UniversalParams) (*ticketswitch.DiscountsResult, error)
+	GetSourcesFunc                func(ctx context.Context, params *ticketswitch.UniversalParams) (*ticketswitch.SourcesResult, error)
+	GetSendMethodsFunc            func(ctx context.Context, perf string, params *ticketswitch.UniversalParams) (*ticketswitch.SendMethodsResults, error)
+	GetTrolleyFunc                func(ctx context.Context, params *ticketswitch.TrolleyParams) (*ticketswitch.TrolleyResult, error)
+	MakeReservationFunc           func(ctx context.Context, params *ticketswitch.MakeReservationParams) (*ticketswitch.ReservationResult, error)
+	MakeMetaReservationFunc       func(ctx context.Context, params *ticketswitch.MetaReservationParams) (*ticketswitch.MetaReservationResult, error)
+	ReleaseReservationFunc        func(ctx context.Context, params *ticketswitch.TransactionParams) (bool, error)
+	MakePurchaseFunc              func(ctx context.Context, params *ticketswitch.MakePurchaseParams) (*ticketswitch.MakePurchaseResult, error)
+	CallbackFunc                  func(ctx context.Context, params *ticketswitch.CallbackParams) (*ticketswitch.MakePurchaseResult, error)
+	GetStatusFunc                 func(ctx context.Context, params *ticketswitch.TransactionParams) (*ticketswitch.StatusResult, error)
+	GetSelfPrintVoucherFunc       func(ctx context.Context, order *ticketswitch.Order) (*ticketswitch.Voucher, error)
+	CancelFunc                    func(ctx context.Context, params *ticketswitch.CancellationParams) (*ticketswitch.CancellationResult, error)
+	CancelOrdersFunc              func(ctx context.Context, params *ticketswitch.CancelOrdersParams) (*ticketswitch.CancelOrdersResult, error)
+	EmailCheckFunc                func(ctx context.Context, params *ticketswitch.EmailCheckParams) error
+
+	calls recorder
+}
+
+// Test records the call and calls TestFunc.
+func (api *API) Test(ctx context.Context) (*ticketswitch.User, error) {
+	api.record("Test")
+	if api.TestFunc == nil {
+		return nil, notMocked("Test")
+	}
+	return api.TestFunc(ctx)
+}
+
+// ListEvents records the call and calls ListEventsFunc.
+func (api *API) ListEvents(ctx context.Context, params *ticketswitch.ListEventsParams) (*ticketswitch.ListEventsResults, error) {
+	api.record("ListEvents", params)
+	if api.ListEventsFunc == nil {
+		return nil, notMocked("ListEvents")
+	}
+	return api.ListEventsFunc(ctx, params)
+}
+
+// GetEvents records the call and calls GetEventsFunc.
+func (api *API) GetEvents(ctx context.Context, eventIDs []string, params *ticketswitch.UniversalParams) (map[string]*ticketswitch.Event, error) {
+	api.record("GetEvents", eventIDs, params)
+	if api.GetEventsFunc == nil {
+		return nil, notMocked("GetEvents")
+	}
+	return api.GetEventsFunc(ctx, eventIDs, params)
+}
+
+// GetEvent records the call and calls GetEventFunc.
+func (api *API) GetEvent(ctx context.Context, eventID string, params *ticketswitch.UniversalParams) (*ticketswitch.Event, error) {
+	api.record("GetEvent", eventID, params)
+	if api.GetEventFunc == nil {
+		return nil, notMocked("GetEvent")
+	}
+	return api.GetEventFunc(ctx, eventID, params)
+}
+
+// ListPerformances records the call and calls ListPerformancesFunc.
+func (api *API) ListPerformances(ctx context.Context, params *ticketswitch.ListPerformancesParams) (*ticketswitch.ListPerformancesResults, error) {
+	api.record("ListPerformances", params)
+	if api.ListPerformancesFunc == nil {
+		return nil, notMocked("ListPerformances")
+	}
+	return api.ListPerformancesFunc(ctx, params)
+}
+
+// ListComponentPerformances records the call and calls ListComponentPerformancesFunc.
+func (api *API) ListComponentPerformances(ctx context.Context, event *ticketswitch.Event, params *ticketswitch.ListPerformancesParams) (map[string]*ticketswitch.ListPerformancesResults, error) {
+	api.record("ListComponentPerformances", event, params)
+	if api.ListComponentPerformancesFunc == nil {
+		return nil, notMocked("ListComponentPerformances")
+	}
+	return api.ListComponentPerformancesFunc(ctx, event, params)
+}
+
+// ListPerformanceTimes records the call and calls ListPerformanceTimesFunc.
+func (api *API) ListPerformanceTimes(ctx context.Context, params *ticketswitch.ListPerformancesParams) (*ticketswitch.ListPerformanceTimesResults, error) {
+	api.record("ListPerformanceTimes", params)
+	if api.ListPerformanceTimesFunc == nil {
+		return nil, notMocked("ListPerformanceTimes")
+	}
+	return api.ListPerformanceTimesFunc(ctx, params)
+}
+
+// GetAvailability records the call and calls GetAvailabilityFunc.
+func (api *API) GetAvailability(ctx context.Context, perf string, params *ticketswitch.GetAvailabilityParams) (*ticketswitch.AvailabilityResult, error) {
+	api.record("GetAvailability", perf, params)
+	if api.GetAvailabilityFunc == nil {
+		return nil, notMocked("GetAvailability")
+	}
+	return api.GetAvailabilityFunc(ctx, perf, params)
+}
+
+// GetProductAvailability records the call and calls GetProductAvailabilityFunc.
+func (api *API) GetProductAvailability(ctx context.Context, event *ticketswitch.Event, params *ticketswitch.GetAvailabilityParams) (*ticketswitch.ProductAvailability, error) {
+	api.record("GetProductAvailability", event, params)
+	if api.GetProductAvailabilityFunc == nil {
+		return nil, notMocked("GetProductAvailability")
+	}
+	return api.GetProductAvailabilityFunc(ctx, event, params)
+}
+
+// GetDiscounts records the call and calls GetDiscountsFunc.
+func (api *API) GetDiscounts(ctx context.Context, perf string, ticketTypeCode string, priceBandCode string, params *ticketswitch.UniversalParams) (*ticketswitch.DiscountsResult, error) {
+	api.record("GetDiscounts", perf, ticketTypeCode, priceBandCode, params)
+	if api.GetDiscountsFunc == nil {
+		return nil, notMocked("GetDiscounts")
+	}
+	return api.GetDiscountsFunc(ctx, perf, ticketTypeCode, priceBandCode, params)
+}
+
+// GetSources records the call and calls GetSourcesFunc.
+func (api *API) GetSources(ctx context.Context, params *ticketswitch.UniversalParams) (*ticketswitch.SourcesResult, error) {
+	api.record("GetSources", params)
+	if api.GetSourcesFunc == nil {
+		return nil, notMocked("GetSources")
+	}
+	return api.GetSourcesFunc(ctx, params)
+}
+
+// GetSendMethods records the call and calls GetSendMethodsFunc.
+func (api *API) GetSendMethods(ctx context.Context, perf string, params *ticketswitch.UniversalParams) (*ticketswitch.SendMethodsResults, error) {
+	api.record("GetSendMethods", perf, params)
+	if api.GetSendMethodsFunc == nil {
+		return nil, notMocked("GetSendMethods")
+	}
+	return api.GetSendMethodsFunc(ctx, perf, params)
+}
+
+// GetTrolley records the call and calls GetTrolleyFunc.
+func (api *API) GetTrolley(ctx context.Context, params *ticketswitch.TrolleyParams) (*ticketswitch.TrolleyResult, error) {
+	api.record("GetTrolley", params)
+	if api.GetTrolleyFunc == nil {
+		return nil, notMocked("GetTrolley")
+	}
+	return api.GetTrolleyFunc(ctx, params)
+}
+
+// MakeReservation records the call and calls MakeReservationFunc.
+func (api *API) MakeReservation(ctx context.Context, params *ticketswitch.MakeReservationParams) (*ticketswitch.ReservationResult, error) {
+	api.record("MakeReservation", params)
+	if api.MakeReservationFunc == nil {
+		return nil, notMocked("MakeReservation")
+	}
+	return api.MakeReservationFunc(ctx, params)
+}
+
+// MakeMetaReservation records the call and calls MakeMetaReservationFunc.
+func (api *API) MakeMetaReservation(ctx context.Context, params *ticketswitch.MetaReservationParams) (*ticketswitch.MetaReservationResult, error) {
+	api.record("MakeMetaReservation", params)
+	if api.MakeMetaReservationFunc == nil {
+		return nil, notMocked("MakeMetaReservation")
+	}
+	return api.MakeMetaReservationFunc(ctx, params)
+}
+
+// ReleaseReservation records the call and calls ReleaseReservationFunc.
+func (api *API) ReleaseReservation(ctx context.Context, params *ticketswitch.TransactionParams) (bool, error) {
+	api.record("ReleaseReservation", params)
+	if api.ReleaseReservationFunc == nil {
+		return false, notMocked("ReleaseReservation")
+	}
+	return api.ReleaseReservationFunc(ctx, params)
+}
+
+// MakePurchase records the call and calls MakePurchaseFunc.
+func (api *API) MakePurchase(ctx context.Context, params *ticketswitch.MakePurchaseParams) (*ticketswitch.MakePurchaseResult, error) {
+	api.record("MakePurchase", params)
+	if api.MakePurchaseFunc == nil {
+		return nil, notMocked("MakePurchase")
+	}
+	return api.MakePurchaseFunc(ctx, params)
+}
+
+// Callback records the call and calls CallbackFunc.
+func (api *API) Callback(ctx context.Context, params *ticketswitch.CallbackParams) (*ticketswitch.MakePurchaseResult, error) {
+	api.record("Callback", params)
+	if api.CallbackFunc == nil {
+		return nil, notMocked("Callback")
+	}
+	return api.CallbackFunc(ctx, params)
+}
+
+// GetStatus records the call and calls GetStatusFunc.
+func (api *API) GetStatus(ctx context.Context, params *ticketswitch.TransactionParams) (*ticketswitch.StatusResult, error) {
+	api.record("GetStatus", params)
+	if api.GetStatusFunc == nil {
+		return nil, notMocked("GetStatus")
+	}
+	return api.GetStatusFunc(ctx, params)
+}
+
+// GetSelfPrintVoucher records the call and calls GetSelfPrintVoucherFunc.
+func (api *API) GetSelfPrintVoucher(ctx context.Context, order *ticketswitch.Order) (*ticketswitch.Voucher, error) {
+	api.record("GetSelfPrintVoucher", order)
+	if api.GetSelfPrintVoucherFunc == nil {
+		return nil, notMocked("GetSelfPrintVoucher")
+	}
+	return api.GetSelfPrintVoucherFunc(ctx, order)
+}
+
+// Cancel records the call and calls CancelFunc.
+func (api *API) Cancel(ctx context.Context, params *ticketswitch.CancellationParams) (*ticketswitch.CancellationResult, error) {
+	api.record("Cancel", params)
+	if api.CancelFunc == nil {
+		return nil, notMocked("Cancel")
+	}
+	return api.CancelFunc(ctx, params)
+}
+
+// CancelOrders records the call and calls CancelOrdersFunc.
+func (api *API) CancelOrders(ctx context.Context, params *ticketswitch.CancelOrdersParams) (*ticketswitch.CancelOrdersResult, error) {
+	api.record("CancelOrders", params)
+	if api.CancelOrdersFunc == nil {
+		return nil, notMocked("CancelOrders")
+	}
+	return api.CancelOrdersFunc(ctx, params)
+}
+
+// EmailCheck records the call and calls EmailCheckFunc.
+func (api *API) EmailCheck(ctx context.Context, params *ticketswitch.EmailCheckParams) error {
+	api.record("EmailCheck", params)
+	if api.EmailCheckFunc == nil {
+		return notMocked("EmailCheck")
+	}
+	return api.EmailCheckFunc(ctx, params)
+}
@@ -0,0 +1,41 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPI(t *testing.T) {
+	api := &API{
+		GetStatusFunc: func(ctx context.Context, params *ticketswitch.TransactionParams) (*ticketswitch.StatusResult, error) {
+			return &ticketswitch.StatusResult{Status: "purchased"}, nil
+		},
+	}
+
+	params := &ticketswitch.TransactionParams{TransactionUUID: "abc"}
+	status, err := api.GetStatus(context.Background(), params)
+	assert.Nil(t, err)
+	assert.Equal(t, "purchased", status.Status)
+
+	released, err := api.ReleaseReservation(context.Background(), params)
+	assert.False(t, released)
+	assert.True(t, errors.Is(err, ErrNotMocked))
+	assert.EqualError(t, err, "mock: method not mocked: ReleaseReservation")
+
+	_, err = api.GetDiscounts(context.Background(), "7AB-5", "STALLS", "A/pool", nil)
+	assert.NotNil(t, err)
+
+	assert.Equal(t, []Call{
+		{Method: "GetStatus", Args: []interface{}{params}},
+		{Method: "ReleaseReservation", Args: []interface{}{params}},
+		{Method: "GetDiscounts", Args: []interface{}{"7AB-5", "STALLS", "A/pool", (*ticketswitch.UniversalParams)(nil)}},
+	}, api.Calls())
+	assert.Len(t, api.CallsTo("GetStatus"), 1)
+
+	api.Reset()
+	assert.Empty(t, api.Calls())
+}
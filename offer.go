@@ -16,4 +16,11 @@ type Offer struct {
 	// the amount of money saved by this offer, as a percentage of the original
 	// price.
 	PercentageSaving decimal.Decimal `json:"percentage_saving"`
+
+	// the prices above in the desired currency, when one was requested.
+	SeatPriceInDesired      decimal.Decimal `json:"offer_seatprice_in_desired"`
+	SurchargeInDesired      decimal.Decimal `json:"offer_surcharge_in_desired"`
+	FullSeatPriceInDesired  decimal.Decimal `json:"full_seatprice_in_desired"`
+	FullSurchargeInDesired  decimal.Decimal `json:"full_surcharge_in_desired"`
+	AbsoluteSavingInDesired decimal.Decimal `json:"absolute_saving_in_desired"`
 }
@@ -8,3 +8,9 @@ type PagingStatus struct {
 	ResultsRemaining int `json:"results_remaining"`
 	TotalResults     int `json:"total_unpaged_results"`
 }
+
+// NextPage returns the page number to request for the page after this one.
+// The boolean is false when there are no pages remaining.
+func (status PagingStatus) NextPage() (int, bool) {
+	return status.PageNumber + 1, status.PagesRemaining > 0
+}
@@ -45,6 +45,10 @@ type Performance struct {
 
 // ListPerformancesResults represents the results from a ListPerformance call
 type ListPerformancesResults struct {
+	// indicates that the list contains only one performance and it should be
+	// automatically selected for the customer.
+	AutoSelect bool `json:"-"`
+
 	// indicates that the related performances have names
 	HasPerfNames bool `json:"has_perf_names"`
 
@@ -0,0 +1,132 @@
+package ticketswitch
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ValidateFor checks the reservation request against the requirements of the
+// event being booked, returning ValidationErrors listing every problem
+// found.
+func (params *MakeReservationParams) ValidateFor(event *Event) error {
+	var errs ValidationErrors
+
+	// a trolley token on its own reserves orders that were checked when they
+	// were added to the trolley.
+	if params.TrolleyToken == "" || params.PerformanceID != "" {
+		required := []struct {
+			field string
+			value string
+		}{
+			{"perf_id", params.PerformanceID},
+			{"ticket_type_code", params.TicketTypeCode},
+			{"price_band_code", params.PriceBandCode},
+		}
+		for _, r := range required {
+			if r.value == "" {
+				errs = append(errs, FieldError{Field: r.field, Message: "is required"})
+			}
+		}
+		if params.NumberOfSeats < 1 {
+			errs = append(errs, FieldError{Field: "no_of_seats", Message: "must be at least 1"})
+		} else {
+			// seats and discounts are sent as seat0, disc0 and so on.
+			errs = append(errs, perSeatErrors("seat", len(params.Seats), params.NumberOfSeats)...)
+			errs = append(errs, perSeatErrors("disc", len(params.Discounts), params.NumberOfSeats)...)
+		}
+	}
+
+	if params.SendMethod != "" && params.SourceCode == "" {
+		errs = append(errs, FieldError{Field: "source_code", Message: "is required with a send method"})
+	}
+
+	if event.NeedsDepartureDate && params.DepartureDate.IsZero() {
+		errs = append(errs, FieldError{Field: "departure_date", Message: "is required"})
+	}
+	if !params.DepartureDate.IsZero() && beforeToday(params.DepartureDate) {
+		errs = append(errs, FieldError{Field: "departure_date", Message: "is in the past"})
+	}
+	if event.NeedsDuration && params.Duration < 1 {
+		errs = append(errs, FieldError{Field: "duration", Message: "is required"})
+	}
+	if params.Duration < 0 {
+		errs = append(errs, FieldError{Field: "duration", Message: "must not be negative"})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// perSeatErrors checks that a list of per seat values, sent as prefix0,
+// prefix1 and so on, has a value for each seat when it is given at all.
+func perSeatErrors(prefix string, values, seats int) []FieldError {
+	switch {
+	case values == 0 || values == seats:
+		return nil
+	case values < seats:
+		return []FieldError{{Field: fmt.Sprintf("%s%d", prefix, values), Message: "is required for every seat"}}
+	}
+	return []FieldError{{Field: fmt.Sprintf("%s%d", prefix, seats), Message: "is more than no_of_seats"}}
+}
+
+// beforeToday checks if the calendar date of t, in its own location, is
+// before today's date in the local time zone. Dates parsed without a zone
+// are midnight UTC, so comparing instants would reject today west of UTC.
+func beforeToday(t time.Time) bool {
+	year, month, day := t.Date()
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	year, month, day = time.Now().Date()
+	return date.Before(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// ProductAvailability is the availability of an event that is booked without
+// choosing a performance, such as an attraction or tour.
+type ProductAvailability struct {
+	*AvailabilityResult
+	// the performance the API selected for the event. Reserve with it.
+	PerformanceID string
+}
+
+// GetProductAvailability fetches the availability of an event that doesn't
+// need a performance to be chosen, such as an attraction or tour. The API
+// lists a single performance for such events, which is selected
+// automatically. Events that need a departure date or duration must have them
+// set in the params.
+func (client *Client) GetProductAvailability(ctx context.Context, event *Event, params *GetAvailabilityParams) (*ProductAvailability, error) {
+	if event.NeedsPerformance && !event.HasNoPerformances {
+		return nil, fmt.Errorf("ticketswitch: event %s needs a performance to be chosen", event.ID)
+	}
+	if params == nil {
+		params = &GetAvailabilityParams{}
+	}
+	if event.NeedsDepartureDate && params.DepartureDate.IsZero() {
+		return nil, fmt.Errorf("ticketswitch: event %s needs a departure date", event.ID)
+	}
+	if event.NeedsDuration && params.Duration < 1 {
+		return nil, fmt.Errorf("ticketswitch: event %s needs a duration", event.ID)
+	}
+
+	performances, err := client.ListPerformances(ctx, &ListPerformancesParams{
+		UniversalParams: params.UniversalParams,
+		EventID:         event.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(performances.Performances) == 0 {
+		return nil, fmt.Errorf("ticketswitch: event %s has no performance to check availability for", event.ID)
+	}
+	if len(performances.Performances) > 1 && !performances.AutoSelect {
+		return nil, fmt.Errorf("ticketswitch: event %s has %d performances, choose one", event.ID, len(performances.Performances))
+	}
+
+	perfID := performances.Performances[0].ID
+	availability, err := client.GetAvailability(ctx, perfID, params)
+	if err != nil {
+		return nil, err
+	}
+	return &ProductAvailability{AvailabilityResult: availability, PerformanceID: perfID}, nil
+}
@@ -0,0 +1,163 @@
+package ticketswitch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvent_needs(t *testing.T) {
+	var event Event
+	err := json.Unmarshal([]byte(`{
+		"event_id": "7AB",
+		"has_no_perfs": true,
+		"need_departure_date": true,
+		"need_duration": true,
+		"need_performance": false
+	}`), &event)
+	if assert.Nil(t, err) {
+		assert.True(t, event.HasNoPerformances)
+		assert.True(t, event.NeedsDepartureDate)
+		assert.True(t, event.NeedsDuration)
+		assert.False(t, event.NeedsPerformance)
+	}
+}
+
+func TestMakeReservationParams_duration(t *testing.T) {
+	params := MakeReservationParams{PerformanceID: "7AB-1", Duration: 3}
+	assert.Equal(t, "3", params.Params()["duration"])
+
+	params.Duration = 0
+	assert.NotContains(t, params.Params(), "duration")
+}
+
+func TestMakeReservationParams_ValidateFor(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	event := &Event{ID: "7AB", HasNoPerformances: true, NeedsDepartureDate: true, NeedsDuration: true}
+	params := MakeReservationParams{
+		PerformanceID:  "7AB-1",
+		TicketTypeCode: "ADULT",
+		PriceBandCode:  "A",
+		NumberOfSeats:  2,
+		DepartureDate:  tomorrow,
+		Duration:       3,
+	}
+	assert.Nil(t, params.ValidateFor(event))
+
+	params = MakeReservationParams{
+		TicketTypeCode: "ADULT",
+		NumberOfSeats:  2,
+		Discounts:      []string{"ADULT"},
+		DepartureDate:  time.Now().AddDate(0, 0, -2),
+		SendMethod:     "POST",
+	}
+	err := params.ValidateFor(event)
+	errs, ok := err.(ValidationErrors)
+	if assert.True(t, ok) {
+		assert.Equal(t, ValidationErrors{
+			{Field: "perf_id", Message: "is required"},
+			{Field: "price_band_code", Message: "is required"},
+			{Field: "disc1", Message: "is required for every seat"},
+			{Field: "source_code", Message: "is required with a send method"},
+			{Field: "departure_date", Message: "is in the past"},
+			{Field: "duration", Message: "is required"},
+		}, errs)
+		_, ok = errs.Field("duration")
+		assert.True(t, ok)
+	}
+
+	// a trolley only needs what the event needs.
+	params = MakeReservationParams{TrolleyToken: "abc"}
+	err = params.ValidateFor(event)
+	assert.EqualError(t, err, "ticketswitch: invalid request: departure_date: is required; duration: is required")
+
+	params = MakeReservationParams{
+		PerformanceID:  "7AB-1",
+		TicketTypeCode: "ADULT",
+		PriceBandCode:  "A",
+		NumberOfSeats:  1,
+		Seats:          []string{"A1", "A2"},
+		Duration:       3,
+		DepartureDate:  tomorrow,
+	}
+	err = params.ValidateFor(event)
+	assert.EqualError(t, err, "ticketswitch: invalid request: seat1: is more than no_of_seats")
+}
+
+func TestMakeReservationParams_ValidateFor_departure_date_zones(t *testing.T) {
+	local := time.Local
+	defer func() { time.Local = local }()
+
+	event := &Event{ID: "7AB", HasNoPerformances: true, NeedsDepartureDate: true}
+	for _, zone := range []*time.Location{
+		time.FixedZone("EST", -5*60*60),
+		time.FixedZone("LINT", 14*60*60),
+	} {
+		time.Local = zone
+		// dates from time.Parse, as tsw reads them, are midnight UTC.
+		date, err := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+		if !assert.Nil(t, err) {
+			return
+		}
+		params := MakeReservationParams{
+			PerformanceID:  "7AB-1",
+			TicketTypeCode: "ADULT",
+			PriceBandCode:  "A",
+			NumberOfSeats:  1,
+			DepartureDate:  date,
+		}
+		assert.Nil(t, params.ValidateFor(event), zone.String())
+
+		params.DepartureDate = date.AddDate(0, 0, -1)
+		err = params.ValidateFor(event)
+		assert.EqualError(t, err, "ticketswitch: invalid request: departure_date: is in the past", zone.String())
+	}
+}
+
+func TestGetProductAvailability(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/f13/performances.v1":
+				assert.Equal(t, "7AB", r.URL.Query().Get("event_id"))
+				w.Write([]byte(`{
+					"autoselect_this_performance": true,
+					"results": {"performance": [{"perf_id": "7AB-1"}]}
+				}`))
+			case "/f13/availability.v1":
+				query := r.URL.Query()
+				assert.Equal(t, "7AB-1", query.Get("perf_id"))
+				assert.Equal(t, "20301225", query.Get("departure_date"))
+				assert.Equal(t, "3", query.Get("duration"))
+				w.Write([]byte(`{"currency_code": "gbp", "availability": {"ticket_type": [{"ticket_type_code": "ADULT"}]}}`))
+			default:
+				t.Errorf("unexpected request to %s", r.URL.Path)
+			}
+		}))
+	defer server.Close()
+
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+	event := &Event{ID: "7AB", HasNoPerformances: true, NeedsDepartureDate: true, NeedsDuration: true}
+	params := &GetAvailabilityParams{
+		DepartureDate: time.Date(2030, 12, 25, 0, 0, 0, 0, time.UTC),
+		Duration:      3,
+	}
+
+	result, err := client.GetProductAvailability(context.Background(), event, params)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "7AB-1", result.PerformanceID)
+		assert.Equal(t, "gbp", result.CurrencyCode)
+		assert.Equal(t, "ADULT", result.Availability.TicketTypes[0].Code)
+	}
+
+	_, err = client.GetProductAvailability(context.Background(), event, &GetAvailabilityParams{Duration: 3})
+	assert.EqualError(t, err, "ticketswitch: event 7AB needs a departure date")
+
+	_, err = client.GetProductAvailability(context.Background(), &Event{ID: "6IF", NeedsPerformance: true}, nil)
+	assert.EqualError(t, err, "ticketswitch: event 6IF needs a performance to be chosen")
+}
@@ -70,3 +70,31 @@ type MakePurchaseResult struct {
 	ReserveUser      User                `json:"reserve_user"`
 	Languages        []string            `json:"language_list"`
 }
+
+// RedirectionDetails is a PaymentMethod for purchases where the customer may
+// be redirected to a payment page. When the purchase returns a Callout the
+// customer is sent to it and comes back to URL, which should identify the
+// Token, for example https://example.com/return/<token>. Complete the
+// purchase with Client.Callback.
+type RedirectionDetails struct {
+	// a unique token identifying this purchase attempt.
+	Token string
+	// where the customer is sent back to after the callout.
+	URL string
+	// the User-Agent and Accept headers of the customer's browser.
+	UserAgent string
+	Accept    string
+	// the scheme and host of the site the customer is using.
+	RemoteSite string
+}
+
+// PaymentParams returns the redirection parameters of a purchase.
+func (details RedirectionDetails) PaymentParams() map[string]string {
+	return map[string]string{
+		"return_token":           details.Token,
+		"return_url":             details.URL,
+		"client_http_user_agent": details.UserAgent,
+		"client_http_accept":     details.Accept,
+		"remote_site":            details.RemoteSite,
+	}
+}
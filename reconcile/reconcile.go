@@ -0,0 +1,370 @@
+// Package reconcile checks the transactions an application has recorded
+// against their state in the API.
+//
+// A Reconciler looks up the status of each Record with bounded concurrency,
+// classifies the transaction and compares its state, total and seat count
+// with what was recorded. Records can be built from an application's own
+// database, read from CSV with ReadRecords or derived from a transaction
+// journal with FromJournal:
+//
+//	entries, err := ticketswitch.ReadJournal(file)
+//	report, err := reconcile.New(client).Reconcile(ctx, reconcile.FromJournal(entries))
+//	for _, result := range report.Discrepant() {
+//		...
+//	}
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultConcurrency is the number of transactions looked up at once.
+const DefaultConcurrency = 4
+
+// DefaultExpiringWithin is how close to expiry a reservation is reported as
+// expiring.
+const DefaultExpiringWithin = 5 * time.Minute
+
+// State classifies a transaction.
+type State string
+
+// The states of a transaction.
+const (
+	// reserved and not about to expire.
+	StateReserved State = "reserved"
+	// reserved and due to expire within ExpiringWithin.
+	StateExpiring  State = "expiring"
+	StatePurchased State = "purchased"
+	// purchased, but the backend only confirmed some of the orders.
+	StatePartiallyPurchased State = "partially_purchased"
+	// released, either explicitly or because the reservation expired.
+	StateReleased  State = "released"
+	StateCancelled State = "cancelled"
+	// purchased with some, but not all, of the orders cancelled.
+	StatePartiallyCancelled State = "partially_cancelled"
+	// any other status reported by the API.
+	StateOther State = "other"
+	// the status couldn't be looked up.
+	StateError State = "error"
+)
+
+// reserved checks if the state is held as a reservation.
+func (state State) reserved() bool {
+	return state == StateReserved || state == StateExpiring
+}
+
+// Classify returns the state of a transaction from its status.
+// Reservations with less than expiringWithin left are StateExpiring.
+func Classify(status *ticketswitch.StatusResult, expiringWithin time.Duration) State {
+	switch status.Status {
+	case "reserved":
+		left := time.Duration(status.MinutesLeftOnReserve * float64(time.Minute))
+		if left <= expiringWithin {
+			return StateExpiring
+		}
+		return StateReserved
+	case "released":
+		return StateReleased
+	//nolint:misspell
+	case "cancelled":
+		return StateCancelled
+	case "purchased":
+	default:
+		return StateOther
+	}
+
+	orders, cancelled := 0, 0
+	partial := status.Trolley.PurchaseResult.IsPartial
+	for _, bundle := range status.Trolley.Bundles {
+		if bundle.PurchaseResult.IsPartial {
+			partial = true
+		}
+		for _, order := range bundle.Orders {
+			orders++
+			//nolint:misspell
+			if order.CancellationStatus == "cancelled" {
+				cancelled++
+			}
+		}
+	}
+	switch {
+	case orders > 0 && cancelled == orders:
+		return StateCancelled
+	case cancelled > 0:
+		return StatePartiallyCancelled
+	case partial:
+		return StatePartiallyPurchased
+	}
+	return StatePurchased
+}
+
+// Record is what an application recorded about a transaction.
+type Record struct {
+	TransactionUUID string `json:"transaction_uuid"`
+	// the state the transaction should be in, empty when not known.
+	State State `json:"state,omitempty"`
+	// indicates the outcome of the last call made for the transaction was
+	// never seen.
+	Unconfirmed bool `json:"unconfirmed,omitempty"`
+	// the total cost of the transaction, not compared when invalid.
+	Total decimal.NullDecimal `json:"total"`
+	// not compared when empty.
+	CurrencyCode string `json:"currency_code,omitempty"`
+	// the number of seats, not compared when zero.
+	Seats int `json:"seats,omitempty"`
+}
+
+// DiscrepancyKind describes how a transaction differs from its record.
+type DiscrepancyKind string
+
+// The kinds of discrepancy.
+const (
+	// the transaction is still reserved but wasn't recorded as a
+	// reservation. The seats are held until the reservation expires.
+	KindOrphanedReservation DiscrepancyKind = "orphaned_reservation"
+	// the transaction is in a different state than the one recorded.
+	KindStateMismatch DiscrepancyKind = "state_mismatch"
+	// the outcome of the last call was never seen, so the actual state needs
+	// recording.
+	KindUnconfirmed      DiscrepancyKind = "unconfirmed"
+	KindTotalMismatch    DiscrepancyKind = "total_mismatch"
+	KindCurrencyMismatch DiscrepancyKind = "currency_mismatch"
+	KindSeatsMismatch    DiscrepancyKind = "seats_mismatch"
+	// the status couldn't be looked up.
+	KindLookupFailed DiscrepancyKind = "lookup_failed"
+)
+
+// Discrepancy is a difference between a transaction and its record.
+type Discrepancy struct {
+	Kind     DiscrepancyKind `json:"kind"`
+	Expected string          `json:"expected,omitempty"`
+	Actual   string          `json:"actual,omitempty"`
+}
+
+func (discrepancy Discrepancy) String() string {
+	if discrepancy.Expected == "" && discrepancy.Actual == "" {
+		return string(discrepancy.Kind)
+	}
+	if discrepancy.Expected == "" {
+		return fmt.Sprintf("%s: %s", discrepancy.Kind, discrepancy.Actual)
+	}
+	return fmt.Sprintf("%s: expected %s, got %s", discrepancy.Kind, discrepancy.Expected, discrepancy.Actual)
+}
+
+// Result is the reconciled state of a single transaction.
+type Result struct {
+	Record Record `json:"record"`
+	State  State  `json:"state"`
+	// the transaction status reported by the API.
+	Status      string          `json:"status,omitempty"`
+	MinutesLeft float64         `json:"minutes_left,omitempty"`
+	Total       decimal.Decimal `json:"total"`
+	// the currencies of the trolley's bundles, comma separated when there is
+	// more than one.
+	CurrencyCode  string        `json:"currency_code,omitempty"`
+	Seats         int           `json:"seats"`
+	Discrepancies []Discrepancy `json:"discrepancies,omitempty"`
+	// the error looking up the status.
+	Err error `json:"-"`
+}
+
+// newResult summarises a status.
+func newResult(record Record, status *ticketswitch.StatusResult, expiringWithin time.Duration) *Result {
+	result := &Result{
+		Record:      record,
+		State:       Classify(status, expiringWithin),
+		Status:      status.Status,
+		MinutesLeft: status.MinutesLeftOnReserve,
+	}
+	currencies := make([]string, 0, 1)
+	for _, bundle := range status.Trolley.Bundles {
+		result.Total = result.Total.Add(bundle.TotalCost)
+		if bundle.CurrencyCode != "" && !contains(currencies, bundle.CurrencyCode) {
+			currencies = append(currencies, bundle.CurrencyCode)
+		}
+		for _, order := range bundle.Orders {
+			result.Seats += order.TotalNumberOfSeats
+		}
+	}
+	result.CurrencyCode = strings.Join(currencies, ",")
+	result.Discrepancies = result.compare()
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// compare finds the discrepancies between the result and its record.
+func (result *Result) compare() []Discrepancy {
+	record := &result.Record
+	discrepancies := make([]Discrepancy, 0)
+	switch {
+	case result.State.reserved() && !record.State.reserved():
+		discrepancies = append(discrepancies, Discrepancy{
+			Kind: KindOrphanedReservation, Expected: string(record.State), Actual: string(result.State),
+		})
+	case record.Unconfirmed:
+		discrepancies = append(discrepancies, Discrepancy{
+			Kind: KindUnconfirmed, Expected: string(record.State), Actual: string(result.State),
+		})
+	case record.State != "" && !(record.State.reserved() && result.State.reserved()) && record.State != result.State:
+		discrepancies = append(discrepancies, Discrepancy{
+			Kind: KindStateMismatch, Expected: string(record.State), Actual: string(result.State),
+		})
+	}
+	if record.Total.Valid && !record.Total.Decimal.Equal(result.Total) {
+		discrepancies = append(discrepancies, Discrepancy{
+			Kind: KindTotalMismatch, Expected: record.Total.Decimal.String(), Actual: result.Total.String(),
+		})
+	}
+	if record.CurrencyCode != "" && !strings.EqualFold(record.CurrencyCode, result.CurrencyCode) {
+		discrepancies = append(discrepancies, Discrepancy{
+			Kind: KindCurrencyMismatch, Expected: record.CurrencyCode, Actual: result.CurrencyCode,
+		})
+	}
+	if record.Seats != 0 && record.Seats != result.Seats {
+		discrepancies = append(discrepancies, Discrepancy{
+			Kind: KindSeatsMismatch, Expected: fmt.Sprint(record.Seats), Actual: fmt.Sprint(result.Seats),
+		})
+	}
+	return discrepancies
+}
+
+// Report is the outcome of reconciling a set of records.
+type Report struct {
+	// a result for every record, in the order of the records.
+	Results []*Result `json:"results"`
+	// the number of transactions in each state.
+	States map[State]int `json:"states"`
+}
+
+// Discrepant returns the results that have discrepancies.
+func (report *Report) Discrepant() []*Result {
+	results := make([]*Result, 0)
+	for _, result := range report.Results {
+		if len(result.Discrepancies) > 0 {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Counts returns the number of discrepancies of each kind.
+func (report *Report) Counts() map[DiscrepancyKind]int {
+	counts := make(map[DiscrepancyKind]int)
+	for _, result := range report.Results {
+		for _, discrepancy := range result.Discrepancies {
+			counts[discrepancy.Kind]++
+		}
+	}
+	return counts
+}
+
+// Kinds returns the kinds of discrepancy in the report, sorted.
+func (report *Report) Kinds() []DiscrepancyKind {
+	counts := report.Counts()
+	kinds := make([]DiscrepancyKind, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+	return kinds
+}
+
+// Reconciler compares records with the state of their transactions.
+type Reconciler struct {
+	// the number of transactions looked up at once.
+	Concurrency int
+	// reservations with less time than this left are reported as expiring.
+	ExpiringWithin time.Duration
+	// parameters sent with every status request.
+	Params ticketswitch.UniversalParams
+	// called with each result as soon as it's known, from any goroutine.
+	OnResult func(result *Result)
+
+	client ticketswitch.API
+}
+
+// New returns a Reconciler that looks transactions up with the client.
+func New(client ticketswitch.API) *Reconciler {
+	return &Reconciler{
+		Concurrency:    DefaultConcurrency,
+		ExpiringWithin: DefaultExpiringWithin,
+		client:         client,
+	}
+}
+
+// Reconcile looks up every record and reports how the transactions differ
+// from them. A failed lookup is reported as a result with StateError rather
+// than stopping the run; an error is only returned when ctx is done.
+func (reconciler *Reconciler) Reconcile(ctx context.Context, records []Record) (*Report, error) {
+	concurrency := reconciler.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var mu sync.Mutex
+	results := make([]*Result, len(records))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range records {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			result := reconciler.reconcile(ctx, records[i])
+			results[i] = result
+			if reconciler.OnResult != nil {
+				mu.Lock()
+				reconciler.OnResult(result)
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	report := &Report{Results: results, States: make(map[State]int)}
+	for _, result := range results {
+		report.States[result.State]++
+	}
+	return report, nil
+}
+
+func (reconciler *Reconciler) reconcile(ctx context.Context, record Record) *Result {
+	status, err := reconciler.client.GetStatus(ctx, &ticketswitch.TransactionParams{
+		UniversalParams: reconciler.Params,
+		TransactionUUID: record.TransactionUUID,
+	})
+	if err != nil {
+		return &Result{
+			Record:        record,
+			State:         StateError,
+			Discrepancies: []Discrepancy{{Kind: KindLookupFailed, Actual: err.Error()}},
+			Err:           err,
+		}
+	}
+	return newResult(record, status, reconciler.ExpiringWithin)
+}
@@ -0,0 +1,165 @@
+package reconcile
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T) *ticketswitch.Client {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			uuid := r.URL.Query().Get("transaction_uuid")
+			switch {
+			case strings.HasPrefix(uuid, "purchased"):
+				data, _ := os.ReadFile("../testdata/status.json")
+				w.Write(data)
+			case uuid == "reserved":
+				data, _ := os.ReadFile("../testdata/reservation.json")
+				w.Write(data)
+			case uuid == "expiring":
+				w.Write([]byte(`{"transaction_status": "reserved", "minutes_left_on_reserve": 2}`))
+			case uuid == "released":
+				w.Write([]byte(`{"transaction_status": "released"}`))
+			case uuid == "partial":
+				w.Write([]byte(`{"transaction_status": "purchased", "trolley_contents": {"bundle": [{"order": [
+					{"item_number": 1, "cancellation_status": "cancelled"},
+					{"item_number": 2, "cancellation_status": "possible"}
+				]}]}}`))
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error_code": 1000, "error_desc": "transaction not found"}`))
+			}
+		}))
+	t.Cleanup(server.Close)
+	return ticketswitch.NewClient(&ticketswitch.Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+}
+
+func total(s string) decimal.NullDecimal {
+	return decimal.NullDecimal{Decimal: decimal.RequireFromString(s), Valid: true}
+}
+
+func TestClassify(t *testing.T) {
+	status := &ticketswitch.StatusResult{Status: "reserved", MinutesLeftOnReserve: 10}
+	assert.Equal(t, StateReserved, Classify(status, 5*time.Minute))
+	assert.Equal(t, StateExpiring, Classify(status, 10*time.Minute))
+
+	status = &ticketswitch.StatusResult{Status: "purchased"}
+	status.Trolley.Bundles = []ticketswitch.Bundle{{PurchaseResult: ticketswitch.PurchaseResult{IsPartial: true}}}
+	assert.Equal(t, StatePartiallyPurchased, Classify(status, 0))
+
+	status.Trolley.Bundles[0].Orders = []ticketswitch.Order{{CancellationStatus: "cancelled"}}
+	assert.Equal(t, StateCancelled, Classify(status, 0))
+
+	assert.Equal(t, StateOther, Classify(&ticketswitch.StatusResult{Status: "attempting"}, 0))
+}
+
+func TestReconciler_Reconcile(t *testing.T) {
+	reconciler := New(newTestClient(t))
+	reconciler.Concurrency = 2
+	seen := 0
+	reconciler.OnResult = func(result *Result) { seen++ }
+
+	report, err := reconciler.Reconcile(context.Background(), []Record{
+		{TransactionUUID: "purchased-1", State: StatePurchased, Total: total("110"), CurrencyCode: "GBP", Seats: 2},
+		{TransactionUUID: "purchased-2", State: StatePurchased, Total: total("100"), Seats: 3},
+		{TransactionUUID: "reserved", State: StateReleased},
+		{TransactionUUID: "expiring", State: StateReserved},
+		{TransactionUUID: "released", State: StateReserved, Unconfirmed: true},
+		{TransactionUUID: "partial", State: StatePurchased},
+		{TransactionUUID: "missing"},
+	})
+	if !assert.Nil(t, err) || !assert.Len(t, report.Results, 7) {
+		return
+	}
+	assert.Equal(t, 7, seen)
+
+	results := report.Results
+	assert.Equal(t, StatePurchased, results[0].State)
+	assert.Equal(t, "110", results[0].Total.String())
+	assert.Equal(t, "gbp", results[0].CurrencyCode)
+	assert.Equal(t, 2, results[0].Seats)
+	assert.Empty(t, results[0].Discrepancies)
+
+	assert.Equal(t, []Discrepancy{
+		{Kind: KindTotalMismatch, Expected: "100", Actual: "110"},
+		{Kind: KindSeatsMismatch, Expected: "3", Actual: "2"},
+	}, results[1].Discrepancies)
+
+	assert.Equal(t, StateReserved, results[2].State)
+	assert.Equal(t, []Discrepancy{{Kind: KindOrphanedReservation, Expected: "released", Actual: "reserved"}}, results[2].Discrepancies)
+
+	assert.Equal(t, StateExpiring, results[3].State)
+	assert.Empty(t, results[3].Discrepancies)
+
+	assert.Equal(t, []Discrepancy{{Kind: KindUnconfirmed, Expected: "reserved", Actual: "released"}}, results[4].Discrepancies)
+
+	assert.Equal(t, StatePartiallyCancelled, results[5].State)
+	assert.Equal(t, "state_mismatch: expected purchased, got partially_cancelled", results[5].Discrepancies[0].String())
+
+	assert.Equal(t, StateError, results[6].State)
+	assert.NotNil(t, results[6].Err)
+	if assert.Len(t, results[6].Discrepancies, 1) {
+		assert.Equal(t, KindLookupFailed, results[6].Discrepancies[0].Kind)
+	}
+
+	assert.Len(t, report.Discrepant(), 5)
+	assert.Equal(t, 2, report.States[StatePurchased])
+	assert.Equal(t, []DiscrepancyKind{
+		KindLookupFailed, KindOrphanedReservation, KindSeatsMismatch, KindStateMismatch, KindTotalMismatch, KindUnconfirmed,
+	}, report.Kinds())
+}
+
+func TestReconciler_Reconcile_cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := New(newTestClient(t)).Reconcile(ctx, []Record{{TransactionUUID: "reserved"}})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestFromJournal(t *testing.T) {
+	entries := []ticketswitch.JournalEntry{
+		{ID: "1", Call: "reserve.v1", Phase: ticketswitch.JournalStarted},
+		{ID: "1", Call: "reserve.v1", Phase: ticketswitch.JournalFinished, TransactionUUID: "a", Outcome: ticketswitch.OutcomeSucceeded, Status: "reserved"},
+		{ID: "2", Call: "reserve.v1", Phase: ticketswitch.JournalStarted},
+		{ID: "3", Call: "purchase.v1", Phase: ticketswitch.JournalStarted, TransactionUUID: "a"},
+		{ID: "3", Call: "purchase.v1", Phase: ticketswitch.JournalFinished, TransactionUUID: "a", Outcome: ticketswitch.OutcomeSucceeded, Status: "purchased"},
+		{ID: "4", Call: "reserve.v1", Phase: ticketswitch.JournalFinished, TransactionUUID: "b", Outcome: ticketswitch.OutcomeSucceeded, Status: "reserved"},
+		{ID: "5", Call: "release.v1", Phase: ticketswitch.JournalStarted, TransactionUUID: "b"},
+		{ID: "5", Call: "release.v1", Phase: ticketswitch.JournalFinished, TransactionUUID: "b", Outcome: ticketswitch.OutcomeUnknown},
+		{ID: "6", Call: "cancel.v1", Phase: ticketswitch.JournalStarted, TransactionUUID: "a"},
+		{ID: "6", Call: "cancel.v1", Phase: ticketswitch.JournalFinished, TransactionUUID: "a", Outcome: ticketswitch.OutcomeSucceeded, Status: "not_cancelled"},
+		{ID: "7", Call: "purchase.v1", Phase: ticketswitch.JournalStarted, TransactionUUID: "c"},
+	}
+	assert.Equal(t, []Record{
+		{TransactionUUID: "a", State: StatePurchased},
+		{TransactionUUID: "b", State: StateReserved, Unconfirmed: true},
+		{TransactionUUID: "c", Unconfirmed: true},
+	}, FromJournal(entries))
+}
+
+func TestReadRecords(t *testing.T) {
+	records, err := ReadRecords(strings.NewReader(
+		"Transaction_UUID,state,total,currency_code,seats,notes\n" +
+			"abc,purchased,110.00,gbp,2,vip\n" +
+			",,,,,\n" +
+			"def,,,,\n"))
+	if assert.Nil(t, err) && assert.Len(t, records, 2) {
+		assert.Equal(t, Record{TransactionUUID: "abc", State: StatePurchased, Total: total("110.00"), CurrencyCode: "gbp", Seats: 2}, records[0])
+		assert.Equal(t, Record{TransactionUUID: "def"}, records[1])
+	}
+
+	_, err = ReadRecords(strings.NewReader("uuid\nabc\n"))
+	assert.EqualError(t, err, "ticketswitch: records have no transaction_uuid column")
+
+	_, err = ReadRecords(strings.NewReader("transaction_uuid,seats\nabc,two\n"))
+	assert.EqualError(t, err, `ticketswitch: records line 2: bad seats "two"`)
+}
@@ -0,0 +1,158 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/shopspring/decimal"
+)
+
+// FromJournal returns a record for every transaction in the journal entries,
+// in the order they first appear, with the state left by the last call whose
+// outcome was seen. Calls that never finished, or finished with an unknown
+// outcome, mark the record as unconfirmed. Reservations that never returned a
+// transaction can't be looked up and are skipped.
+func FromJournal(entries []ticketswitch.JournalEntry) []Record {
+	records := make([]Record, 0)
+	index := make(map[string]int)
+	// started calls by journal id, removed once they finish.
+	pending := make(map[string]string)
+
+	record := func(uuid string) *Record {
+		i, ok := index[uuid]
+		if !ok {
+			i = len(records)
+			index[uuid] = i
+			records = append(records, Record{TransactionUUID: uuid})
+		}
+		return &records[i]
+	}
+
+	for _, entry := range entries {
+		if entry.Phase == ticketswitch.JournalStarted {
+			pending[entry.ID] = entry.TransactionUUID
+			if entry.TransactionUUID != "" {
+				record(entry.TransactionUUID)
+			}
+			continue
+		}
+		delete(pending, entry.ID)
+		if entry.TransactionUUID == "" {
+			continue
+		}
+		r := record(entry.TransactionUUID)
+		switch entry.Outcome {
+		case ticketswitch.OutcomeSucceeded:
+			if state := journalState(entry); state != "" {
+				r.State = state
+			}
+			r.Unconfirmed = false
+		case ticketswitch.OutcomeUnknown:
+			r.Unconfirmed = true
+		}
+	}
+
+	for _, uuid := range pending {
+		if uuid != "" {
+			record(uuid).Unconfirmed = true
+		}
+	}
+	return records
+}
+
+// journalState returns the state left by a successful call, or an empty
+// state when the call didn't change it.
+func journalState(entry ticketswitch.JournalEntry) State {
+	switch entry.Call {
+	case "reserve.v1":
+		return StateReserved
+	case "purchase.v1", "callback.v1":
+		if entry.Status == "purchased" {
+			return StatePurchased
+		}
+	case "release.v1":
+		if entry.Status == "released" {
+			return StateReleased
+		}
+	case "cancel.v1":
+		switch entry.Status {
+		//nolint:misspell
+		case "cancelled":
+			return StateCancelled
+		case "partially_cancelled":
+			return StatePartiallyCancelled
+		}
+	}
+	return ""
+}
+
+// RecordColumns are the columns understood by ReadRecords. Only
+// transaction_uuid is required.
+var RecordColumns = []string{"transaction_uuid", "state", "total", "currency_code", "seats"}
+
+// ReadRecords reads records from CSV with a header row naming the columns,
+// see RecordColumns. Other columns are ignored and empty values are not
+// compared.
+func ReadRecords(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("ticketswitch: records have no header")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ticketswitch: reading records: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	if _, ok := columns["transaction_uuid"]; !ok {
+		return nil, errors.New("ticketswitch: records have no transaction_uuid column")
+	}
+
+	records := make([]Record, 0)
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, fmt.Errorf("ticketswitch: reading records: %w", err)
+		}
+		value := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		record := Record{
+			TransactionUUID: value("transaction_uuid"),
+			State:           State(value("state")),
+			CurrencyCode:    value("currency_code"),
+		}
+		if record.TransactionUUID == "" {
+			continue
+		}
+		if total := value("total"); total != "" {
+			d, err := decimal.NewFromString(total)
+			if err != nil {
+				return records, fmt.Errorf("ticketswitch: records line %d: bad total %q", line, total)
+			}
+			record.Total = decimal.NullDecimal{Decimal: d, Valid: true}
+		}
+		if seats := value("seats"); seats != "" {
+			if record.Seats, err = strconv.Atoi(seats); err != nil {
+				return records, fmt.Errorf("ticketswitch: records line %d: bad seats %q", line, seats)
+			}
+		}
+		records = append(records, record)
+	}
+}
@@ -0,0 +1,337 @@
+// Package reporting sums the commission earned on purchased transactions for
+// finance reporting.
+//
+// A Report adds up user commission, gross commission and agent cost per
+// period, currency, source and event over the status of each transaction.
+// Statuses can come from an application's own GetStatus calls or be looked up
+// for the purchases in a transaction journal with FromJournal:
+//
+//	entries, err := ticketswitch.ReadJournal(file)
+//	statuses, err := reporting.FromJournal(ctx, client, entries, ticketswitch.UniversalParams{})
+//	report := reporting.New(reporting.Monthly)
+//	for _, status := range statuses {
+//		report.Add(status)
+//	}
+//	err = report.WriteCSV(w)
+//
+// Request statuses with ticketswitch.UniversalParams.SourceInfo set for
+// source descriptions to be included.
+package reporting
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/shopspring/decimal"
+)
+
+// Period is the length of time a report row covers.
+type Period string
+
+// The periods a report can be broken down by.
+const (
+	Daily   Period = "daily"
+	Monthly Period = "monthly"
+	Yearly  Period = "yearly"
+	// a single row per currency, source and event.
+	AllTime Period = "all"
+)
+
+func (period Period) label(t time.Time) string {
+	switch period {
+	case Daily:
+		return t.Format("2006-01-02")
+	case Monthly:
+		return t.Format("2006-01")
+	case Yearly:
+		return t.Format("2006")
+	}
+	return ""
+}
+
+// Key identifies a row of a report.
+type Key struct {
+	// the date, month or year of the purchases, empty for AllTime.
+	Period       string
+	CurrencyCode string
+	SourceCode   string
+	// empty for agent costs of bundles with orders for more than one event.
+	EventID string
+}
+
+// Row is the commission earned on the purchases sharing a Key.
+type Row struct {
+	Key
+	SourceDesc string
+	EventDesc  string
+	// the number of transactions, orders and seats the row includes.
+	Transactions          int
+	Orders                int
+	Seats                 int
+	UserCommissionIncVat  decimal.Decimal
+	UserCommissionExVat   decimal.Decimal
+	GrossCommissionIncVat decimal.Decimal
+	GrossCommissionExVat  decimal.Decimal
+	AgentCost             decimal.Decimal
+}
+
+// Report sums commission over the transactions added to it.
+type Report struct {
+	// the time zone purchases are assigned to periods in, defaults to UTC.
+	Location *time.Location
+
+	period Period
+	rows   map[Key]*row
+	seen   map[string]bool
+	places map[string]int
+}
+
+type row struct {
+	Row
+	transactions map[string]bool
+}
+
+// New returns an empty Report broken down by the period.
+func New(period Period) *Report {
+	return &Report{
+		period: period,
+		rows:   make(map[Key]*row),
+		seen:   make(map[string]bool),
+		places: make(map[string]int),
+	}
+}
+
+func (report *Report) row(key Key) *row {
+	r, ok := report.rows[key]
+	if !ok {
+		r = &row{Row: Row{Key: key}, transactions: make(map[string]bool)}
+		report.rows[key] = r
+	}
+	return r
+}
+
+// Add adds the orders of a purchased transaction to the report. Transactions
+// that were never purchased, cancelled orders and transactions that have
+// already been added are ignored.
+//
+// Commission is reported in the currency it is paid in. The agent cost of a
+// bundle is reported against its event when every counted order in the bundle
+// is for the same event, and with an empty EventID otherwise.
+func (report *Report) Add(status *ticketswitch.StatusResult) {
+	uuid := status.Trolley.TransactionUUID
+	if status.PurchaseDatetime.IsZero() || (uuid != "" && report.seen[uuid]) {
+		return
+	}
+	report.seen[uuid] = true
+
+	location := report.Location
+	if location == nil {
+		location = time.UTC
+	}
+	period := report.period.label(status.PurchaseDatetime.In(location))
+	for code, currency := range status.CurrencyDetails {
+		report.places[code] = currency.Places
+	}
+
+	for _, bundle := range status.Trolley.Bundles {
+		eventID, events := "", 0
+		for i := range bundle.Orders {
+			order := &bundle.Orders[i]
+			if order.CancellationStatus == "cancelled" {
+				continue
+			}
+			if events == 0 || order.Event.ID != eventID {
+				events++
+				eventID = order.Event.ID
+			}
+
+			currency := order.UserCommission.CurrencyCode
+			if currency == "" {
+				currency = order.GrossCommission.CurrencyCode
+			}
+			if currency == "" {
+				currency = bundle.CurrencyCode
+			}
+			r := report.row(Key{Period: period, CurrencyCode: currency, SourceCode: bundle.SourceCode, EventID: order.Event.ID})
+			r.SourceDesc = bundle.SourceDesc
+			r.EventDesc = order.Event.Description
+			r.transactions[uuid] = true
+			r.Orders++
+			r.Seats += order.TotalNumberOfSeats
+			r.UserCommissionIncVat = r.UserCommissionIncVat.Add(order.UserCommission.IncVat)
+			r.UserCommissionExVat = r.UserCommissionExVat.Add(order.UserCommission.ExVat)
+			r.GrossCommissionIncVat = r.GrossCommissionIncVat.Add(order.GrossCommission.IncVat)
+			r.GrossCommissionExVat = r.GrossCommissionExVat.Add(order.GrossCommission.ExVat)
+		}
+
+		agentCost := bundle.PurchaseResult.AgentCost
+		if events == 0 || agentCost.TotalAgentCost.IsZero() {
+			continue
+		}
+		if events > 1 {
+			eventID = ""
+		}
+		currency := agentCost.CurrencyCode
+		if currency == "" {
+			currency = bundle.CurrencyCode
+		}
+		r := report.row(Key{Period: period, CurrencyCode: currency, SourceCode: bundle.SourceCode, EventID: eventID})
+		r.SourceDesc = bundle.SourceDesc
+		r.transactions[uuid] = true
+		r.AgentCost = r.AgentCost.Add(agentCost.TotalAgentCost)
+	}
+}
+
+// Rows returns the rows of the report ordered by period, currency, source and
+// event.
+func (report *Report) Rows() []Row {
+	rows := make([]Row, 0, len(report.rows))
+	for _, r := range report.rows {
+		row := r.Row
+		row.Transactions = len(r.transactions)
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i].Key, rows[j].Key
+		if a.Period != b.Period {
+			return a.Period < b.Period
+		}
+		if a.CurrencyCode != b.CurrencyCode {
+			return a.CurrencyCode < b.CurrencyCode
+		}
+		if a.SourceCode != b.SourceCode {
+			return a.SourceCode < b.SourceCode
+		}
+		return a.EventID < b.EventID
+	})
+	return rows
+}
+
+// Totals returns a row per currency summing every period, source and event,
+// ordered by currency.
+func (report *Report) Totals() []Row {
+	totals := make(map[string]*Row)
+	transactions := make(map[string]map[string]bool)
+	for _, r := range report.rows {
+		total, ok := totals[r.CurrencyCode]
+		if !ok {
+			total = &Row{Key: Key{CurrencyCode: r.CurrencyCode}}
+			totals[r.CurrencyCode] = total
+			transactions[r.CurrencyCode] = make(map[string]bool)
+		}
+		for uuid := range r.transactions {
+			transactions[r.CurrencyCode][uuid] = true
+		}
+		total.Orders += r.Orders
+		total.Seats += r.Seats
+		total.UserCommissionIncVat = total.UserCommissionIncVat.Add(r.UserCommissionIncVat)
+		total.UserCommissionExVat = total.UserCommissionExVat.Add(r.UserCommissionExVat)
+		total.GrossCommissionIncVat = total.GrossCommissionIncVat.Add(r.GrossCommissionIncVat)
+		total.GrossCommissionExVat = total.GrossCommissionExVat.Add(r.GrossCommissionExVat)
+		total.AgentCost = total.AgentCost.Add(r.AgentCost)
+	}
+
+	rows := make([]Row, 0, len(totals))
+	for code, total := range totals {
+		total.Transactions = len(transactions[code])
+		rows = append(rows, *total)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].CurrencyCode < rows[j].CurrencyCode })
+	return rows
+}
+
+// Columns is the header of the CSV written by WriteCSV. Amounts are written
+// to the number of decimal places of their currency when it is known.
+var Columns = []string{
+	"period",
+	"currency_code",
+	"source_code",
+	"source_desc",
+	"event_id",
+	"event_desc",
+	"transactions",
+	"orders",
+	"seats",
+	"user_commission_inc_vat",
+	"user_commission_ex_vat",
+	"gross_commission_inc_vat",
+	"gross_commission_ex_vat",
+	"agent_cost",
+}
+
+// WriteCSV writes the rows of the report as CSV with a Columns header.
+func (report *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns); err != nil {
+		return err
+	}
+	for _, row := range report.Rows() {
+		amount := func(value decimal.Decimal) string {
+			if places, ok := report.places[row.CurrencyCode]; ok {
+				return value.StringFixed(int32(places))
+			}
+			return value.String()
+		}
+		if err := cw.Write([]string{
+			row.Period,
+			row.CurrencyCode,
+			row.SourceCode,
+			row.SourceDesc,
+			row.EventID,
+			row.EventDesc,
+			strconv.Itoa(row.Transactions),
+			strconv.Itoa(row.Orders),
+			strconv.Itoa(row.Seats),
+			amount(row.UserCommissionIncVat),
+			amount(row.UserCommissionExVat),
+			amount(row.GrossCommissionIncVat),
+			amount(row.GrossCommissionExVat),
+			amount(row.AgentCost),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// FromJournal looks up the status of every transaction the journal entries
+// record a successful purchase or callback for, in the order they first
+// appear. The status is looked up rather than taken from the journal so that
+// later cancellations are reflected. It stops at the first lookup that fails,
+// as a report missing a transaction would be wrong.
+func FromJournal(ctx context.Context, client ticketswitch.API, entries []ticketswitch.JournalEntry, params ticketswitch.UniversalParams) ([]*ticketswitch.StatusResult, error) {
+	var uuids []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Phase != ticketswitch.JournalFinished || entry.Outcome != ticketswitch.OutcomeSucceeded {
+			continue
+		}
+		if entry.Call != "purchase.v1" && entry.Call != "callback.v1" {
+			continue
+		}
+		if entry.TransactionUUID != "" && !seen[entry.TransactionUUID] {
+			seen[entry.TransactionUUID] = true
+			uuids = append(uuids, entry.TransactionUUID)
+		}
+	}
+
+	statuses := make([]*ticketswitch.StatusResult, 0, len(uuids))
+	for _, uuid := range uuids {
+		status, err := client.GetStatus(ctx, &ticketswitch.TransactionParams{
+			UniversalParams: params,
+			TransactionUUID: uuid,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ticketswitch: looking up %s: %w", uuid, err)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,174 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/ingresso-group/goticketswitch.v2/mock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadStatus(t *testing.T) *ticketswitch.StatusResult {
+	data, err := os.ReadFile("../testdata/status.json")
+	if err != nil {
+		t.Fatalf("Cannot find testdata/status.json")
+	}
+	var status ticketswitch.StatusResult
+	if err := json.Unmarshal(data, &status); err != nil {
+		t.Fatal(err)
+	}
+	return &status
+}
+
+func commissionedStatus(uuid string, purchased time.Time, orders ...ticketswitch.Order) *ticketswitch.StatusResult {
+	return &ticketswitch.StatusResult{
+		PurchaseDatetime: purchased,
+		Trolley: ticketswitch.Trolley{
+			TransactionUUID: uuid,
+			Bundles: []ticketswitch.Bundle{{
+				SourceCode:   "ext_test0",
+				CurrencyCode: "gbp",
+				Orders:       orders,
+				PurchaseResult: ticketswitch.PurchaseResult{
+					AgentCost: ticketswitch.AgentCost{CurrencyCode: "gbp", TotalAgentCost: decimal.NewFromInt(20)},
+				},
+			}},
+		},
+	}
+}
+
+func commissionedOrder(eventID string, seats int, user, gross string) ticketswitch.Order {
+	return ticketswitch.Order{
+		Event:              ticketswitch.Event{ID: eventID, Description: "Event " + eventID},
+		TotalNumberOfSeats: seats,
+		UserCommission:     ticketswitch.UserCommission{IncVat: decimal.RequireFromString(user), ExVat: decimal.RequireFromString(user).Div(decimal.RequireFromString("1.2")), CurrencyCode: "gbp"},
+		GrossCommission:    ticketswitch.GrossCommission{IncVat: decimal.RequireFromString(gross), ExVat: decimal.RequireFromString(gross).Div(decimal.RequireFromString("1.2")), CurrencyCode: "gbp"},
+	}
+}
+
+func TestReport_Add(t *testing.T) {
+	report := New(Monthly)
+	report.Add(loadStatus(t))
+	// the same transaction again is ignored.
+	report.Add(loadStatus(t))
+
+	rows := report.Rows()
+	if assert.Len(t, rows, 1) {
+		row := rows[0]
+		assert.Equal(t, Key{Period: "2018-05", CurrencyCode: "gbp", SourceCode: "ext_test1", EventID: "7AB"}, row.Key)
+		assert.Equal(t, "External Test Backend 1", row.SourceDesc)
+		assert.Equal(t, 1, row.Transactions)
+		assert.Equal(t, 1, row.Orders)
+		assert.Equal(t, 2, row.Seats)
+		assert.True(t, row.UserCommissionIncVat.Equal(decimal.NewFromInt(10)))
+		assert.True(t, row.UserCommissionExVat.Equal(decimal.RequireFromString("8.33")))
+		assert.True(t, row.GrossCommissionIncVat.Equal(decimal.NewFromInt(15)))
+		assert.True(t, row.AgentCost.Equal(decimal.NewFromInt(99)))
+	}
+
+	report.Add(&ticketswitch.StatusResult{Status: "reserved"})
+	assert.Len(t, report.Rows(), 1)
+}
+
+func TestReport_periods_and_totals(t *testing.T) {
+	may := time.Date(2024, 5, 31, 23, 30, 0, 0, time.UTC)
+	june := time.Date(2024, 6, 2, 12, 0, 0, 0, time.UTC)
+	cancelled := commissionedOrder("6IF", 2, "100", "100")
+	cancelled.CancellationStatus = "cancelled"
+
+	report := New(Monthly)
+	report.Add(commissionedStatus("t1", may, commissionedOrder("6IF", 2, "6", "12")))
+	report.Add(commissionedStatus("t2", may, commissionedOrder("6IF", 1, "3", "6"), cancelled))
+	report.Add(commissionedStatus("t3", june, commissionedOrder("6IF", 2, "6", "12"), commissionedOrder("7AB", 4, "12", "24")))
+
+	rows := report.Rows()
+	if assert.Len(t, rows, 4) {
+		assert.Equal(t, Key{Period: "2024-05", CurrencyCode: "gbp", SourceCode: "ext_test0", EventID: "6IF"}, rows[0].Key)
+		assert.Equal(t, 2, rows[0].Transactions)
+		assert.Equal(t, 2, rows[0].Orders)
+		assert.Equal(t, 3, rows[0].Seats)
+		assert.Equal(t, "9", rows[0].UserCommissionIncVat.String())
+		assert.Equal(t, "40", rows[0].AgentCost.String())
+
+		// agent cost of a bundle with two events has no event.
+		assert.Equal(t, Key{Period: "2024-06", CurrencyCode: "gbp", SourceCode: "ext_test0"}, rows[1].Key)
+		assert.Equal(t, "20", rows[1].AgentCost.String())
+		assert.Equal(t, 0, rows[1].Orders)
+		assert.Equal(t, "6IF", rows[2].EventID)
+		assert.Equal(t, "7AB", rows[3].EventID)
+	}
+
+	totals := report.Totals()
+	if assert.Len(t, totals, 1) {
+		assert.Equal(t, "gbp", totals[0].CurrencyCode)
+		assert.Equal(t, 3, totals[0].Transactions)
+		assert.Equal(t, 4, totals[0].Orders)
+		assert.Equal(t, "27", totals[0].UserCommissionIncVat.String())
+		assert.Equal(t, "54", totals[0].GrossCommissionIncVat.String())
+		assert.Equal(t, "60", totals[0].AgentCost.String())
+	}
+
+	// in New York the late May purchase is still in May, the same day.
+	newYork, err := time.LoadLocation("America/New_York")
+	if err == nil {
+		daily := New(Daily)
+		daily.Location = newYork
+		daily.Add(commissionedStatus("t1", may, commissionedOrder("6IF", 2, "6", "12")))
+		assert.Equal(t, "2024-05-31", daily.Rows()[0].Period)
+	}
+}
+
+func TestReport_WriteCSV(t *testing.T) {
+	report := New(Yearly)
+	report.Add(loadStatus(t))
+
+	var buf bytes.Buffer
+	assert.Nil(t, report.WriteCSV(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, strings.Join(Columns, ","), lines[0])
+		assert.Equal(t, "2018,gbp,ext_test1,External Test Backend 1,7AB,TEST EVENT - The Unremarkable Incident of the Cat at Lunchtime,1,1,2,10.00,8.33,15.00,12.50,99.00", lines[1])
+	}
+}
+
+func TestFromJournal(t *testing.T) {
+	status := loadStatus(t)
+	api := &mock.API{
+		GetStatusFunc: func(ctx context.Context, params *ticketswitch.TransactionParams) (*ticketswitch.StatusResult, error) {
+			if params.TransactionUUID == "broken" {
+				return nil, errors.New("connection reset")
+			}
+			return status, nil
+		},
+	}
+	entries := []ticketswitch.JournalEntry{
+		{Call: "reserve.v1", Phase: ticketswitch.JournalFinished, Outcome: ticketswitch.OutcomeSucceeded, TransactionUUID: "reserved-only"},
+		{Call: "purchase.v1", Phase: ticketswitch.JournalStarted, TransactionUUID: "t1"},
+		{Call: "purchase.v1", Phase: ticketswitch.JournalFinished, Outcome: ticketswitch.OutcomeSucceeded, TransactionUUID: "t1"},
+		{Call: "purchase.v1", Phase: ticketswitch.JournalFinished, Outcome: ticketswitch.OutcomeFailed, TransactionUUID: "t2"},
+		{Call: "callback.v1", Phase: ticketswitch.JournalFinished, Outcome: ticketswitch.OutcomeSucceeded, TransactionUUID: "t3"},
+		{Call: "callback.v1", Phase: ticketswitch.JournalFinished, Outcome: ticketswitch.OutcomeSucceeded, TransactionUUID: "t1"},
+	}
+
+	statuses, err := FromJournal(context.Background(), api, entries, ticketswitch.UniversalParams{})
+	if assert.Nil(t, err) {
+		assert.Len(t, statuses, 2)
+		calls := api.CallsTo("GetStatus")
+		if assert.Len(t, calls, 2) {
+			assert.Equal(t, "t1", calls[0].Args[0].(*ticketswitch.TransactionParams).TransactionUUID)
+			assert.Equal(t, "t3", calls[1].Args[0].(*ticketswitch.TransactionParams).TransactionUUID)
+		}
+	}
+
+	entries = append(entries, ticketswitch.JournalEntry{Call: "purchase.v1", Phase: ticketswitch.JournalFinished, Outcome: ticketswitch.OutcomeSucceeded, TransactionUUID: "broken"})
+	_, err = FromJournal(context.Background(), api, entries, ticketswitch.UniversalParams{})
+	assert.EqualError(t, err, "ticketswitch: looking up broken: connection reset")
+}
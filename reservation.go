@@ -22,37 +22,50 @@ type ReservationResult struct {
 	Status                         string              `json:"transaction_status"`
 	Trolley                        Trolley             `json:"trolley_contents"`
 	UnreservedOrders               []Order             `json:"unreserved_orders"`
+	// how a request for specific seats was met, nil if none were requested.
+	SeatRequestOutcome *SeatRequestOutcome `json:"-"`
 }
 
 // MakeReservationParams stores the parameters for making a reservation
 type MakeReservationParams struct {
 	UniversalParams
-	DepartureDate  time.Time
+	DepartureDate  time.Time // Required if the event needs a departure date
+	Duration       int       // Required if the event needs a duration
 	Discounts      []string
-	NumberOfSeats  int    // Required
-	PerformanceID  string // Required
-	PriceBandCode  string // Required
+	NumberOfSeats  int    // Required unless reserving a trolley
+	PerformanceID  string // Required unless reserving a trolley
+	PriceBandCode  string // Required unless reserving a trolley
 	Seats          []string
 	SendMethod     string
 	SourceCode     string // Required if specifying the send method
-	TicketTypeCode string // Required
+	TicketTypeCode string // Required unless reserving a trolley
 	TrolleyToken   string
 	UserCommission bool
+	// release the reservation and return a SeatRequestError unless exactly
+	// the requested seats were reserved.
+	RequireRequestedSeats bool
 }
 
 // Params returns the call parameters as a map
 func (params *MakeReservationParams) Params() map[string]string {
 	values := make(map[string]string)
 
-	values["no_of_seats"] = strconv.Itoa(params.NumberOfSeats)
-	values["perf_id"] = params.PerformanceID
-	values["price_band_code"] = params.PriceBandCode
-	values["ticket_type_code"] = params.TicketTypeCode
+	// a trolley token on its own reserves the orders already in the trolley.
+	if params.PerformanceID != "" || params.TrolleyToken == "" {
+		values["no_of_seats"] = strconv.Itoa(params.NumberOfSeats)
+		values["perf_id"] = params.PerformanceID
+		values["price_band_code"] = params.PriceBandCode
+		values["ticket_type_code"] = params.TicketTypeCode
+	}
 
 	if !params.DepartureDate.IsZero() {
 		values["departure_date"] = params.DepartureDate.Format("20060102")
 	}
 
+	if params.Duration > 0 {
+		values["duration"] = strconv.Itoa(params.Duration)
+	}
+
 	for index, disc := range params.Discounts {
 		values[fmt.Sprintf("disc%d", index)] = disc
 	}
@@ -0,0 +1,154 @@
+package ticketswitch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SeatRequestOutcome describes how a request for specific seats was met.
+type SeatRequestOutcome struct {
+	// the seat ids that were asked for.
+	Requested []string
+	// the requested seats that were reserved.
+	Granted []string
+	// the seats that were reserved in place of requested seats.
+	Substituted []string
+	// the requested seats that were not reserved.
+	Missing []string
+}
+
+// Exact checks if exactly the requested seats were reserved.
+func (outcome *SeatRequestOutcome) Exact() bool {
+	return len(outcome.Missing) == 0 && len(outcome.Substituted) == 0
+}
+
+func (outcome *SeatRequestOutcome) add(other *SeatRequestOutcome) {
+	outcome.Requested = append(outcome.Requested, other.Requested...)
+	outcome.Granted = append(outcome.Granted, other.Granted...)
+	outcome.Substituted = append(outcome.Substituted, other.Substituted...)
+	outcome.Missing = append(outcome.Missing, other.Missing...)
+}
+
+// ReservedSeatIDs returns the ids of the seats reserved for the order, if the
+// API has listed them.
+func (order *Order) ReservedSeatIDs() []string {
+	var ids []string
+	for _, ticketOrder := range order.TicketOrdersHolder.TicketOrders {
+		for _, seat := range ticketOrder.Seats {
+			ids = append(ids, seat.FullID)
+		}
+	}
+	return ids
+}
+
+// SeatRequestOutcome compares the seats requested for the order with the
+// seats reserved. It returns nil when no specific seats were requested.
+func (order *Order) SeatRequestOutcome() *SeatRequestOutcome {
+	if len(order.RequestedSeatIDs) == 0 {
+		return nil
+	}
+	outcome := &SeatRequestOutcome{Requested: order.RequestedSeatIDs}
+
+	// pool seats may not be listed, so trust the API when it says the
+	// requested seats were got.
+	reserved := order.ReservedSeatIDs()
+	if order.GotRequestedSeats && len(reserved) == 0 {
+		outcome.Granted = order.RequestedSeatIDs
+		return outcome
+	}
+	return compareSeats(order.RequestedSeatIDs, reserved)
+}
+
+// compareSeats compares the ids of the requested seats with those of the
+// reserved seats.
+func compareSeats(requested, reserved []string) *SeatRequestOutcome {
+	outcome := &SeatRequestOutcome{Requested: requested}
+	wanted := make(map[string]bool, len(requested))
+	for _, id := range requested {
+		wanted[id] = true
+	}
+	got := make(map[string]bool, len(reserved))
+	for _, id := range reserved {
+		got[id] = true
+		if !wanted[id] {
+			outcome.Substituted = append(outcome.Substituted, id)
+		}
+	}
+	for _, id := range requested {
+		if got[id] {
+			outcome.Granted = append(outcome.Granted, id)
+		} else {
+			outcome.Missing = append(outcome.Missing, id)
+		}
+	}
+	return outcome
+}
+
+// seatRequestOutcome combines the outcomes of every order in the reservation,
+// counting the requested seats of unreserved orders as missing. When the API
+// doesn't say which seats were requested, the requested seats are compared
+// with every seat reserved instead, so a reservation that lists no seats has
+// them all missing. It returns nil when no specific seats were requested.
+func (result *ReservationResult) seatRequestOutcome(requested []string) *SeatRequestOutcome {
+	var outcome *SeatRequestOutcome
+	add := func(other *SeatRequestOutcome) {
+		if other == nil {
+			return
+		}
+		if outcome == nil {
+			outcome = &SeatRequestOutcome{}
+		}
+		outcome.add(other)
+	}
+
+	for _, bundle := range result.Trolley.Bundles {
+		for i := range bundle.Orders {
+			add(bundle.Orders[i].SeatRequestOutcome())
+		}
+	}
+	for _, order := range result.UnreservedOrders {
+		if len(order.RequestedSeatIDs) > 0 {
+			add(&SeatRequestOutcome{Requested: order.RequestedSeatIDs, Missing: order.RequestedSeatIDs})
+		}
+	}
+	if outcome != nil || len(requested) == 0 {
+		return outcome
+	}
+
+	var reserved []string
+	for _, bundle := range result.Trolley.Bundles {
+		for i := range bundle.Orders {
+			reserved = append(reserved, bundle.Orders[i].ReservedSeatIDs()...)
+		}
+	}
+	return compareSeats(requested, reserved)
+}
+
+// SeatRequestError is returned by MakeReservation when
+// MakeReservationParams.RequireRequestedSeats is set and the requested seats
+// weren't reserved exactly.
+type SeatRequestError struct {
+	Outcome         *SeatRequestOutcome
+	TransactionUUID string
+	// set when the reservation was released.
+	Released bool
+	// the error from releasing the reservation, if any.
+	ReleaseErr error
+}
+
+func (err *SeatRequestError) Error() string {
+	var problems []string
+	if len(err.Outcome.Missing) > 0 {
+		problems = append(problems, "missing "+strings.Join(err.Outcome.Missing, ", "))
+	}
+	if len(err.Outcome.Substituted) > 0 {
+		problems = append(problems, "substituted "+strings.Join(err.Outcome.Substituted, ", "))
+	}
+	message := "ticketswitch: requested seats not reserved: " + strings.Join(problems, "; ")
+	if err.ReleaseErr != nil {
+		message += fmt.Sprintf(" (release of %s failed: %v)", err.TransactionUUID, err.ReleaseErr)
+	} else if err.TransactionUUID != "" && !err.Released {
+		message += fmt.Sprintf(" (%s was not released)", err.TransactionUUID)
+	}
+	return message
+}
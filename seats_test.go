@@ -0,0 +1,164 @@
+package ticketswitch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrder_SeatRequestOutcome(t *testing.T) {
+	order := Order{}
+	assert.Nil(t, order.SeatRequestOutcome())
+
+	order = Order{
+		RequestedSeatIDs: []string{"A1", "A2", "A3"},
+		TicketOrdersHolder: TicketOrdersHolder{TicketOrders: []TicketOrder{
+			{Seats: []Seat{{FullID: "A1"}, {FullID: "A2"}}},
+			{Seats: []Seat{{FullID: "B7"}}},
+		}},
+	}
+	outcome := order.SeatRequestOutcome()
+	assert.Equal(t, &SeatRequestOutcome{
+		Requested:   []string{"A1", "A2", "A3"},
+		Granted:     []string{"A1", "A2"},
+		Substituted: []string{"B7"},
+		Missing:     []string{"A3"},
+	}, outcome)
+	assert.False(t, outcome.Exact())
+
+	// pool seats aren't always listed.
+	order = Order{RequestedSeatIDs: []string{"A1"}, GotRequestedSeats: true}
+	outcome = order.SeatRequestOutcome()
+	assert.Equal(t, []string{"A1"}, outcome.Granted)
+	assert.True(t, outcome.Exact())
+}
+
+func TestReservationResult_seatRequestOutcome(t *testing.T) {
+	result := ReservationResult{
+		Trolley: Trolley{Bundles: []Bundle{{Orders: []Order{
+			{RequestedSeatIDs: []string{"A1"}, GotRequestedSeats: true},
+			{},
+		}}}},
+		UnreservedOrders: []Order{{RequestedSeatIDs: []string{"C1", "C2"}}},
+	}
+	assert.Equal(t, &SeatRequestOutcome{
+		Requested: []string{"A1", "C1", "C2"},
+		Granted:   []string{"A1"},
+		Missing:   []string{"C1", "C2"},
+	}, result.seatRequestOutcome(nil))
+
+	assert.Nil(t, (&ReservationResult{}).seatRequestOutcome(nil))
+
+	// the requested seats are compared with the reserved ones when the API
+	// doesn't echo them.
+	result = ReservationResult{
+		Trolley: Trolley{Bundles: []Bundle{{Orders: []Order{
+			{TicketOrdersHolder: TicketOrdersHolder{TicketOrders: []TicketOrder{
+				{Seats: []Seat{{FullID: "A1"}, {FullID: "A3"}}},
+			}}},
+		}}}},
+	}
+	assert.Equal(t, &SeatRequestOutcome{
+		Requested:   []string{"A1", "A2"},
+		Granted:     []string{"A1"},
+		Substituted: []string{"A3"},
+		Missing:     []string{"A2"},
+	}, result.seatRequestOutcome([]string{"A1", "A2"}))
+
+	outcome := (&ReservationResult{}).seatRequestOutcome([]string{"A1"})
+	assert.Equal(t, []string{"A1"}, outcome.Missing)
+	assert.False(t, outcome.Exact())
+}
+
+func TestMakeReservation_RequireRequestedSeats(t *testing.T) {
+	reservationJSON, err := os.ReadFile("testdata/reservation_failure.json")
+	if err != nil {
+		t.Fatalf("Cannot find testdata/reservation_failure.json")
+	}
+	released := false
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/f13/reserve.v1":
+				w.Write(reservationJSON)
+			case "/f13/release.v1":
+				released = true
+				w.Write([]byte(`{"released_ok": true}`))
+			default:
+				t.Errorf("unexpected request to %s", r.URL.Path)
+			}
+		}))
+	defer server.Close()
+
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+	result, err := client.MakeReservation(context.Background(), &MakeReservationParams{
+		PerformanceID:         "7AB-5",
+		PriceBandCode:         "B/pool",
+		TicketTypeCode:        "CIRCLE",
+		NumberOfSeats:         2,
+		Seats:                 []string{"H9", "H10"},
+		RequireRequestedSeats: true,
+	})
+
+	assert.Nil(t, result)
+	assert.True(t, released)
+	seatErr, ok := err.(*SeatRequestError)
+	if assert.True(t, ok) {
+		assert.True(t, seatErr.Released)
+		assert.Equal(t, "U-8841ADC8-5F69-11E8-A0DD-AC1F6B466128-EC1A0BEE-LDNX", seatErr.TransactionUUID)
+		assert.Equal(t, []string{"H9", "H10"}, seatErr.Outcome.Missing)
+		assert.EqualError(t, err, "ticketswitch: requested seats not reserved: missing H9, H10")
+	}
+}
+
+func TestMakeReservation_RequireRequestedSeats_not_echoed(t *testing.T) {
+	// the reservation lists the seats but not which were requested.
+	reservationJSON, err := os.ReadFile("testdata/reservation.json")
+	if err != nil {
+		t.Fatalf("Cannot find testdata/reservation.json")
+	}
+	released := false
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/f13/reserve.v1":
+				w.Write(reservationJSON)
+			case "/f13/release.v1":
+				released = true
+				w.Write([]byte(`{"released_ok": true}`))
+			default:
+				t.Errorf("unexpected request to %s", r.URL.Path)
+			}
+		}))
+	defer server.Close()
+
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+	params := &MakeReservationParams{
+		PerformanceID:         "6IF-B0I",
+		PriceBandCode:         "A/pool",
+		TicketTypeCode:        "STALLS",
+		NumberOfSeats:         3,
+		Seats:                 []string{"GL418", "GL421", "GL424"},
+		RequireRequestedSeats: true,
+	}
+	result, err := client.MakeReservation(context.Background(), params)
+	if assert.Nil(t, err) {
+		assert.True(t, result.SeatRequestOutcome.Exact())
+		assert.Equal(t, []string{"GL418", "GL421", "GL424"}, result.SeatRequestOutcome.Granted)
+	}
+	assert.False(t, released)
+
+	params.Seats = []string{"GL418", "GL421", "GL425"}
+	result, err = client.MakeReservation(context.Background(), params)
+	assert.Nil(t, result)
+	assert.True(t, released)
+	seatErr, ok := err.(*SeatRequestError)
+	if assert.True(t, ok) {
+		assert.Equal(t, []string{"GL425"}, seatErr.Outcome.Missing)
+		assert.Equal(t, []string{"GL424"}, seatErr.Outcome.Substituted)
+	}
+}
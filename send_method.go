@@ -1,6 +1,17 @@
 package ticketswitch
 
-import "github.com/shopspring/decimal"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
 
 type Country struct {
 	Code string `json:"country_code"`
@@ -14,11 +25,12 @@ type PermittedCountries struct {
 type SendMethod struct {
 	Code                 string             `json:"send_code"`
 	Cost                 decimal.Decimal    `json:"send_cost"`
+	CostInDesired        decimal.Decimal    `json:"send_cost_in_desired"`
 	Desc                 string             `json:"send_desc"`
 	Type                 string             `json:"send_type"`
 	PermittedCountries   PermittedCountries `json:"permitted_countries"`
-	FinalType            string             `json:"send_final_tpe"`
-	CanGenerateSelfPrint bool               `json:"CanGenerateSelfPrint"`
+	FinalType            string             `json:"send_final_type"`
+	CanGenerateSelfPrint bool               `json:"can_generate_self_print"`
 	SelfPrintVoucherURL  string             `json:"self_print_voucher_url"`
 	HasHTMLPage          bool               `json:"has_html_page"`
 }
@@ -33,3 +45,160 @@ type SendMethodsResults struct {
 	SourceCode        string              `json:"source_code"`
 	SendMethodsHolder SendMethodsHolder   `json:"send_methods"`
 }
+
+// apiCountryCode converts an ISO 3166-1 country code to the code the API
+// uses, which is lower case and uk rather than gb.
+func apiCountryCode(code string) string {
+	code = strings.ToLower(code)
+	if code == "gb" {
+		return "uk"
+	}
+	return code
+}
+
+// PermitsCountry checks if the send method can be used for a customer in the
+// country. Send methods that don't list any permitted countries can be used
+// anywhere. The country code is an ISO 3166-1 or API country code.
+func (method *SendMethod) PermitsCountry(countryCode string) bool {
+	if len(method.PermittedCountries.Countries) == 0 {
+		return true
+	}
+	countryCode = apiCountryCode(countryCode)
+	for _, country := range method.PermittedCountries.Countries {
+		if apiCountryCode(country.Code) == countryCode {
+			return true
+		}
+	}
+	return false
+}
+
+// sendTypeRanks orders send types from the most to the least convenient for
+// the customer, for send methods that cost the same.
+var sendTypeRanks = map[string]int{
+	"selfprint": 0,
+	"collect":   1,
+	"post":      2,
+}
+
+func sendTypeRank(method *SendMethod) int {
+	if rank, ok := sendTypeRanks[method.Type]; ok {
+		return rank
+	}
+	return len(sendTypeRanks)
+}
+
+// ForCountry returns the send methods that can be used for a customer in the
+// country, ranked cheapest first. Methods that cost the same are ranked self
+// print, then collection, then post, then any other type, and then by whether
+// they are restricted to the country, as those are usually meant for it.
+func (results *SendMethodsResults) ForCountry(countryCode string) []SendMethod {
+	var methods []SendMethod
+	for _, method := range results.SendMethodsHolder.SendMethods {
+		if method.PermitsCountry(countryCode) {
+			methods = append(methods, method)
+		}
+	}
+	sort.SliceStable(methods, func(i, j int) bool {
+		a, b := &methods[i], &methods[j]
+		if !a.Cost.Equal(b.Cost) {
+			return a.Cost.LessThan(b.Cost)
+		}
+		if rankA, rankB := sendTypeRank(a), sendTypeRank(b); rankA != rankB {
+			return rankA < rankB
+		}
+		return len(a.PermittedCountries.Countries) > 0 && len(b.PermittedCountries.Countries) == 0
+	})
+	return methods
+}
+
+// DefaultForCountry returns the best ranked send method that can be used for
+// a customer in the country, or false if there is none.
+func (results *SendMethodsResults) DefaultForCountry(countryCode string) (SendMethod, bool) {
+	methods := results.ForCountry(countryCode)
+	if len(methods) == 0 {
+		return SendMethod{}, false
+	}
+	return methods[0], true
+}
+
+// Voucher is a self print voucher for a purchased order.
+type Voucher struct {
+	// the media type of the body, text/html or application/pdf.
+	ContentType string
+	Body        []byte
+}
+
+// IsPDF checks if the voucher is a PDF rather than an HTML page.
+func (voucher *Voucher) IsPDF() bool {
+	return strings.HasPrefix(voucher.ContentType, "application/pdf")
+}
+
+// GetSelfPrintVoucher fetches the self print voucher of a purchased order
+// from its send method's SelfPrintVoucherURL. The client's credentials, as
+// basic auth or crypto block query parameters, are only sent when the
+// voucher is on the same host as Config.BaseURL; the voucher URL carries its
+// own authorisation otherwise. Parameters already in the voucher URL are
+// left as they are.
+func (client *Client) GetSelfPrintVoucher(ctx context.Context, order *Order) (*Voucher, error) {
+	if order.SendMethod.SelfPrintVoucherURL == "" {
+		return nil, errors.New("ticketswitch: order has no self print voucher")
+	}
+	u, err := url.Parse(order.SendMethod.SelfPrintVoucherURL)
+	if err != nil {
+		return nil, fmt.Errorf("ticketswitch: invalid self print voucher url: %w", err)
+	}
+	base, err := url.Parse(client.Config.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req := NewRequest(http.MethodGet, "", nil)
+	if u.Host == base.Host {
+		if err := client.setHeaders(ctx, req); err != nil {
+			return nil, err
+		}
+		q := u.Query()
+		setDefault := func(key, value string) {
+			if value != "" && q.Get(key) == "" {
+				q.Set(key, value)
+			}
+		}
+		if client.Config.CryptoBlock != "" {
+			if client.Config.User == "" {
+				return nil, fmt.Errorf("ticketswitch: config specifies cryptoblock but doesn't supply a user")
+			}
+			setDefault("user_id", client.Config.User)
+			setDefault("crypto_block", client.Config.CryptoBlock)
+		}
+		setDefault("sub_id", client.Config.SubUser)
+		u.RawQuery = q.Encode()
+	} else if trackingID, ok := GetSessionTrackingID(ctx); ok {
+		req.Header.Set("x-request-id", trackingID)
+	}
+	req.Header.Set("Accept", "text/html, application/pdf")
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	r.Header = req.Header
+
+	resp, err := client.HTTPClient.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ticketswitch: fetching self print voucher: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	return &Voucher{ContentType: contentType, Body: body}, nil
+}
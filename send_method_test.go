@@ -0,0 +1,136 @@
+package ticketswitch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendMethod_tags(t *testing.T) {
+	data, err := os.ReadFile("testdata/status.json")
+	if err != nil {
+		t.Fatalf("Cannot find testdata/status.json")
+	}
+	var result StatusResult
+	if assert.Nil(t, json.Unmarshal(data, &result)) {
+		method := result.Trolley.Bundles[0].Orders[0].SendMethod
+		assert.Equal(t, "selfprint", method.FinalType)
+		assert.False(t, method.CanGenerateSelfPrint)
+		assert.Contains(t, method.SelfPrintVoucherURL, "web_self_print.buy")
+	}
+
+	var method SendMethod
+	assert.Nil(t, json.Unmarshal([]byte(`{"can_generate_self_print": true}`), &method))
+	assert.True(t, method.CanGenerateSelfPrint)
+}
+
+func TestSendMethod_PermitsCountry(t *testing.T) {
+	post := SendMethod{PermittedCountries: PermittedCountries{Countries: []Country{{Code: "ie"}, {Code: "uk"}}}}
+	assert.True(t, post.PermitsCountry("IE"))
+	assert.True(t, post.PermitsCountry("uk"))
+	assert.True(t, post.PermitsCountry("GB"))
+	assert.False(t, post.PermitsCountry("us"))
+
+	collect := SendMethod{}
+	assert.True(t, collect.PermitsCountry("us"))
+}
+
+func TestSendMethodsResults_ForCountry(t *testing.T) {
+	results := SendMethodsResults{SendMethodsHolder: SendMethodsHolder{SendMethods: []SendMethod{
+		{Code: "POST", Type: "post", Cost: decimal.NewFromFloat(3.5), PermittedCountries: PermittedCountries{Countries: []Country{{Code: "uk"}}}},
+		{Code: "COBO", Type: "collect", Cost: decimal.NewFromFloat(1.5)},
+		{Code: "INTL", Type: "post", Cost: decimal.NewFromFloat(3.5)},
+		{Code: "VOUCH", Type: "selfprint", Cost: decimal.NewFromFloat(1.5)},
+	}}}
+
+	codes := func(methods []SendMethod) []string {
+		var codes []string
+		for _, method := range methods {
+			codes = append(codes, method.Code)
+		}
+		return codes
+	}
+	assert.Equal(t, []string{"VOUCH", "COBO", "POST", "INTL"}, codes(results.ForCountry("gb")))
+	assert.Equal(t, []string{"VOUCH", "COBO", "INTL"}, codes(results.ForCountry("fr")))
+
+	method, ok := results.DefaultForCountry("fr")
+	assert.True(t, ok)
+	assert.Equal(t, "VOUCH", method.Code)
+
+	_, ok = (&SendMethodsResults{}).DefaultForCountry("fr")
+	assert.False(t, ok)
+}
+
+func TestGetSelfPrintVoucher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/tickets/web_self_print.buy/demo", r.URL.Path)
+			assert.Equal(t, "abc", r.URL.Query().Get("crypto_block"))
+			user, password, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "bill", user)
+			assert.Equal(t, "hahaha", password)
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.4"))
+		}))
+	defer server.Close()
+
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+	order := &Order{SendMethod: SendMethod{SelfPrintVoucherURL: server.URL + "/tickets/web_self_print.buy/demo?crypto_block=abc"}}
+	voucher, err := client.GetSelfPrintVoucher(context.Background(), order)
+	if assert.Nil(t, err) {
+		assert.True(t, voucher.IsPDF())
+		assert.Equal(t, []byte("%PDF-1.4"), voucher.Body)
+	}
+
+	_, err = client.GetSelfPrintVoucher(context.Background(), &Order{})
+	assert.EqualError(t, err, "ticketswitch: order has no self print voucher")
+}
+
+func TestGetSelfPrintVoucher_crypto_block(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get("Authorization"))
+			assert.Equal(t, "bill", r.URL.Query().Get("user_id"))
+			assert.Equal(t, "secret", r.URL.Query().Get("crypto_block"))
+			assert.Equal(t, "beatrice", r.URL.Query().Get("sub_id"))
+			assert.Equal(t, "7", r.URL.Query().Get("page"))
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.4"))
+		}))
+	defer server.Close()
+
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", CryptoBlock: "secret", SubUser: "beatrice"})
+	order := &Order{SendMethod: SendMethod{SelfPrintVoucherURL: server.URL + "/tickets/web_self_print.buy/demo?page=7"}}
+	voucher, err := client.GetSelfPrintVoucher(context.Background(), order)
+	if assert.Nil(t, err) {
+		assert.True(t, voucher.IsPDF())
+	}
+
+	client = NewClient(&Config{BaseURL: server.URL, CryptoBlock: "secret"})
+	_, err = client.GetSelfPrintVoucher(context.Background(), order)
+	assert.EqualError(t, err, "ticketswitch: config specifies cryptoblock but doesn't supply a user")
+}
+
+func TestGetSelfPrintVoucher_other_host(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get("Authorization"))
+			w.Write([]byte("<html><body>voucher</body></html>"))
+		}))
+	defer server.Close()
+
+	client := NewClient(&Config{BaseURL: "https://api.ticketswitch.com", User: "bill", Password: "hahaha"})
+	order := &Order{SendMethod: SendMethod{SelfPrintVoucherURL: server.URL + "/voucher"}}
+	voucher, err := client.GetSelfPrintVoucher(context.Background(), order)
+	if assert.Nil(t, err) {
+		assert.False(t, voucher.IsPDF())
+		assert.Equal(t, "text/html; charset=utf-8", voucher.ContentType)
+	}
+}
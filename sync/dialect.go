@@ -0,0 +1,143 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ColumnKind is the kind of data held by a column, which a Dialect maps to a
+// SQL type.
+type ColumnKind int
+
+// The kinds of column used by the tables.
+const (
+	// a short string that is part of a key.
+	KeyColumn ColumnKind = iota
+	// a short string.
+	StringColumn
+	// text of any length.
+	TextColumn
+	// a price.
+	DecimalColumn
+	// a floating point number.
+	FloatColumn
+	// an integer.
+	IntColumn
+	// a boolean.
+	BoolColumn
+	// a date and time.
+	TimeColumn
+)
+
+// Dialect generates the SQL that differs between databases.
+type Dialect interface {
+	// Placeholder returns the bind parameter for the nth argument, starting
+	// from 1.
+	Placeholder(n int) string
+	// Upsert returns a statement that inserts a row, or updates the other
+	// columns of the row with the same key columns when there is one.
+	Upsert(table string, columns, keys []string) string
+	// ColumnType returns the SQL type for a kind of column.
+	ColumnType(kind ColumnKind) string
+}
+
+// placeholders returns the bind parameters for n arguments.
+func placeholders(dialect Dialect, n int) string {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = dialect.Placeholder(i + 1)
+	}
+	return strings.Join(values, ", ")
+}
+
+// nonKeys returns the columns that aren't keys.
+func nonKeys(columns, keys []string) []string {
+	isKey := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		isKey[key] = true
+	}
+	others := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if !isKey[column] {
+			others = append(others, column)
+		}
+	}
+	return others
+}
+
+type postgres struct{}
+
+// Postgres is the dialect for PostgreSQL, for use with github.com/lib/pq.
+var Postgres Dialect = postgres{}
+
+func (postgres) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (dialect postgres) Upsert(table string, columns, keys []string) string {
+	updates := nonKeys(columns, keys)
+	for i, column := range updates {
+		updates[i] = column + " = EXCLUDED." + column
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s",
+		table, strings.Join(columns, ", "), placeholders(dialect, len(columns)),
+		strings.Join(keys, ", "), strings.Join(updates, ", "))
+}
+
+func (postgres) ColumnType(kind ColumnKind) string {
+	switch kind {
+	case KeyColumn, StringColumn:
+		return "VARCHAR(255)"
+	case DecimalColumn:
+		return "NUMERIC(18, 4)"
+	case FloatColumn:
+		return "DOUBLE PRECISION"
+	case IntColumn:
+		return "INTEGER"
+	case BoolColumn:
+		return "BOOLEAN"
+	case TimeColumn:
+		return "TIMESTAMP WITH TIME ZONE"
+	default:
+		return "TEXT"
+	}
+}
+
+type mysql struct{}
+
+// MySQL is the dialect for MySQL and MariaDB, for use with
+// github.com/ziutek/mymysql/godrv or another MySQL driver.
+var MySQL Dialect = mysql{}
+
+func (mysql) Placeholder(n int) string {
+	return "?"
+}
+
+func (dialect mysql) Upsert(table string, columns, keys []string) string {
+	updates := nonKeys(columns, keys)
+	for i, column := range updates {
+		updates[i] = column + " = VALUES(" + column + ")"
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s",
+		table, strings.Join(columns, ", "), placeholders(dialect, len(columns)),
+		strings.Join(updates, ", "))
+}
+
+func (mysql) ColumnType(kind ColumnKind) string {
+	switch kind {
+	case KeyColumn, StringColumn:
+		return "VARCHAR(255)"
+	case DecimalColumn:
+		return "DECIMAL(18, 4)"
+	case FloatColumn:
+		return "DOUBLE"
+	case IntColumn:
+		return "INT"
+	case BoolColumn:
+		return "BOOLEAN"
+	case TimeColumn:
+		return "DATETIME"
+	default:
+		return "TEXT"
+	}
+}
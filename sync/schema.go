@@ -0,0 +1,129 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Column describes a column of a table.
+type Column struct {
+	Name string
+	Kind ColumnKind
+}
+
+// Table describes a table written by a Syncer.
+type Table struct {
+	Name    string
+	Columns []Column
+	// the columns of the primary key.
+	Keys []string
+}
+
+// ColumnNames returns the names of the table's columns.
+func (table *Table) ColumnNames() []string {
+	names := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// Create returns the statement that creates the table if it doesn't exist.
+func (table *Table) Create(dialect Dialect) string {
+	lines := make([]string, 0, len(table.Columns)+1)
+	for _, column := range table.Columns {
+		line := "  " + column.Name + " " + dialect.ColumnType(column.Kind)
+		if column.Kind == KeyColumn {
+			line += " NOT NULL"
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "  PRIMARY KEY ("+strings.Join(table.Keys, ", ")+")")
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n%s\n)", table.Name, strings.Join(lines, ",\n"))
+}
+
+// The tables written by a Syncer. Every table has a synced_at column holding
+// the start of the sync that last wrote the row. Events and performances that
+// are no longer listed by the API have is_dead set rather than being deleted.
+var (
+	EventsTable = Table{
+		Name: "events",
+		Columns: []Column{
+			{"event_id", KeyColumn},
+			{"event_desc", StringColumn},
+			{"event_status", StringColumn},
+			{"event_type", StringColumn},
+			{"source_code", StringColumn},
+			{"venue_desc", StringColumn},
+			{"postcode", StringColumn},
+			{"city_code", StringColumn},
+			{"city_desc", StringColumn},
+			{"country_code", StringColumn},
+			{"latitude", FloatColumn},
+			{"longitude", FloatColumn},
+			{"classes", TextColumn},
+			{"has_no_perfs", BoolColumn},
+			{"currency_code", StringColumn},
+			{"min_seatprice", DecimalColumn},
+			{"max_seatprice", DecimalColumn},
+			{"min_surcharge", DecimalColumn},
+			{"max_surcharge", DecimalColumn},
+			{"is_dead", BoolColumn},
+			{"synced_at", TimeColumn},
+		},
+		Keys: []string{"event_id"},
+	}
+
+	PerformancesTable = Table{
+		Name: "performances",
+		Columns: []Column{
+			{"perf_id", KeyColumn},
+			{"event_id", StringColumn},
+			{"perf_name", StringColumn},
+			{"perf_datetime", TimeColumn},
+			{"running_time", IntColumn},
+			{"is_ghost", BoolColumn},
+			{"is_limited", BoolColumn},
+			{"currency_code", StringColumn},
+			{"min_seatprice", DecimalColumn},
+			{"max_seatprice", DecimalColumn},
+			{"min_surcharge", DecimalColumn},
+			{"max_surcharge", DecimalColumn},
+			{"is_dead", BoolColumn},
+			{"synced_at", TimeColumn},
+		},
+		Keys: []string{"perf_id"},
+	}
+
+	SourcesTable = Table{
+		Name: "sources",
+		Columns: []Column{
+			{"source_code", KeyColumn},
+			{"source_desc", StringColumn},
+			{"source_class", StringColumn},
+			{"source_type", StringColumn},
+			{"after_sales_email", StringColumn},
+			{"postal_addr", TextColumn},
+			{"terms_and_conditions", TextColumn},
+			{"synced_at", TimeColumn},
+		},
+		Keys: []string{"source_code"},
+	}
+
+	SendMethodsTable = Table{
+		Name: "send_methods",
+		Columns: []Column{
+			{"perf_id", KeyColumn},
+			{"send_code", KeyColumn},
+			{"send_desc", StringColumn},
+			{"send_type", StringColumn},
+			{"send_cost", DecimalColumn},
+			{"currency_code", StringColumn},
+			{"synced_at", TimeColumn},
+		},
+		Keys: []string{"perf_id", "send_code"},
+	}
+
+	// Tables lists every table in the order they should be created.
+	Tables = []*Table{&EventsTable, &PerformancesTable, &SourcesTable, &SendMethodsTable}
+)
@@ -0,0 +1,417 @@
+// Package sync mirrors the ticketswitch catalogue into a SQL database.
+//
+// A Syncer walks every event, its performances and optionally their send
+// methods and the backend sources, upserting them into the tables described
+// by Tables. Events and performances that the API no longer lists are marked
+// with is_dead rather than deleted, so a local copy can be searched and
+// reported on with history intact.
+//
+// The SQL that differs between databases is generated by a Dialect. Postgres
+// and MySQL are provided; the database driver must be imported by the caller:
+//
+//	import _ "github.com/lib/pq"
+//
+//	db, err := sql.Open("postgres", dsn)
+//	syncer := sync.New(client, db, sync.Postgres)
+//	err = syncer.CreateTables(ctx)
+//	result, err := syncer.Sync(ctx)
+package sync
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+)
+
+// DefaultPageLength is the number of events requested per page by a Syncer.
+const DefaultPageLength = 100
+
+// DB is the part of *sql.DB used by a Syncer.
+type DB interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// EventError is reported when part of an event couldn't be fetched from the
+// API. The event itself is still written.
+type EventError struct {
+	EventID string
+	Err     error
+}
+
+func (err *EventError) Error() string {
+	return fmt.Sprintf("ticketswitch: syncing %s: %s", err.EventID, err.Err)
+}
+
+func (err *EventError) Unwrap() error {
+	return err.Err
+}
+
+// Result describes a finished sync.
+type Result struct {
+	Events       int
+	Performances int
+	SendMethods  int
+	Sources      int
+	// the number of events newly marked as dead.
+	DeadEvents int64
+	// the events that couldn't be fully fetched.
+	Errors []*EventError
+}
+
+// Syncer copies the catalogue from the API into a database.
+type Syncer struct {
+	// parameters for listing events. Cost ranges are always requested and
+	// the pagination is managed by the syncer.
+	Params ticketswitch.ListEventsParams
+	// the number of events requested per page.
+	PageLength int
+	// also sync the send methods of every performance.
+	SendMethods bool
+	// also sync the backend sources.
+	Sources bool
+	// mark events that weren't listed as dead after a successful sync. This
+	// should be turned off when Params only selects part of the catalogue.
+	MarkDead bool
+	// called with each event that couldn't be fully fetched.
+	OnError func(err *EventError)
+	// returns the time recorded in synced_at, defaults to time.Now.
+	Now func() time.Time
+
+	client  ticketswitch.API
+	db      DB
+	dialect Dialect
+}
+
+// New returns a Syncer that writes to the database using the dialect. Dead
+// events are marked by default.
+func New(client ticketswitch.API, db DB, dialect Dialect) *Syncer {
+	return &Syncer{
+		PageLength: DefaultPageLength,
+		MarkDead:   true,
+		Now:        time.Now,
+		client:     client,
+		db:         db,
+		dialect:    dialect,
+	}
+}
+
+// CreateTables creates any of the tables that don't exist yet.
+func (syncer *Syncer) CreateTables(ctx context.Context) error {
+	for _, table := range Tables {
+		if _, err := syncer.db.ExecContext(ctx, table.Create(syncer.dialect)); err != nil {
+			return fmt.Errorf("ticketswitch: creating table %s: %w", table.Name, err)
+		}
+	}
+	return nil
+}
+
+// upsert inserts or updates a row of the table. The values must be in the
+// order of the table's columns.
+func (syncer *Syncer) upsert(ctx context.Context, db execer, table *Table, values ...interface{}) error {
+	query := syncer.dialect.Upsert(table.Name, table.ColumnNames(), table.Keys)
+	if _, err := db.ExecContext(ctx, query, values...); err != nil {
+		return fmt.Errorf("ticketswitch: writing %s: %w", table.Name, err)
+	}
+	return nil
+}
+
+// costRange returns the currency and prices of a cost range, with NULL prices
+// when there is no cost range.
+func costRange(costRange *ticketswitch.CostRange) []interface{} {
+	if costRange.CurrencyCode == "" {
+		return []interface{}{"", nil, nil, nil, nil}
+	}
+	return []interface{}{
+		costRange.CurrencyCode,
+		costRange.MinSeatPrice,
+		costRange.MaxSeatPrice,
+		costRange.MinSurcharge,
+		costRange.MaxSurcharge,
+	}
+}
+
+func classes(event *ticketswitch.Event) string {
+	ids := make([]string, 0, len(event.Classes))
+	for id := range event.Classes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for i, id := range ids {
+		ids[i] = id + ":" + event.Classes[id]
+	}
+	return strings.Join(ids, ";")
+}
+
+func (syncer *Syncer) writeEvent(ctx context.Context, db execer, event *ticketswitch.Event, now time.Time) error {
+	values := []interface{}{
+		event.ID,
+		event.Description,
+		event.Status,
+		event.EventType,
+		event.SourceCode,
+		event.Venue,
+		event.Postcode,
+		event.CityCode,
+		event.City,
+		event.CountryCode,
+		event.GeoData.Latitude,
+		event.GeoData.Longitude,
+		classes(event),
+		event.HasNoPerformances,
+	}
+	values = append(values, costRange(&event.CostRange)...)
+	values = append(values, event.Status == "dead", now)
+	return syncer.upsert(ctx, db, &EventsTable, values...)
+}
+
+func (syncer *Syncer) writePerformance(ctx context.Context, db execer, perf *ticketswitch.Performance, now time.Time) error {
+	datetime := sql.NullTime{Time: perf.Datetime, Valid: !perf.Datetime.IsZero()}
+	values := []interface{}{
+		perf.ID,
+		perf.EventID,
+		perf.Name,
+		datetime,
+		perf.RunningTime,
+		perf.IsGhost,
+		perf.IsLimited,
+	}
+	values = append(values, costRange(&perf.CostRange)...)
+	// a ghost performance is still listed, performances are only marked
+	// dead by syncEvent and markDead once the API stops listing them.
+	values = append(values, false, now)
+	return syncer.upsert(ctx, db, &PerformancesTable, values...)
+}
+
+func (syncer *Syncer) writeSendMethod(ctx context.Context, db execer, perfID, currency string, method *ticketswitch.SendMethod, now time.Time) error {
+	return syncer.upsert(ctx, db, &SendMethodsTable,
+		perfID, method.Code, method.Desc, method.Type, method.Cost, currency, now)
+}
+
+// syncSources writes every backend source in a single transaction.
+func (syncer *Syncer) syncSources(ctx context.Context, now time.Time) (int, error) {
+	sources, err := syncer.client.GetSources(ctx, &syncer.Params.UniversalParams)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := syncer.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	for _, source := range sources.Sources {
+		err := syncer.upsert(ctx, tx, &SourcesTable,
+			source.Code, source.Description, source.Class, source.Type,
+			source.Email, source.Address, source.TermsAndConditions, now)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(sources.Sources), tx.Commit()
+}
+
+// fetchedEvent is everything fetched from the API for an event.
+type fetchedEvent struct {
+	perfs       []ticketswitch.Performance
+	sendMethods map[string]*ticketswitch.SendMethodsResults
+	// the performances are complete, so any others can be marked dead.
+	complete bool
+	err      error
+}
+
+func (syncer *Syncer) fetchEvent(ctx context.Context, event *ticketswitch.Event) *fetchedEvent {
+	fetched := &fetchedEvent{sendMethods: make(map[string]*ticketswitch.SendMethodsResults)}
+	if event.HasNoPerformances {
+		fetched.complete = true
+		return fetched
+	}
+
+	params := &ticketswitch.ListPerformancesParams{
+		UniversalParams:  syncer.Params.UniversalParams,
+		EventID:          event.ID,
+		RequireCostRange: true,
+	}
+	for {
+		results, err := syncer.client.ListPerformances(ctx, params)
+		if err != nil {
+			fetched.err = err
+			return fetched
+		}
+		fetched.perfs = append(fetched.perfs, results.Performances...)
+		next, more := results.PagingStatus.NextPage()
+		if !more {
+			break
+		}
+		params.PageNumber = next
+	}
+	fetched.complete = true
+
+	if syncer.SendMethods {
+		for _, perf := range fetched.perfs {
+			methods, err := syncer.client.GetSendMethods(ctx, perf.ID, &syncer.Params.UniversalParams)
+			if err != nil {
+				fetched.err = err
+				return fetched
+			}
+			fetched.sendMethods[perf.ID] = methods
+		}
+	}
+	return fetched
+}
+
+// syncEvent writes an event along with whatever was fetched for it in a
+// single transaction and returns the number of performances and send methods
+// written.
+func (syncer *Syncer) syncEvent(ctx context.Context, event *ticketswitch.Event, fetched *fetchedEvent, now time.Time) (int, int, error) {
+	tx, err := syncer.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := syncer.writeEvent(ctx, tx, event, now); err != nil {
+		return 0, 0, err
+	}
+	sendMethods := 0
+	for i := range fetched.perfs {
+		perf := &fetched.perfs[i]
+		if perf.EventID == "" {
+			perf.EventID = event.ID
+		}
+		if err := syncer.writePerformance(ctx, tx, perf, now); err != nil {
+			return 0, 0, err
+		}
+		methods, ok := fetched.sendMethods[perf.ID]
+		if !ok {
+			continue
+		}
+		for j := range methods.SendMethodsHolder.SendMethods {
+			method := &methods.SendMethodsHolder.SendMethods[j]
+			if err := syncer.writeSendMethod(ctx, tx, perf.ID, methods.CurrencyCode, method, now); err != nil {
+				return 0, 0, err
+			}
+			sendMethods++
+		}
+	}
+
+	if fetched.complete {
+		query := fmt.Sprintf("UPDATE %s SET is_dead = %s WHERE event_id = %s AND synced_at < %s",
+			PerformancesTable.Name, syncer.dialect.Placeholder(1),
+			syncer.dialect.Placeholder(2), syncer.dialect.Placeholder(3))
+		if _, err := tx.ExecContext(ctx, query, true, event.ID, now); err != nil {
+			return 0, 0, fmt.Errorf("ticketswitch: marking dead performances: %w", err)
+		}
+	}
+	return len(fetched.perfs), sendMethods, tx.Commit()
+}
+
+// markDead marks the events that weren't written by this sync, and their
+// performances, as dead.
+func (syncer *Syncer) markDead(ctx context.Context, now time.Time) (int64, error) {
+	tx, err := syncer.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	p := syncer.dialect.Placeholder
+	query := fmt.Sprintf("UPDATE %s SET is_dead = %s WHERE synced_at < %s AND is_dead = %s",
+		EventsTable.Name, p(1), p(2), p(3))
+	result, err := tx.ExecContext(ctx, query, true, now, false)
+	if err != nil {
+		return 0, fmt.Errorf("ticketswitch: marking dead events: %w", err)
+	}
+	dead, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET is_dead = %s WHERE is_dead = %s AND event_id IN (SELECT event_id FROM %s WHERE is_dead = %s)",
+		PerformancesTable.Name, p(1), p(2), EventsTable.Name, p(3))
+	if _, err := tx.ExecContext(ctx, query, true, false, true); err != nil {
+		return 0, fmt.Errorf("ticketswitch: marking dead performances: %w", err)
+	}
+	return dead, tx.Commit()
+}
+
+// Sync copies the catalogue into the database. Each event is written in its
+// own transaction along with its performances and send methods, so an
+// interrupted sync leaves complete events behind and can simply be run again.
+//
+// When the performances or send methods of an event can't be fetched the
+// event is still written, the error is passed to OnError and recorded in the
+// result, and the event's existing performances are left alone. Errors
+// listing events or writing to the database stop the sync. Dead events are
+// only marked after every page of events has been written.
+func (syncer *Syncer) Sync(ctx context.Context) (*Result, error) {
+	// DATETIME columns keep whole seconds, so rows written by this sync would
+	// otherwise be stored before now and marked dead.
+	now := syncer.Now().UTC().Truncate(time.Second)
+	result := &Result{Errors: make([]*EventError, 0)}
+
+	if syncer.Sources {
+		count, err := syncer.syncSources(ctx, now)
+		if err != nil {
+			return result, err
+		}
+		result.Sources = count
+	}
+
+	params := syncer.Params
+	params.CostRange = true
+	params.PageNumber = 0
+	if syncer.PageLength > 0 {
+		params.PageLength = syncer.PageLength
+	}
+	for {
+		events, err := syncer.client.ListEvents(ctx, &params)
+		if err != nil {
+			return result, err
+		}
+		for i := range events.Events {
+			event := &events.Events[i]
+			fetched := syncer.fetchEvent(ctx, event)
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			if fetched.err != nil {
+				eventErr := &EventError{EventID: event.ID, Err: fetched.err}
+				result.Errors = append(result.Errors, eventErr)
+				if syncer.OnError != nil {
+					syncer.OnError(eventErr)
+				}
+			}
+			perfs, sendMethods, err := syncer.syncEvent(ctx, event, fetched, now)
+			if err != nil {
+				return result, err
+			}
+			result.Events++
+			result.Performances += perfs
+			result.SendMethods += sendMethods
+		}
+		next, more := events.PagingStatus.NextPage()
+		if !more {
+			break
+		}
+		params.PageNumber = next
+	}
+
+	if syncer.MarkDead {
+		dead, err := syncer.markDead(ctx, now)
+		if err != nil {
+			return result, err
+		}
+		result.DeadEvents = dead
+	}
+	return result, nil
+}
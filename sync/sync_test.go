@@ -0,0 +1,252 @@
+package sync
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	testdb "github.com/erikstmartin/go-testdb"
+	ticketswitch "github.com/ingresso-group/goticketswitch.v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// statement is a statement executed against the test database.
+type statement struct {
+	query string
+	args  []driver.Value
+}
+
+// openTestDB returns a go-testdb database that records every statement.
+func openTestDB(t *testing.T) (*sql.DB, *[]statement) {
+	testdb.Reset()
+	t.Cleanup(testdb.Reset)
+	statements := make([]statement, 0)
+	testdb.SetExecWithArgsFunc(func(query string, args []driver.Value) (driver.Result, error) {
+		statements = append(statements, statement{query: query, args: args})
+		return testdb.NewResult(0, nil, 1, nil), nil
+	})
+	db, err := sql.Open("testdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, &statements
+}
+
+func newTestClient(t *testing.T, perfStatus int) *ticketswitch.Client {
+	files := map[string]string{
+		"performances.v1": "performances_avail_details.json",
+		"send_methods.v1": "send_methods.json",
+		"sources.v1":      "sources.json",
+	}
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			endpoint := strings.TrimPrefix(r.URL.Path, "/f13/")
+			if endpoint == "events.v1" {
+				assert.Equal(t, "1", r.URL.Query().Get("req_cost_range"))
+				w.Write([]byte(`{"results": {"event": [
+					{"event_id": "6IF", "event_desc": "Nutcracker", "event_status": "live",
+					 "classes": {"dance": "Dance", "ballet": "Ballet"},
+					 "cost_range": {"currency_code": "gbp", "min_seatprice": 15, "max_seatprice": 55}},
+					{"event_id": "6KU", "event_desc": "Attraction", "has_no_perfs": true}
+				]}}`))
+				return
+			}
+			if endpoint == "performances.v1" && perfStatus != http.StatusOK {
+				w.WriteHeader(perfStatus)
+				w.Write([]byte(`{"error_code": 8, "error_desc": "broken"}`))
+				return
+			}
+			data, err := os.ReadFile("../testdata/" + files[endpoint])
+			if err != nil {
+				t.Error(err)
+			}
+			w.Write(data)
+		}))
+	t.Cleanup(server.Close)
+	return ticketswitch.NewClient(&ticketswitch.Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+}
+
+var syncTime = time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+
+func queriesStartingWith(statements []statement, prefix string) []statement {
+	matches := make([]statement, 0)
+	for _, s := range statements {
+		if strings.HasPrefix(s.query, prefix) {
+			matches = append(matches, s)
+		}
+	}
+	return matches
+}
+
+func TestDialect_Upsert(t *testing.T) {
+	columns := []string{"perf_id", "send_code", "send_desc", "synced_at"}
+	keys := []string{"perf_id", "send_code"}
+	assert.Equal(t,
+		"INSERT INTO send_methods (perf_id, send_code, send_desc, synced_at) VALUES ($1, $2, $3, $4) "+
+			"ON CONFLICT (perf_id, send_code) DO UPDATE SET send_desc = EXCLUDED.send_desc, synced_at = EXCLUDED.synced_at",
+		Postgres.Upsert("send_methods", columns, keys))
+	assert.Equal(t,
+		"INSERT INTO send_methods (perf_id, send_code, send_desc, synced_at) VALUES (?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE send_desc = VALUES(send_desc), synced_at = VALUES(synced_at)",
+		MySQL.Upsert("send_methods", columns, keys))
+}
+
+func TestTable_Create(t *testing.T) {
+	table := Table{
+		Name:    "things",
+		Columns: []Column{{"id", KeyColumn}, {"price", DecimalColumn}, {"at", TimeColumn}},
+		Keys:    []string{"id"},
+	}
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS things (\n"+
+		"  id VARCHAR(255) NOT NULL,\n"+
+		"  price NUMERIC(18, 4),\n"+
+		"  at TIMESTAMP WITH TIME ZONE,\n"+
+		"  PRIMARY KEY (id)\n)", table.Create(Postgres))
+	assert.Contains(t, table.Create(MySQL), "price DECIMAL(18, 4),\n  at DATETIME,")
+}
+
+func TestSyncer_CreateTables(t *testing.T) {
+	db, statements := openTestDB(t)
+	syncer := New(nil, db, MySQL)
+	assert.Nil(t, syncer.CreateTables(context.Background()))
+	if assert.Len(t, *statements, len(Tables)) {
+		assert.True(t, strings.HasPrefix((*statements)[0].query, "CREATE TABLE IF NOT EXISTS events ("))
+		assert.Contains(t, (*statements)[3].query, "PRIMARY KEY (perf_id, send_code)")
+	}
+}
+
+func TestSyncer_Sync(t *testing.T) {
+	db, statements := openTestDB(t)
+	syncer := New(newTestClient(t, http.StatusOK), db, Postgres)
+	syncer.SendMethods = true
+	syncer.Sources = true
+	syncer.Now = func() time.Time { return syncTime }
+
+	result, err := syncer.Sync(context.Background())
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 2, result.Events)
+	assert.Equal(t, 2, result.Performances)
+	assert.Equal(t, 3, result.Sources)
+	assert.Equal(t, 4, result.SendMethods)
+	assert.Equal(t, int64(1), result.DeadEvents)
+	assert.Empty(t, result.Errors)
+
+	sources := queriesStartingWith(*statements, "INSERT INTO sources ")
+	assert.Len(t, sources, 3)
+
+	events := queriesStartingWith(*statements, "INSERT INTO events ")
+	if assert.Len(t, events, 2) {
+		args := events[0].args
+		assert.Len(t, args, len(EventsTable.Columns))
+		assert.Equal(t, "6IF", args[0])
+		assert.Equal(t, "ballet:Ballet;dance:Dance", args[12])
+		assert.Equal(t, "gbp", args[14])
+		assert.Equal(t, "15", args[15])
+		assert.Equal(t, false, args[19])
+		assert.Equal(t, syncTime, args[20])
+
+		args = events[1].args
+		assert.Equal(t, "6KU", args[0])
+		assert.Equal(t, true, args[13])
+		assert.Nil(t, args[15])
+	}
+
+	perfs := queriesStartingWith(*statements, "INSERT INTO performances ")
+	if assert.Len(t, perfs, 2) {
+		args := perfs[0].args
+		assert.Len(t, args, len(PerformancesTable.Columns))
+		assert.Equal(t, "6IF-C5O", args[0])
+		assert.Equal(t, "6IF", args[1])
+		assert.Equal(t, time.Date(2026, 12, 12, 19, 30, 0, 0, time.UTC), args[3].(time.Time).UTC())
+	}
+
+	assert.Len(t, queriesStartingWith(*statements, "INSERT INTO send_methods "), 4)
+
+	stale := queriesStartingWith(*statements, "UPDATE performances SET is_dead = $1 WHERE event_id = $2")
+	if assert.Len(t, stale, 2) {
+		assert.Equal(t, []driver.Value{true, "6IF", syncTime}, stale[0].args)
+	}
+
+	dead := queriesStartingWith(*statements, "UPDATE events SET is_dead = $1 WHERE synced_at < $2")
+	if assert.Len(t, dead, 1) {
+		assert.Equal(t, []driver.Value{true, syncTime, false}, dead[0].args)
+	}
+	assert.Len(t, queriesStartingWith(*statements, "UPDATE performances SET is_dead = $1 WHERE is_dead = $2 AND event_id IN"), 1)
+}
+
+func TestSyncer_writePerformance_ghost(t *testing.T) {
+	db, statements := openTestDB(t)
+	syncer := New(nil, db, Postgres)
+
+	perf := &ticketswitch.Performance{ID: "6IF-C5O", EventID: "6IF", IsGhost: true}
+	if !assert.Nil(t, syncer.writePerformance(context.Background(), db, perf, syncTime)) {
+		return
+	}
+	perfs := queriesStartingWith(*statements, "INSERT INTO performances ")
+	if assert.Len(t, perfs, 1) {
+		assert.Equal(t, true, perfs[0].args[5])
+		assert.Equal(t, false, perfs[0].args[12])
+	}
+}
+
+func TestSyncer_Sync_event_error(t *testing.T) {
+	db, statements := openTestDB(t)
+	syncer := New(newTestClient(t, http.StatusBadRequest), db, MySQL)
+	syncer.MarkDead = false
+	var reported []string
+	syncer.OnError = func(err *EventError) { reported = append(reported, err.EventID) }
+
+	result, err := syncer.Sync(context.Background())
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 2, result.Events)
+	assert.Equal(t, 0, result.Performances)
+	if assert.Len(t, result.Errors, 1) {
+		assert.Equal(t, "6IF", result.Errors[0].EventID)
+		assert.Contains(t, result.Errors[0].Error(), "broken")
+	}
+	assert.Equal(t, []string{"6IF"}, reported)
+
+	assert.Len(t, queriesStartingWith(*statements, "INSERT INTO events "), 2)
+	stale := queriesStartingWith(*statements, "UPDATE performances SET is_dead = ? WHERE event_id = ?")
+	if assert.Len(t, stale, 1) {
+		assert.Equal(t, "6KU", stale[0].args[1])
+	}
+	assert.Empty(t, queriesStartingWith(*statements, "UPDATE events"))
+}
+
+func TestSyncer_Sync_subsecond(t *testing.T) {
+	db, statements := openTestDB(t)
+	syncer := New(newTestClient(t, http.StatusOK), db, MySQL)
+	syncer.Now = func() time.Time { return syncTime.Add(300 * time.Millisecond) }
+
+	_, err := syncer.Sync(context.Background())
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// a MySQL DATETIME would round the synced_at of the rows written to
+	// whole seconds, so the dead markers must compare against the same.
+	events := queriesStartingWith(*statements, "INSERT INTO events ")
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, syncTime, events[0].args[20])
+	}
+	stale := queriesStartingWith(*statements, "UPDATE performances SET is_dead = ? WHERE event_id = ?")
+	if assert.Len(t, stale, 2) {
+		assert.Equal(t, syncTime, stale[0].args[2])
+	}
+	dead := queriesStartingWith(*statements, "UPDATE events SET is_dead = ? WHERE synced_at < ?")
+	if assert.Len(t, dead, 1) {
+		assert.Equal(t, syncTime, dead[0].args[1])
+	}
+}
@@ -1,6 +1,16 @@
 package ticketswitch
 
-import "github.com/shopspring/decimal"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type Seat struct {
 	ColumnID         string `json:"col_id"`
@@ -13,15 +23,21 @@ type Seat struct {
 }
 
 type TicketOrder struct {
-	DiscountCode         string          `json:"discount_code"`
-	DiscountSemanticType string          `json:"discount_semantic_type"`
-	DiscountDesc         string          `json:"discount_desc"`
-	NumberOfSeats        int             `json:"no_of_seats"`
-	SaleSeatprice        decimal.Decimal `json:"sale_seatprice"`
-	SaleSurcharge        decimal.Decimal `json:"sale_surcharge"`
-	Seats                []Seat          `json:"seats"`
-	TotalSaleSeatprice   decimal.Decimal `json:"total_sale_seatprice"`
-	TotalSaleSurcharge   decimal.Decimal `json:"total_sale_surcharge"`
+	DiscountCode                string          `json:"discount_code"`
+	DiscountSemanticType        string          `json:"discount_semantic_type"`
+	DiscountDesc                string          `json:"discount_desc"`
+	NumberOfSeats               int             `json:"no_of_seats"`
+	SaleSeatprice               decimal.Decimal `json:"sale_seatprice"`
+	SaleSurcharge               decimal.Decimal `json:"sale_surcharge"`
+	Seats                       []Seat          `json:"seats"`
+	TotalSaleSeatprice          decimal.Decimal `json:"total_sale_seatprice"`
+	TotalSaleSurcharge          decimal.Decimal `json:"total_sale_surcharge"`
+	SaleSeatpriceInDesired      decimal.Decimal `json:"sale_seatprice_in_desired"`
+	SaleSurchargeInDesired      decimal.Decimal `json:"sale_surcharge_in_desired"`
+	SaleCombinedInDesired       decimal.Decimal `json:"sale_combined_in_desired"`
+	TotalSaleSeatpriceInDesired decimal.Decimal `json:"total_sale_seatprice_in_desired"`
+	TotalSaleSurchargeInDesired decimal.Decimal `json:"total_sale_surcharge_in_desired"`
+	TotalSaleCombinedInDesired  decimal.Decimal `json:"total_sale_combined_in_desired"`
 }
 
 type TicketOrdersHolder struct {
@@ -48,6 +64,9 @@ type Order struct {
 	TotalNumberOfSeats           int                `json:"total_no_of_seats"`
 	TotalSaleSeatprice           decimal.Decimal    `json:"total_sale_seatprice"`
 	TotalSaleSurcharge           decimal.Decimal    `json:"total_sale_surcharge"`
+	TotalSaleSeatpriceInDesired  decimal.Decimal    `json:"total_sale_seatprice_in_desired"`
+	TotalSaleSurchargeInDesired  decimal.Decimal    `json:"total_sale_surcharge_in_desired"`
+	TotalSaleCombinedInDesired   decimal.Decimal    `json:"total_sale_combined_in_desired"`
 	UserCommission               UserCommission     `json:"user_commission"`
 	GrossCommission              GrossCommission    `json:"gross_commission"`
 	BackendPurchaseReference     string             `json:"backend_purchase_reference"`
@@ -55,16 +74,21 @@ type Order struct {
 }
 
 type Bundle struct {
-	OrderCount     int             `json:"bundle_order_count"`
-	SourceCode     string          `json:"bundle_source_code"`
-	SourceDesc     string          `json:"bundle_source_desc"`
-	TotalCost      decimal.Decimal `json:"bundle_total_cost"`
-	TotalSeatprice decimal.Decimal `json:"bundle_total_seatprice"`
-	TotalSendCost  decimal.Decimal `json:"bundle_total_send_cost"`
-	TotalSurcharge decimal.Decimal `json:"bundle_total_surcharge"`
-	CurrencyCode   string          `json:"currency_code"`
-	Orders         []Order         `json:"order"`
-	PurchaseResult PurchaseResult  `json:"purchase_result"`
+	OrderCount              int             `json:"bundle_order_count"`
+	SourceCode              string          `json:"bundle_source_code"`
+	SourceDesc              string          `json:"bundle_source_desc"`
+	TotalCost               decimal.Decimal `json:"bundle_total_cost"`
+	TotalSeatprice          decimal.Decimal `json:"bundle_total_seatprice"`
+	TotalSendCost           decimal.Decimal `json:"bundle_total_send_cost"`
+	TotalSurcharge          decimal.Decimal `json:"bundle_total_surcharge"`
+	CurrencyCode            string          `json:"currency_code"`
+	TotalCostInDesired      decimal.Decimal `json:"bundle_total_cost_in_desired"`
+	TotalSeatpriceInDesired decimal.Decimal `json:"bundle_total_seatprice_in_desired"`
+	TotalSendCostInDesired  decimal.Decimal `json:"bundle_total_send_cost_in_desired"`
+	TotalSurchargeInDesired decimal.Decimal `json:"bundle_total_surcharge_in_desired"`
+	DesiredCurrencyCode     string          `json:"desired_currency_code"`
+	Orders                  []Order         `json:"order"`
+	PurchaseResult          PurchaseResult  `json:"purchase_result"`
 }
 
 type AgentCost struct {
@@ -87,3 +111,105 @@ type Trolley struct {
 	OrderCount      int            `json:"trolley_order_count"`
 	PurchaseResult  PurchaseResult `json:"purchase_result"`
 }
+
+// TrolleyParams are the parameters that can be passed to the GetTrolley call.
+// When PerformanceID is set the order is added to the trolley.
+type TrolleyParams struct {
+	UniversalParams
+	// the trolley to add to, empty for a new trolley.
+	Token          string
+	DepartureDate  time.Time
+	Duration       int
+	Discounts      []string
+	NumberOfSeats  int
+	PerformanceID  string
+	PriceBandCode  string
+	Seats          []string
+	SendMethod     string
+	SourceCode     string
+	TicketTypeCode string
+	// orders to take out of the trolley.
+	ItemNumbersToRemove []int
+}
+
+// Params returns the call parameters as a map
+func (params *TrolleyParams) Params() map[string]string {
+	values := make(map[string]string)
+
+	if params.Token != "" {
+		values["trolley_token"] = params.Token
+	}
+
+	if params.PerformanceID != "" {
+		values["no_of_seats"] = strconv.Itoa(params.NumberOfSeats)
+		values["perf_id"] = params.PerformanceID
+		values["price_band_code"] = params.PriceBandCode
+		values["ticket_type_code"] = params.TicketTypeCode
+
+		if !params.DepartureDate.IsZero() {
+			values["departure_date"] = params.DepartureDate.Format("20060102")
+		}
+		if params.Duration > 0 {
+			values["duration"] = strconv.Itoa(params.Duration)
+		}
+		for index, disc := range params.Discounts {
+			values[fmt.Sprintf("disc%d", index)] = disc
+		}
+		for index, seat := range params.Seats {
+			values[fmt.Sprintf("seat%d", index)] = seat
+		}
+		if params.SendMethod != "" && params.SourceCode != "" {
+			values[fmt.Sprintf("%s_send_code", params.SourceCode)] = params.SendMethod
+		}
+	}
+
+	if len(params.ItemNumbersToRemove) > 0 {
+		items := make([]string, len(params.ItemNumbersToRemove))
+		for i, item := range params.ItemNumbersToRemove {
+			items[i] = strconv.Itoa(item)
+		}
+		values["remove_items_list"] = strings.Join(items, ",")
+	}
+
+	for k, v := range params.Universal() {
+		values[k] = v
+	}
+
+	return values
+}
+
+// TrolleyResult is the result from the GetTrolley call.
+type TrolleyResult struct {
+	// identifies the trolley in later GetTrolley and MakeReservation calls.
+	Token           string              `json:"trolley_token"`
+	Trolley         Trolley             `json:"trolley_contents"`
+	CurrencyDetails map[string]Currency `json:"currency_details"`
+	// orders that couldn't be added to the trolley.
+	DiscardedOrders                []Order `json:"discarded_orders"`
+	InputContainedUnavailableOrder bool    `json:"input_contained_unavailable_order"`
+}
+
+// GetTrolley adds orders to or removes them from a trolley without reserving
+// anything. Reserve everything in the trolley by passing its token to
+// MakeReservation.
+func (client *Client) GetTrolley(ctx context.Context, params *TrolleyParams) (*TrolleyResult, error) {
+	req := NewRequest(http.MethodGet, "trolley.v1", nil)
+	if params != nil {
+		req.SetValues(params.Params())
+	}
+
+	resp, err := client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result TrolleyResult
+	decoder := json.NewDecoder(resp.Body)
+	err = decoder.Decode(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,476 @@
+package ticketswitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults used by NewWatcher.
+const (
+	DefaultWatchInterval     = time.Minute
+	DefaultWatchJitter       = 0.1
+	DefaultWatchRequestDelay = 250 * time.Millisecond
+	DefaultWatchMaxBackoff   = 10 * time.Minute
+)
+
+// WatchEventType identifies the kind of change reported by a Watcher.
+type WatchEventType string
+
+// The changes a Watcher reports.
+const (
+	// a ticket type and price band combination has appeared.
+	PriceBandAdded WatchEventType = "price_band_added"
+	// a ticket type and price band combination has gone.
+	PriceBandRemoved WatchEventType = "price_band_removed"
+	// the seat price or surcharge of a price band has changed.
+	PriceChanged WatchEventType = "price_changed"
+	// the number available has risen to or above the threshold.
+	QuantityAboveThreshold WatchEventType = "quantity_above_threshold"
+	// the number available has fallen below the threshold.
+	QuantityBelowThreshold WatchEventType = "quantity_below_threshold"
+	// a price band has become an offer.
+	OfferStarted WatchEventType = "offer_started"
+	// a price band is no longer an offer.
+	OfferEnded WatchEventType = "offer_ended"
+)
+
+// WatchEvent describes a change in the availability of a performance.
+type WatchEvent struct {
+	Type           WatchEventType `json:"type"`
+	PerformanceID  string         `json:"perf_id"`
+	TicketTypeCode string         `json:"ticket_type_code"`
+	TicketTypeDesc string         `json:"ticket_type_desc"`
+	PriceBandCode  string         `json:"price_band_code"`
+	// the price band before the change, nil when it was added.
+	Previous *PriceBand `json:"previous,omitempty"`
+	// the price band after the change, nil when it was removed.
+	Current *PriceBand `json:"current,omitempty"`
+	// when the change was seen.
+	Time time.Time `json:"time"`
+}
+
+// WatchError is reported when a performance couldn't be polled.
+type WatchError struct {
+	PerformanceID string
+	Err           error
+}
+
+func (err *WatchError) Error() string {
+	return fmt.Sprintf("ticketswitch: watching %s: %s", err.PerformanceID, err.Err)
+}
+
+func (err *WatchError) Unwrap() error {
+	return err.Err
+}
+
+// ErrBackendUnavailable is reported by a Watcher when the API says the
+// backend system for a performance is down, broken or throttled. The
+// availability from such a response is not compared.
+var ErrBackendUnavailable = errors.New("ticketswitch: backend is unavailable")
+
+// errThrottled marks errors that should make the watcher back off.
+var errThrottled = errors.New("ticketswitch: backend throttled the request")
+
+// watchedBand is a price band along with the ticket type it belongs to.
+type watchedBand struct {
+	ticketType *TicketType
+	priceBand  *PriceBand
+}
+
+func availabilityBands(result *AvailabilityResult) (map[string]watchedBand, []string) {
+	bands := make(map[string]watchedBand)
+	keys := make([]string, 0)
+	if result == nil {
+		return bands, keys
+	}
+	for i := range result.Availability.TicketTypes {
+		ticketType := &result.Availability.TicketTypes[i]
+		for j := range ticketType.PriceBands {
+			key := ticketType.Code + "/" + ticketType.PriceBands[j].Code
+			if _, ok := bands[key]; ok {
+				continue
+			}
+			bands[key] = watchedBand{ticketType: ticketType, priceBand: &ticketType.PriceBands[j]}
+			keys = append(keys, key)
+		}
+	}
+	return bands, keys
+}
+
+// DiffAvailability compares two availability results for a performance and
+// returns the changes between them, in the order of the ticket types and price
+// bands of the current result followed by any that were removed. A quantity
+// event is returned when the number available crosses the threshold.
+func DiffAvailability(perfID string, previous, current *AvailabilityResult, threshold int) []WatchEvent {
+	before, beforeKeys := availabilityBands(previous)
+	after, afterKeys := availabilityBands(current)
+	events := make([]WatchEvent, 0)
+
+	event := func(eventType WatchEventType, band watchedBand, prev, cur *PriceBand) WatchEvent {
+		return WatchEvent{
+			Type:           eventType,
+			PerformanceID:  perfID,
+			TicketTypeCode: band.ticketType.Code,
+			TicketTypeDesc: band.ticketType.Desc,
+			PriceBandCode:  band.priceBand.Code,
+			Previous:       prev,
+			Current:        cur,
+		}
+	}
+
+	for _, key := range afterKeys {
+		cur := after[key]
+		old, ok := before[key]
+		if !ok {
+			events = append(events, event(PriceBandAdded, cur, nil, cur.priceBand))
+			continue
+		}
+		prev := old.priceBand
+		if !prev.Seatprice.Equal(cur.priceBand.Seatprice) || !prev.Surcharge.Equal(cur.priceBand.Surcharge) {
+			events = append(events, event(PriceChanged, cur, prev, cur.priceBand))
+		}
+		if prev.NumberAvailable < threshold && cur.priceBand.NumberAvailable >= threshold {
+			events = append(events, event(QuantityAboveThreshold, cur, prev, cur.priceBand))
+		}
+		if prev.NumberAvailable >= threshold && cur.priceBand.NumberAvailable < threshold {
+			events = append(events, event(QuantityBelowThreshold, cur, prev, cur.priceBand))
+		}
+		if !prev.IsOffer && cur.priceBand.IsOffer {
+			events = append(events, event(OfferStarted, cur, prev, cur.priceBand))
+		}
+		if prev.IsOffer && !cur.priceBand.IsOffer {
+			events = append(events, event(OfferEnded, cur, prev, cur.priceBand))
+		}
+	}
+
+	for _, key := range beforeKeys {
+		if _, ok := after[key]; !ok {
+			old := before[key]
+			events = append(events, event(PriceBandRemoved, old, old.priceBand, nil))
+		}
+	}
+	return events
+}
+
+// cachedAvailability builds an availability result for a performance from its
+// cached availability details, using the details that apply to the date of
+// the performance.
+func cachedAvailability(perf *Performance) *AvailabilityResult {
+	result := &AvailabilityResult{}
+	for _, ticketType := range perf.AvailabilityDetails.TicketTypes {
+		tt := TicketType{Code: ticketType.Code, Desc: ticketType.Desc}
+		for _, priceBand := range ticketType.PriceBands {
+			var cheapest *AvailabilityDetail
+			band := PriceBand{Code: priceBand.Code, Desc: priceBand.Desc}
+			for i := range priceBand.Details {
+				detail := &priceBand.Details[i]
+				if !perf.Datetime.IsZero() && !detail.AvailableOn(perf.Datetime) {
+					continue
+				}
+				band.NumberAvailable += detail.CachedNumberAvailable
+				band.AvailDetails = append(band.AvailDetails, *detail)
+				if cheapest == nil || detail.CombinedPrice().LessThan(cheapest.CombinedPrice()) {
+					cheapest = detail
+				}
+			}
+			if cheapest == nil {
+				continue
+			}
+			band.Seatprice = cheapest.Seatprice
+			band.Surcharge = cheapest.Surcharge
+			band.NonOfferSeatprice = cheapest.FullSeatprice
+			band.NonOfferSurcharge = cheapest.FullSurcharge
+			band.AbsoluteSaving = cheapest.AbsoluteSaving
+			band.PercentageSaving = cheapest.PercentageSaving
+			band.IsOffer = cheapest.AbsoluteSaving.IsPositive() || cheapest.PercentageSaving.IsPositive()
+			result.CurrencyCode = cheapest.AvailabilityCurrencyCode
+			tt.PriceBands = append(tt.PriceBands, band)
+		}
+		if len(tt.PriceBands) > 0 {
+			result.Availability.TicketTypes = append(result.Availability.TicketTypes, tt)
+		}
+	}
+	return result
+}
+
+// Watcher polls the availability of a set of performances and reports the
+// changes between successive polls. Polls are spread out by RequestDelay,
+// each round is followed by a jittered wait and the watcher backs off when
+// the API rate limits it or the backend is throttled.
+//
+// The first poll of each performance records a baseline and reports nothing.
+type Watcher struct {
+	// the performances to watch.
+	PerformanceIDs []string
+	// the wait between rounds of polling.
+	Interval time.Duration
+	// the fraction of Interval, between 0 and 1, randomly added to or
+	// removed from each wait.
+	Jitter float64
+	// the minimum gap between two requests in a round.
+	RequestDelay time.Duration
+	// the longest the watcher will back off for.
+	MaxBackoff time.Duration
+	// the number available that triggers quantity events.
+	Threshold int
+	// use the cached availability details from ListPerformances rather
+	// than calling GetAvailability. The event ID of each performance is the
+	// part of its ID before the first hyphen.
+	Cached bool
+	// parameters passed to GetAvailability.
+	Params *GetAvailabilityParams
+	// called with the errors for individual performances, which don't stop
+	// the watcher.
+	OnError func(err error)
+
+	client   API
+	mu       sync.Mutex
+	previous map[string]*AvailabilityResult
+	random   *rand.Rand
+}
+
+// NewWatcher returns a Watcher for the performances with the default
+// settings.
+func NewWatcher(client API, perfIDs ...string) *Watcher {
+	return &Watcher{
+		PerformanceIDs: perfIDs,
+		Interval:       DefaultWatchInterval,
+		Jitter:         DefaultWatchJitter,
+		RequestDelay:   DefaultWatchRequestDelay,
+		MaxBackoff:     DefaultWatchMaxBackoff,
+		Threshold:      1,
+		client:         client,
+	}
+}
+
+// Poll fetches the availability of every performance once and returns the
+// changes since the previous poll. Errors for individual performances are
+// passed to OnError. Polling stops early and the error is returned when the
+// API rate limits the watcher, the backend is throttled or the context is
+// done.
+func (w *Watcher) Poll(ctx context.Context) ([]WatchEvent, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.previous == nil {
+		w.previous = make(map[string]*AvailabilityResult)
+	}
+	results, err := w.fetch(ctx)
+	now := time.Now()
+	events := make([]WatchEvent, 0)
+	for _, perfID := range w.PerformanceIDs {
+		current, ok := results[perfID]
+		if !ok {
+			continue
+		}
+		if previous, seen := w.previous[perfID]; seen {
+			for _, event := range DiffAvailability(perfID, previous, current, w.Threshold) {
+				event.Time = now
+				events = append(events, event)
+			}
+		}
+		w.previous[perfID] = current
+	}
+	return events, err
+}
+
+// fetch returns the usable availability of each performance.
+func (w *Watcher) fetch(ctx context.Context) (map[string]*AvailabilityResult, error) {
+	results := make(map[string]*AvailabilityResult)
+	first := true
+	wait := func() error {
+		if !first && w.RequestDelay > 0 {
+			if err := sleep(ctx, w.RequestDelay); err != nil {
+				return err
+			}
+		}
+		first = false
+		return nil
+	}
+
+	if w.Cached {
+		perfIDs := make(map[string]bool)
+		eventIDs := make([]string, 0)
+		seenEvents := make(map[string]bool)
+		for _, perfID := range w.PerformanceIDs {
+			perfIDs[perfID] = true
+			eventID := strings.SplitN(perfID, "-", 2)[0]
+			if !seenEvents[eventID] {
+				seenEvents[eventID] = true
+				eventIDs = append(eventIDs, eventID)
+			}
+		}
+		for _, eventID := range eventIDs {
+			params := &ListPerformancesParams{
+				EventID:             eventID,
+				RequireCostRange:    true,
+				RequestAvailDetails: true,
+			}
+			if w.Params != nil {
+				params.UniversalParams = w.Params.UniversalParams
+			}
+			for {
+				if err := wait(); err != nil {
+					return results, err
+				}
+				perfs, err := w.client.ListPerformances(ctx, params)
+				if err != nil {
+					if err := w.report(ctx, eventID, err); err != nil {
+						return results, err
+					}
+					break
+				}
+				for i := range perfs.Performances {
+					perf := &perfs.Performances[i]
+					if perfIDs[perf.ID] {
+						results[perf.ID] = cachedAvailability(perf)
+					}
+				}
+				next, more := perfs.PagingStatus.NextPage()
+				if !more {
+					break
+				}
+				params.PageNumber = next
+			}
+		}
+		return results, nil
+	}
+
+	for _, perfID := range w.PerformanceIDs {
+		if err := wait(); err != nil {
+			return results, err
+		}
+		result, err := w.client.GetAvailability(ctx, perfID, w.Params)
+		if err == nil && result.BackendThrottleFailed {
+			err = errThrottled
+		} else if err == nil && (result.BackendIsDown || result.BackendIsBroken) {
+			err = ErrBackendUnavailable
+		}
+		if err != nil {
+			if err := w.report(ctx, perfID, err); err != nil {
+				return results, err
+			}
+			continue
+		}
+		results[perfID] = result
+	}
+	return results, nil
+}
+
+// report passes the error to OnError and returns it when polling should stop.
+func (w *Watcher) report(ctx context.Context, perfID string, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	watchErr := &WatchError{PerformanceID: perfID, Err: err}
+	if errors.Is(err, errThrottled) {
+		watchErr.Err = ErrBackendUnavailable
+	}
+	if w.OnError != nil {
+		w.OnError(watchErr)
+	}
+	var apiErr Error
+	if errors.Is(err, errThrottled) || (errors.As(err, &apiErr) && apiErr.RateLimited) {
+		return watchErr
+	}
+	return nil
+}
+
+// wait returns the jittered interval to wait after a round, or the backoff
+// after a rate limited round.
+func (w *Watcher) wait(backoff time.Duration) time.Duration {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	if backoff > interval {
+		return backoff
+	}
+	jitter := w.Jitter
+	if jitter > 1 {
+		jitter = 1
+	}
+	if jitter > 0 {
+		if w.random == nil {
+			w.random = rand.New(rand.NewSource(time.Now().UnixNano()))
+		}
+		interval += time.Duration(float64(interval) * jitter * (2*w.random.Float64() - 1))
+	}
+	return interval
+}
+
+// backoff returns the wait after a rate limited round, doubling the previous
+// backoff and honouring any Retry-After sent by the API.
+func (w *Watcher) backoff(previous time.Duration, err error) time.Duration {
+	next := previous * 2
+	if next < w.Interval {
+		next = w.Interval
+	}
+	if next <= 0 {
+		next = DefaultWatchInterval
+	}
+	if w.MaxBackoff > 0 && next > w.MaxBackoff {
+		next = w.MaxBackoff
+	}
+	var apiErr Error
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > next {
+		next = apiErr.RetryAfter
+	}
+	return next
+}
+
+// Run polls until the context is done, calling handler with each change. It
+// always returns the context's error.
+func (w *Watcher) Run(ctx context.Context, handler func(WatchEvent)) error {
+	var backoff time.Duration
+	for {
+		events, err := w.Poll(ctx)
+		for _, event := range events {
+			handler(event)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			backoff = w.backoff(backoff, err)
+		} else {
+			backoff = 0
+		}
+		if err := sleep(ctx, w.wait(backoff)); err != nil {
+			return err
+		}
+	}
+}
+
+// Watch runs the watcher in a goroutine, sending each change on the returned
+// channel. The channel is closed when the context is done.
+func (w *Watcher) Watch(ctx context.Context) <-chan WatchEvent {
+	events := make(chan WatchEvent)
+	go func() {
+		defer close(events)
+		_ = w.Run(ctx, func(event WatchEvent) {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return events
+}
+
+// sleep waits for the duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
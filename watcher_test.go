@@ -0,0 +1,239 @@
+package ticketswitch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadAvailability(t *testing.T) *AvailabilityResult {
+	data, err := os.ReadFile("testdata/availability.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result AvailabilityResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return &result
+}
+
+// changeAvailability makes one of every kind of change to the fixture.
+func changeAvailability(result *AvailabilityResult) {
+	stalls := &result.Availability.TicketTypes[0]
+	circle := &result.Availability.TicketTypes[1]
+	stalls.PriceBands[0].Seatprice = decimal.NewFromInt(45)
+	stalls.PriceBands[1].NumberAvailable = 0
+	circle.PriceBands[0].IsOffer = true
+	circle.PriceBands[1] = PriceBand{Code: "C/pool", NumberAvailable: 2}
+}
+
+func watchEventTypes(events []WatchEvent) []string {
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = string(event.Type) + " " + event.TicketTypeCode + " " + event.PriceBandCode
+	}
+	return types
+}
+
+func TestDiffAvailability(t *testing.T) {
+	previous := loadAvailability(t)
+	current := loadAvailability(t)
+
+	assert.Empty(t, DiffAvailability("7AA-5", previous, current, 1))
+
+	changeAvailability(current)
+	events := DiffAvailability("7AA-5", previous, current, 1)
+	assert.Equal(t, []string{
+		"price_changed STALLS A/pool",
+		"quantity_below_threshold STALLS B/pool",
+		"offer_started CIRCLE A/pool",
+		"price_band_added CIRCLE C/pool",
+		"price_band_removed CIRCLE B/pool",
+	}, watchEventTypes(events))
+	assert.Equal(t, "7AA-5", events[0].PerformanceID)
+	assert.Equal(t, "50", events[0].Previous.Seatprice.String())
+	assert.Equal(t, "45", events[0].Current.Seatprice.String())
+	assert.Nil(t, events[3].Previous)
+	assert.Nil(t, events[4].Current)
+
+	events = DiffAvailability("7AA-5", current, previous, 5)
+	assert.Equal(t, []string{
+		"price_changed STALLS A/pool",
+		"quantity_above_threshold STALLS B/pool",
+		"offer_ended CIRCLE A/pool",
+		"price_band_added CIRCLE B/pool",
+		"price_band_removed CIRCLE C/pool",
+	}, watchEventTypes(events))
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	responses := make(chan func(http.ResponseWriter), 10)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/f13/availability.v1", r.URL.Path)
+			assert.Equal(t, "7AA-5", r.URL.Query().Get("perf_id"))
+			(<-responses)(w)
+		}))
+	defer server.Close()
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+
+	respond := func(result *AvailabilityResult) {
+		responses <- func(w http.ResponseWriter) {
+			json.NewEncoder(w).Encode(result)
+		}
+	}
+
+	var errs []error
+	watcher := NewWatcher(client, "7AA-5")
+	watcher.RequestDelay = 0
+	watcher.OnError = func(err error) { errs = append(errs, err) }
+
+	respond(loadAvailability(t))
+	events, err := watcher.Poll(context.Background())
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+
+	throttled := loadAvailability(t)
+	throttled.BackendThrottleFailed = true
+	throttled.Availability.TicketTypes = nil
+	respond(throttled)
+	events, err = watcher.Poll(context.Background())
+	assert.Empty(t, events)
+	assert.True(t, errors.Is(err, ErrBackendUnavailable))
+	assert.Len(t, errs, 1)
+
+	changed := loadAvailability(t)
+	changeAvailability(changed)
+	respond(changed)
+	events, err = watcher.Poll(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, events, 5)
+	assert.False(t, events[0].Time.IsZero())
+
+	responses <- func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+	_, err = watcher.Poll(context.Background())
+	var apiErr Error
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.True(t, apiErr.RateLimited)
+	}
+	assert.Len(t, errs, 2)
+}
+
+func TestWatcher_Cached(t *testing.T) {
+	data, err := os.ReadFile("testdata/performances_avail_details.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := false
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/f13/performances.v1", r.URL.Path)
+			assert.Equal(t, "6IF", r.URL.Query().Get("event_id"))
+			assert.Equal(t, "yes", r.URL.Query().Get("req_avail_details"))
+			if !changed {
+				w.Write(data)
+				return
+			}
+			var doc map[string]interface{}
+			json.Unmarshal(data, &doc)
+			perf := doc["results"].(map[string]interface{})["performance"].([]interface{})[0].(map[string]interface{})
+			stalls := perf["avail_details"].(map[string]interface{})["ticket_type"].([]interface{})[1].(map[string]interface{})
+			detail := stalls["price_band"].([]interface{})[0].(map[string]interface{})["avail_detail"].([]interface{})[0].(map[string]interface{})
+			detail["cached_number_available"] = 0
+			json.NewEncoder(w).Encode(doc)
+		}))
+	defer server.Close()
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+
+	watcher := NewWatcher(client, "6IF-C5O", "6IF-C5P")
+	watcher.Cached = true
+	watcher.Threshold = 5
+
+	events, err := watcher.Poll(context.Background())
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+	if assert.Contains(t, watcher.previous, "6IF-C5O") {
+		cached := watcher.previous["6IF-C5O"]
+		assert.Equal(t, 2, len(cached.Availability.TicketTypes))
+		assert.Equal(t, 12, cached.Availability.TicketTypes[0].PriceBands[0].NumberAvailable)
+	}
+
+	changed = true
+	events, err = watcher.Poll(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"quantity_below_threshold STALLS A"}, watchEventTypes(events))
+}
+
+func TestWatcher_wait(t *testing.T) {
+	watcher := NewWatcher(nil)
+	watcher.Interval = time.Minute
+	watcher.MaxBackoff = 5 * time.Minute
+	for i := 0; i < 100; i++ {
+		wait := watcher.wait(0)
+		assert.True(t, wait >= 54*time.Second && wait <= 66*time.Second, wait)
+	}
+
+	backoff := watcher.backoff(0, errThrottled)
+	assert.Equal(t, time.Minute, backoff)
+	backoff = watcher.backoff(backoff, errThrottled)
+	assert.Equal(t, 2*time.Minute, backoff)
+	assert.Equal(t, 2*time.Minute, watcher.wait(backoff))
+	backoff = watcher.backoff(4*time.Minute, errThrottled)
+	assert.Equal(t, 5*time.Minute, backoff)
+	backoff = watcher.backoff(0, Error{RateLimited: true, RetryAfter: 3 * time.Minute})
+	assert.Equal(t, 3*time.Minute, backoff)
+}
+
+func TestWatcher_zero_value(t *testing.T) {
+	watcher := &Watcher{Interval: time.Minute, Jitter: 0.5}
+	wait := watcher.wait(0)
+	assert.True(t, wait >= 30*time.Second && wait <= 90*time.Second, wait)
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(loadAvailability(t))
+		}))
+	defer server.Close()
+	watcher.PerformanceIDs = []string{"7AA-5"}
+	watcher.client = NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+	for i := 0; i < 2; i++ {
+		events, err := watcher.Poll(context.Background())
+		assert.Nil(t, err)
+		assert.Empty(t, events)
+	}
+}
+
+func TestWatcher_Watch(t *testing.T) {
+	first := true
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			result := loadAvailability(t)
+			if !first {
+				changeAvailability(result)
+			}
+			first = false
+			json.NewEncoder(w).Encode(result)
+		}))
+	defer server.Close()
+	client := NewClient(&Config{BaseURL: server.URL, User: "bill", Password: "hahaha"})
+
+	watcher := NewWatcher(client, "7AA-5")
+	watcher.Interval = time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	event, ok := <-watcher.Watch(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, PriceChanged, event.Type)
+}